@@ -8,6 +8,7 @@ import (
 	"image/color"
 
 	"gioui.org/io/event"
+	"gioui.org/io/input"
 	"gioui.org/io/key"
 	"gioui.org/op"
 
@@ -207,6 +208,20 @@ type driver interface {
 	ProcessEvent(e event.Event)
 }
 
+// clipboardFormatsDriver is implemented by drivers that support clipboard
+// content in formats other than text.
+type clipboardFormatsDriver interface {
+	// WriteClipboardFormats requests a clipboard write of content in
+	// several formats, the first of which is the primary format.
+	WriteClipboardFormats(formats []input.ClipboardFormat)
+	// ReadClipboardTypes requests the clipboard content in the first of
+	// types offered by the clipboard.
+	ReadClipboardTypes(types []string)
+	// ReadClipboardTypeList requests the types offered by the clipboard,
+	// delivered as a clipboard.TypesEvent.
+	ReadClipboardTypeList()
+}
+
 type windowRendezvous struct {
 	in      chan windowAndConfig
 	out     chan windowAndConfig
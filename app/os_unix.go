@@ -7,6 +7,8 @@ package app
 
 import (
 	"errors"
+	"slices"
+	"strings"
 	"unsafe"
 
 	"gioui.org/io/pointer"
@@ -97,3 +99,45 @@ var xCursor = [...]string{
 	pointer.CursorNorthEastSouthWestResize: "fd_double_arrow",
 	pointer.CursorNorthWestSouthEastResize: "bd_double_arrow",
 }
+
+// textClipboardTargets are the X11 targets and Wayland MIME types of
+// clipboard text, in order of preference.
+var textClipboardTargets = []string{"UTF8_STRING", "text/plain;charset=utf-8", "text/plain;charset=utf8", "text/plain", "TEXT", "STRING"}
+
+// clipboardTypes returns the MIME types of the clipboard offering targets,
+// with text reported as "application/text". Targets that aren't MIME types,
+// such as TARGETS or TIMESTAMP, are omitted.
+func clipboardTypes(targets []string) []string {
+	var types []string
+	for _, t := range targets {
+		switch {
+		case slices.Contains(textClipboardTargets, t):
+			t = "application/text"
+		case !strings.Contains(t, "/"):
+			continue
+		}
+		if !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// clipboardTarget returns the first of the MIME types wanted that is
+// offered by targets, along with the target to request.
+func clipboardTarget(wanted, targets []string) (mime, target string, ok bool) {
+	for _, mime := range wanted {
+		if mime != "application/text" {
+			if slices.Contains(targets, mime) {
+				return mime, mime, true
+			}
+			continue
+		}
+		for _, t := range textClipboardTargets {
+			if slices.Contains(targets, t) {
+				return mime, t, true
+			}
+		}
+	}
+	return "", "", false
+}
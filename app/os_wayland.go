@@ -26,7 +26,9 @@ import (
 	"gioui.org/app/internal/xkb"
 	"gioui.org/f32"
 	"gioui.org/internal/fling"
+	"gioui.org/io/clipboard"
 	"gioui.org/io/event"
+	"gioui.org/io/input"
 	"gioui.org/io/key"
 	"gioui.org/io/pointer"
 	"gioui.org/io/system"
@@ -130,13 +132,14 @@ type wlSeat struct {
 	offers map[*C.struct_wl_data_offer][]string
 	// clipboard is the wl_data_offer for the clipboard.
 	clipboard *C.struct_wl_data_offer
-	// mimeType is the chosen mime type of clipboard.
-	mimeType string
 	// source represents the clipboard content of the most recent
 	// clipboard write, if any.
 	source *C.struct_wl_data_source
-	// content is the data belonging to source.
+	// content is the text belonging to source, or nil.
 	content []byte
+	// formats maps the other mime types offered by source
+	// to their data.
+	formats map[string][]byte
 }
 
 type repeatState struct {
@@ -214,6 +217,8 @@ type window struct {
 	inCompositor bool        // window is moving or being resized
 
 	clipReads chan transfer.DataEvent
+	// clipTypes is the pending clipboard.TypesEvent, if any.
+	clipTypes *clipboard.TypesEvent
 
 	wakeups chan struct{}
 
@@ -242,10 +247,6 @@ type wlOutput struct {
 // in C is forbidden.
 var callbackMap sync.Map
 
-// clipboardMimeTypes is a list of supported clipboard mime types, in
-// order of preference.
-var clipboardMimeTypes = []string{"text/plain;charset=utf8", "UTF8_STRING", "text/plain", "TEXT", "STRING"}
-
 var (
 	newWaylandEGLContext    func(w *window) (context, error)
 	newWaylandVulkanContext func(w *window) (context, error)
@@ -280,7 +281,7 @@ func newWLWindow(callbacks *callbacks, options []Option) error {
 	return nil
 }
 
-func (d *wlDisplay) writeClipboard(content []byte) error {
+func (d *wlDisplay) writeClipboard(formats []input.ClipboardFormat) error {
 	s := d.seat
 	if s == nil {
 		return nil
@@ -290,21 +291,51 @@ func (d *wlDisplay) writeClipboard(content []byte) error {
 		C.wl_data_source_destroy(s.source)
 		s.source = nil
 		s.content = nil
+		s.formats = nil
 	}
 	if d.dataDeviceManager == nil || s.dataDev == nil {
 		return nil
 	}
-	s.content = content
+	s.formats = make(map[string][]byte)
+	for _, f := range formats {
+		if f.Type == "application/text" {
+			if s.content == nil {
+				s.content = f.Data
+			}
+			continue
+		}
+		s.formats[f.Type] = f.Data
+	}
 	s.source = C.wl_data_device_manager_create_data_source(d.dataDeviceManager)
 	C.wl_data_source_add_listener(s.source, &C.gio_data_source_listener, unsafe.Pointer(s.seat))
-	for _, mime := range clipboardMimeTypes {
-		C.wl_data_source_offer(s.source, C.CString(mime))
+	offer := func(mime string) {
+		cmime := C.CString(mime)
+		defer C.free(unsafe.Pointer(cmime))
+		C.wl_data_source_offer(s.source, cmime)
+	}
+	if s.content != nil {
+		for _, mime := range textClipboardTargets {
+			offer(mime)
+		}
+	}
+	for mime := range s.formats {
+		offer(mime)
 	}
 	C.wl_data_device_set_selection(s.dataDev, s.source, s.serial)
 	return nil
 }
 
-func (d *wlDisplay) readClipboard() (io.ReadCloser, error) {
+// clipboardTypes returns the mime types offered by the clipboard.
+func (d *wlDisplay) clipboardTypes() []string {
+	s := d.seat
+	if s == nil || s.clipboard == nil {
+		return nil
+	}
+	return s.offers[s.clipboard]
+}
+
+// readClipboard requests the clipboard content in the offered mime type.
+func (d *wlDisplay) readClipboard(mime string) (io.ReadCloser, error) {
 	s := d.seat
 	if s == nil {
 		return nil, nil
@@ -319,7 +350,7 @@ func (d *wlDisplay) readClipboard() (io.ReadCloser, error) {
 	// wl_data_offer_receive performs and implicit dup(2) of the write end
 	// of the pipe. Close our version.
 	defer w.Close()
-	cmimeType := C.CString(s.mimeType)
+	cmimeType := C.CString(mime)
 	defer C.free(unsafe.Pointer(cmimeType))
 	C.wl_data_offer_receive(s.clipboard, cmimeType, C.int(w.Fd()))
 	return r, nil
@@ -750,18 +781,7 @@ func gio_onDataDeviceDrop(data unsafe.Pointer, dataDev *C.struct_wl_data_device)
 func gio_onDataDeviceSelection(data unsafe.Pointer, dataDev *C.struct_wl_data_device, id *C.struct_wl_data_offer) {
 	s := callbackLoad(data).(*wlSeat)
 	defer s.flushOffers()
-	s.clipboard = nil
-loop:
-	for _, want := range clipboardMimeTypes {
-		for _, got := range s.offers[id] {
-			if want != got {
-				continue
-			}
-			s.clipboard = id
-			s.mimeType = got
-			break loop
-		}
-	}
+	s.clipboard = id
 }
 
 //export gio_onRegistryGlobalRemove
@@ -1025,12 +1045,27 @@ func gio_onPointerAxisDiscrete(data unsafe.Pointer, p *C.struct_wl_pointer, axis
 }
 
 func (w *window) ReadClipboard() {
+	w.ReadClipboardTypes(nil)
+}
+
+func (w *window) ReadClipboardTypes(types []string) {
 	if w.disp.readClipClose != nil {
 		return
 	}
+	if len(types) == 0 {
+		types = []string{"application/text"}
+	}
 	w.disp.readClipClose = make(chan struct{})
-	r, err := w.disp.readClipboard()
+	mime, target, ok := clipboardTarget(types, w.disp.clipboardTypes())
+	var r io.ReadCloser
+	var err error
+	if ok {
+		r, err = w.disp.readClipboard(target)
+	}
 	if r == nil || err != nil {
+		// Release the readers, the clipboard can't be read in their types.
+		w.clipReads <- transfer.DataEvent{}
+		w.disp.wakeup()
 		return
 	}
 	// Don't let slow clipboard transfers block event loop.
@@ -1038,7 +1073,7 @@ func (w *window) ReadClipboard() {
 		defer r.Close()
 		data, _ := io.ReadAll(r)
 		e := transfer.DataEvent{
-			Type: "application/text",
+			Type: mime,
 			Open: func() io.ReadCloser {
 				return io.NopCloser(bytes.NewReader(data))
 			},
@@ -1051,8 +1086,16 @@ func (w *window) ReadClipboard() {
 	}()
 }
 
+func (w *window) ReadClipboardTypeList() {
+	w.clipTypes = &clipboard.TypesEvent{Types: clipboardTypes(w.disp.clipboardTypes())}
+}
+
 func (w *window) WriteClipboard(mime string, s []byte) {
-	w.disp.writeClipboard(s)
+	w.disp.writeClipboard([]input.ClipboardFormat{{Type: "application/text", Data: s}})
+}
+
+func (w *window) WriteClipboardFormats(formats []input.ClipboardFormat) {
+	w.disp.writeClipboard(formats)
 }
 
 func (w *window) Configure(options []Option) {
@@ -1387,6 +1430,11 @@ func (w *window) dispatch() {
 		w.w.Invalidate()
 		return
 	}
+	if e := w.clipTypes; e != nil {
+		w.clipTypes = nil
+		w.ProcessEvent(*e)
+		return
+	}
 	if err := w.disp.dispatch(); err != nil || w.closing {
 		w.close(err)
 		return
@@ -1596,7 +1644,10 @@ func gio_onDataSourceTarget(data unsafe.Pointer, source *C.struct_wl_data_source
 //export gio_onDataSourceSend
 func gio_onDataSourceSend(data unsafe.Pointer, source *C.struct_wl_data_source, mime *C.char, fd C.int32_t) {
 	s := callbackLoad(data).(*wlSeat)
-	content := s.content
+	content, ok := s.formats[C.GoString(mime)]
+	if !ok {
+		content = s.content
+	}
 	go func() {
 		defer syscall.Close(int(fd))
 		syscall.Write(int(fd), content)
@@ -1608,6 +1659,7 @@ func gio_onDataSourceCancelled(data unsafe.Pointer, source *C.struct_wl_data_sou
 	s := callbackLoad(data).(*wlSeat)
 	if s.source == source {
 		s.content = nil
+		s.formats = nil
 		s.source = nil
 	}
 	C.wl_data_source_destroy(source)
@@ -28,6 +28,7 @@ package app
 import "C"
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"image"
@@ -39,7 +40,9 @@ import (
 	"unsafe"
 
 	"gioui.org/f32"
+	"gioui.org/io/clipboard"
 	"gioui.org/io/event"
+	"gioui.org/io/input"
 	"gioui.org/io/key"
 	"gioui.org/io/pointer"
 	"gioui.org/io/system"
@@ -81,6 +84,8 @@ type x11Window struct {
 		evDelWindow C.Atom
 		// "ATOM"
 		atom C.Atom
+		// "INCR", the type of incremental selection transfers.
+		incr C.Atom
 		// "GTK_TEXT_BUFFER_CONTENTS"
 		gtk_text_buffer_contents C.Atom
 		// "_NET_WM_NAME"
@@ -106,7 +111,18 @@ type x11Window struct {
 	pointerBtns pointer.Buttons
 
 	clipboard struct {
+		// content is the text of the clipboard, or nil.
 		content []byte
+		// formats maps the targets of the other formats
+		// of the clipboard to their data.
+		formats map[C.Atom][]byte
+		// readTypes are the MIME types of a pending read
+		// awaiting the clipboard targets.
+		readTypes []string
+		// readType is the MIME type being converted.
+		readType string
+		// listTypes is set while a clipboard.TypesEvent is pending.
+		listTypes bool
 	}
 	cursor pointer.Cursor
 	config Config
@@ -152,16 +168,144 @@ func (w *x11Window) SetAnimating(anim bool) {
 }
 
 func (w *x11Window) ReadClipboard() {
+	w.clipboard.readType = ""
 	C.XDeleteProperty(w.x, w.xw, w.atoms.clipboardContent)
 	C.XConvertSelection(w.x, w.atoms.clipboard, w.atoms.utf8string, w.atoms.clipboardContent, w.xw, C.CurrentTime)
 }
 
 func (w *x11Window) WriteClipboard(mime string, s []byte) {
 	w.clipboard.content = s
+	w.clipboard.formats = nil
 	C.XSetSelectionOwner(w.x, w.atoms.clipboard, w.xw, C.CurrentTime)
 	C.XSetSelectionOwner(w.x, w.atoms.primary, w.xw, C.CurrentTime)
 }
 
+func (w *x11Window) WriteClipboardFormats(formats []input.ClipboardFormat) {
+	w.clipboard.content = nil
+	w.clipboard.formats = make(map[C.Atom][]byte)
+	for _, f := range formats {
+		if f.Type == "application/text" {
+			if w.clipboard.content == nil {
+				w.clipboard.content = f.Data
+			}
+			continue
+		}
+		w.clipboard.formats[w.atom(f.Type, false)] = f.Data
+	}
+	C.XSetSelectionOwner(w.x, w.atoms.clipboard, w.xw, C.CurrentTime)
+	C.XSetSelectionOwner(w.x, w.atoms.primary, w.xw, C.CurrentTime)
+}
+
+func (w *x11Window) ReadClipboardTypes(types []string) {
+	if len(types) == 0 || len(types) == 1 && types[0] == "application/text" {
+		// Only text is wanted; skip the round-trip for the targets.
+		w.ReadClipboard()
+		return
+	}
+	w.clipboard.readTypes = types
+	w.requestTargets()
+}
+
+func (w *x11Window) ReadClipboardTypeList() {
+	w.clipboard.listTypes = true
+	w.requestTargets()
+}
+
+// requestTargets requests the list of targets offered by the
+// clipboard owner.
+func (w *x11Window) requestTargets() {
+	C.XDeleteProperty(w.x, w.xw, w.atoms.clipboardContent)
+	C.XConvertSelection(w.x, w.atoms.clipboard, w.atoms.targets, w.atoms.clipboardContent, w.xw, C.CurrentTime)
+}
+
+// clipboardTargets handles the targets offered by the clipboard owner,
+// stored in prop.
+func (w *x11Window) clipboardTargets(prop C.Atom) {
+	var targets []string
+	if prop != C.None {
+		for _, a := range w.propertyAtoms(prop) {
+			name := C.XGetAtomName(w.x, a)
+			if name == nil {
+				continue
+			}
+			targets = append(targets, C.GoString(name))
+			C.XFree(unsafe.Pointer(name))
+		}
+	}
+	if w.clipboard.listTypes {
+		w.clipboard.listTypes = false
+		w.ProcessEvent(clipboard.TypesEvent{Types: clipboardTypes(targets)})
+	}
+	types := w.clipboard.readTypes
+	if types == nil {
+		return
+	}
+	w.clipboard.readTypes = nil
+	mime, target, ok := clipboardTarget(types, targets)
+	if !ok {
+		// Release the readers waiting for the missing types.
+		w.ProcessEvent(transfer.DataEvent{})
+		return
+	}
+	w.clipboard.readType = mime
+	C.XDeleteProperty(w.x, w.xw, w.atoms.clipboardContent)
+	C.XConvertSelection(w.x, w.atoms.clipboard, w.atom(target, false), w.atoms.clipboardContent, w.xw, C.CurrentTime)
+}
+
+// selectionContent returns the clipboard content for the selection
+// target.
+func (w *x11Window) selectionContent(target C.Atom) ([]byte, bool) {
+	switch target {
+	case w.atoms.plaintext, w.atoms.utf8string, w.atoms.gtk_text_buffer_contents:
+		return w.clipboard.content, w.clipboard.content != nil
+	default:
+		data, ok := w.clipboard.formats[target]
+		return data, ok
+	}
+}
+
+// property reads and deletes the window property prop. The caller must
+// free the returned value with XFree.
+func (w *x11Window) property(prop C.Atom) (typ C.Atom, format C.int, nitems C.ulong, value *C.uchar, ok bool) {
+	var after C.ulong
+	// Request up to 64 MiB, in units of 32 bits.
+	const maxLen = 1 << 24
+	st := C.XGetWindowProperty(w.x, w.xw, prop, 0, maxLen, C.Bool(C.True), C.Atom(C.AnyPropertyType),
+		&typ, &format, &nitems, &after, &value)
+	if st != C.Success || value == nil {
+		return 0, 0, 0, nil, false
+	}
+	return typ, format, nitems, value, true
+}
+
+// propertyAtoms returns the list of atoms stored in prop.
+func (w *x11Window) propertyAtoms(prop C.Atom) []C.Atom {
+	typ, format, nitems, value, ok := w.property(prop)
+	if !ok {
+		return nil
+	}
+	defer C.XFree(unsafe.Pointer(value))
+	if typ != w.atoms.atom || format != 32 {
+		return nil
+	}
+	// Xlib returns 32-bit formats as arrays of longs.
+	return append([]C.Atom(nil), unsafe.Slice((*C.Atom)(unsafe.Pointer(value)), int(nitems))...)
+}
+
+// propertyBytes returns the bytes stored in prop.
+func (w *x11Window) propertyBytes(prop C.Atom) ([]byte, bool) {
+	typ, format, nitems, value, ok := w.property(prop)
+	if !ok {
+		return nil, false
+	}
+	defer C.XFree(unsafe.Pointer(value))
+	// Incremental transfers of large data are not supported.
+	if typ == w.atoms.incr || format != 8 {
+		return nil, false
+	}
+	return C.GoBytes(unsafe.Pointer(value), C.int(nitems)), true
+}
+
 func (w *x11Window) Configure(options []Option) {
 	var shints C.XSizeHints
 	prev := w.config
@@ -669,19 +813,46 @@ func (h *x11EventHandler) handleEvents() bool {
 		case C.SelectionNotify:
 			cevt := (*C.XSelectionEvent)(unsafe.Pointer(xev))
 			prop := w.atoms.clipboardContent
+			if cevt.selection != w.atoms.clipboard {
+				break
+			}
+			if cevt.target == w.atoms.targets {
+				w.clipboardTargets(cevt.property)
+				break
+			}
+			if cevt.property == C.None {
+				// The conversion failed; release the readers.
+				w.clipboard.readType = ""
+				w.ProcessEvent(transfer.DataEvent{})
+				break
+			}
 			if cevt.property != prop {
 				break
 			}
-			if cevt.selection != w.atoms.clipboard {
+			if mime := w.clipboard.readType; mime != "" {
+				w.clipboard.readType = ""
+				data, ok := w.propertyBytes(prop)
+				if !ok {
+					w.ProcessEvent(transfer.DataEvent{})
+					break
+				}
+				w.ProcessEvent(transfer.DataEvent{
+					Type: mime,
+					Open: func() io.ReadCloser {
+						return io.NopCloser(bytes.NewReader(data))
+					},
+				})
 				break
 			}
 			var text C.XTextProperty
 			if st := C.XGetTextProperty(w.x, w.xw, &text, prop); st == 0 {
-				// Failed; ignore.
+				// Failed; release the readers.
+				w.ProcessEvent(transfer.DataEvent{})
 				break
 			}
 			if text.format != 8 || text.encoding != w.atoms.utf8string {
 				// Ignore non-utf-8 encoded strings.
+				w.ProcessEvent(transfer.DataEvent{})
 				break
 			}
 			str := C.GoStringN((*C.char)(unsafe.Pointer(text.value)), C.int(text.nitems))
@@ -715,21 +886,30 @@ func (h *x11EventHandler) handleEvents() bool {
 			case w.atoms.targets:
 				// The requestor wants the supported clipboard
 				// formats. First write the targets...
-				formats := [...]C.long{
-					C.long(w.atoms.targets),
-					C.long(w.atoms.utf8string),
-					C.long(w.atoms.plaintext),
-					// GTK clients need this.
-					C.long(w.atoms.gtk_text_buffer_contents),
+				formats := []C.long{C.long(w.atoms.targets)}
+				if w.clipboard.content != nil {
+					formats = append(formats,
+						C.long(w.atoms.utf8string),
+						C.long(w.atoms.plaintext),
+						// GTK clients need this.
+						C.long(w.atoms.gtk_text_buffer_contents),
+					)
+				}
+				for target := range w.clipboard.formats {
+					formats = append(formats, C.long(target))
 				}
 				C.XChangeProperty(w.x, cevt.requestor, cevt.property, w.atoms.atom,
 					32 /* bitwidth of formats */, C.PropModeReplace,
-					(*C.uchar)(unsafe.Pointer(&formats)), C.int(len(formats)),
+					(*C.uchar)(unsafe.Pointer(&formats[0])), C.int(len(formats)),
 				)
 				// ...then notify the requestor.
 				notify()
-			case w.atoms.plaintext, w.atoms.utf8string, w.atoms.gtk_text_buffer_contents:
-				content := w.clipboard.content
+			default:
+				content, ok := w.selectionContent(cevt.target)
+				if !ok {
+					// Unsupported target.
+					break
+				}
 				var ptr *C.uchar
 				if len(content) > 0 {
 					ptr = (*C.uchar)(unsafe.Pointer(&content[0]))
@@ -852,6 +1032,7 @@ func newX11Window(gioWin *callbacks, options []Option) error {
 	w.atoms.primary = w.atom("PRIMARY", false)
 	w.atoms.clipboardContent = w.atom("CLIPBOARD_CONTENT", false)
 	w.atoms.atom = w.atom("ATOM", false)
+	w.atoms.incr = w.atom("INCR", false)
 	w.atoms.targets = w.atom("TARGETS", false)
 	w.atoms.wmName = w.atom("_NET_WM_NAME", false)
 	w.atoms.wmState = w.atom("_NET_WM_STATE", false)
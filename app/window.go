@@ -253,11 +253,23 @@ func (w *Window) updateState() {
 	if hint, ok := q.TextInputHint(); ok {
 		w.driver.SetInputHint(hint)
 	}
-	if mime, txt, ok := q.WriteClipboard(); ok {
-		w.driver.WriteClipboard(mime, txt)
-	}
-	if q.ClipboardRequested() {
-		w.driver.ReadClipboard()
+	if cd, ok := w.driver.(clipboardFormatsDriver); ok {
+		if formats, ok := q.WriteClipboardFormats(); ok {
+			cd.WriteClipboardFormats(formats)
+		}
+		if q.ClipboardRequested() {
+			cd.ReadClipboardTypes(q.ClipboardTypes())
+		}
+		if q.ClipboardTypesRequested() {
+			cd.ReadClipboardTypeList()
+		}
+	} else {
+		if mime, txt, ok := q.WriteClipboard(); ok {
+			w.driver.WriteClipboard(mime, txt)
+		}
+		if q.ClipboardTextRequested() {
+			w.driver.ReadClipboard()
+		}
 	}
 	oldState := w.imeState
 	newState := oldState
@@ -0,0 +1,155 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package opentype
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+
+	giofont "gioui.org/font"
+	fontapi "github.com/go-text/typesetting/font"
+	"github.com/go-text/typesetting/font/opentype"
+	"github.com/go-text/typesetting/font/opentype/tables"
+)
+
+// LazyFace is a Face whose font tables are parsed the first time the face is
+// used rather than when it is created. Text shapers only parse a LazyFace when
+// they need it to display some text, which reduces the startup cost of
+// applications that bundle many large fonts.
+//
+// LazyFace is safe for concurrent use.
+type LazyFace struct {
+	font giofont.Font
+
+	mu     sync.Mutex
+	loader *opentype.Loader
+	face   *fontapi.Font
+	err    error
+	// cmap is the character map of the face, parsed by Covers before the
+	// face is loaded.
+	cmap fontapi.Cmap
+}
+
+// ParseCollectionLazy is like ParseCollection, but defers parsing the faces of
+// the collection until they are first used. Only the table directory and the
+// naming metadata of each face are read up front, so errors in the remaining
+// tables are reported by [LazyFace.Load]. The Face of every returned FontFace
+// is a *LazyFace.
+//
+// The src slice must not be modified after ParseCollectionLazy returns.
+func ParseCollectionLazy(src []byte) ([]giofont.FontFace, error) {
+	src, err := decodeWOFF(src)
+	if err != nil {
+		return nil, err
+	}
+	lds, err := opentype.NewLoaders(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]giofont.FontFace, len(lds))
+	var buf []byte
+	for i, ld := range lds {
+		var desc fontapi.Description
+		desc, buf = fontapi.Describe(ld, buf)
+		f := &LazyFace{
+			font:   DescriptionToFont(desc),
+			loader: ld,
+		}
+		out[i] = giofont.FontFace{Face: f, Font: f.font}
+	}
+	return out, nil
+}
+
+// Font returns the font metadata of the face, which is available without
+// loading it.
+func (f *LazyFace) Font() giofont.Font {
+	return f.font
+}
+
+// Loaded reports whether the face has been parsed successfully. It is false
+// for faces that failed to parse; [LazyFace.Load] reports their error.
+func (f *LazyFace) Loaded() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.face != nil
+}
+
+// Load parses the face if it has not been parsed already, and reports any
+// error encountered while parsing it. Parsing is attempted only once.
+func (f *LazyFace) Load() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.load()
+}
+
+func (f *LazyFace) load() error {
+	if f.face != nil || f.err != nil {
+		return f.err
+	}
+	face, _, err := parseLoader(f.loader)
+	if err != nil {
+		f.err = fmt.Errorf("failed parsing truetype font: %w", err)
+		return f.err
+	}
+	f.face = face
+	f.loader, f.cmap = nil, nil
+	return nil
+}
+
+// Face parses the face if necessary and returns a thread-unsafe wrapper for
+// it, like [Face.Face]. Face returns nil if the face cannot be parsed, and
+// [LazyFace.Load] reports the error.
+func (f *LazyFace) Face() *fontapi.Face {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.load(); err != nil {
+		return nil
+	}
+	return &fontapi.Face{Font: f.face}
+}
+
+// Covers reports whether the face has a glyph for r. If the face is not loaded
+// yet, Covers parses only its character map.
+func (f *LazyFace) Covers(r rune) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.face != nil {
+		_, ok := f.face.NominalGlyph(r)
+		return ok
+	}
+	if f.err != nil {
+		return false
+	}
+	if f.cmap == nil {
+		cmap, err := loadCmap(f.loader)
+		if err != nil {
+			// The face cannot be used without a character map.
+			f.err = fmt.Errorf("failed parsing truetype font: %w", err)
+			return false
+		}
+		f.cmap = cmap
+	}
+	_, ok := f.cmap.Lookup(r)
+	return ok
+}
+
+// loadCmap parses the character map of a font.
+func loadCmap(ld *opentype.Loader) (fontapi.Cmap, error) {
+	page := tables.FPNone
+	if raw, err := ld.RawTable(opentype.MustNewTag("OS/2")); err == nil {
+		if os2, _, err := tables.ParseOs2(raw); err == nil {
+			page = os2.FontPage()
+		}
+	}
+	raw, err := ld.RawTable(opentype.MustNewTag("cmap"))
+	if err != nil {
+		return nil, err
+	}
+	tb, _, err := tables.ParseCmap(raw)
+	if err != nil {
+		return nil, err
+	}
+	cmap, _, err := fontapi.ProcessCmap(tb, page)
+	return cmap, err
+}
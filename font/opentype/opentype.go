@@ -28,8 +28,13 @@ type Face struct {
 	font giofont.Font
 }
 
-// Parse constructs a Face from source bytes.
+// Parse constructs a Face from source bytes. The source may be an OpenType or
+// TrueType font, or a font compressed in the WOFF or WOFF2 formats.
 func Parse(src []byte) (Face, error) {
+	src, err := decodeWOFF(src)
+	if err != nil {
+		return Face{}, err
+	}
 	ld, err := opentype.NewLoader(bytes.NewReader(src))
 	if err != nil {
 		return Face{}, err
@@ -45,12 +50,18 @@ func Parse(src []byte) (Face, error) {
 }
 
 // ParseCollection parse an Opentype font file, with support for collections.
-// Single font files are supported, returning a slice with length 1.
+// Single font files are supported, returning a slice with length 1. Like
+// [Parse], ParseCollection accepts fonts compressed in the WOFF and WOFF2
+// formats.
 // The returned fonts are automatically wrapped in a text.FontFace with
 // inferred font font.
 // BUG(whereswaldon): the only Variant that can be detected automatically is
 // "Mono".
 func ParseCollection(src []byte) ([]giofont.FontFace, error) {
+	src, err := decodeWOFF(src)
+	if err != nil {
+		return nil, err
+	}
 	lds, err := opentype.NewLoaders(bytes.NewReader(src))
 	if err != nil {
 		return nil, err
@@ -74,6 +85,16 @@ func ParseCollection(src []byte) ([]giofont.FontFace, error) {
 	return out, nil
 }
 
+// decodeWOFF converts WOFF2 data to the equivalent font file. Other data,
+// including WOFF fonts that are read directly by the OpenType loader, is
+// returned unchanged.
+func decodeWOFF(src []byte) ([]byte, error) {
+	if !isWOFF2(src) {
+		return src, nil
+	}
+	return decodeWOFF2(src)
+}
+
 func DescriptionToFont(md fontapi.Description) giofont.Font {
 	return giofont.Font{
 		Typeface: giofont.Typeface(md.Family),
@@ -0,0 +1,724 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package opentype
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/andybalholm/brotli"
+)
+
+// WOFF2 decoding, as specified by https://www.w3.org/TR/WOFF2/.
+
+const (
+	signatureWOFF2 = 0x774F4632 // "wOF2"
+	tagTTCF        = 0x74746366 // "ttcf"
+	tagGlyf        = 0x676C7966 // "glyf"
+	tagLoca        = 0x6C6F6361 // "loca"
+	tagHmtx        = 0x686D7478 // "hmtx"
+	tagHhea        = 0x68686561 // "hhea"
+	tagHead        = 0x68656164 // "head"
+)
+
+// woff2KnownTags lists the tags that may be encoded by their index in the
+// flags of a WOFF2 table directory entry.
+var woff2KnownTags = [63]string{
+	"cmap", "head", "hhea", "hmtx", "maxp", "name", "OS/2", "post", "cvt ",
+	"fpgm", "glyf", "loca", "prep", "CFF ", "VORG", "EBDT", "EBLC", "gasp",
+	"hdmx", "kern", "LTSH", "PCLT", "VDMX", "vhea", "vmtx", "BASE", "GDEF",
+	"GPOS", "GSUB", "EBSC", "JSTF", "MATH", "CBDT", "CBLC", "COLR", "CPAL",
+	"SVG ", "sbix", "acnt", "avar", "bdat", "bloc", "bsln", "cvar", "fdsc",
+	"feat", "fmtx", "fvar", "gvar", "hsty", "just", "lcar", "mort", "morx",
+	"opbd", "prop", "trak", "Zapf", "Silf", "Glat", "Gloc", "Feat", "Sill",
+}
+
+// maxWOFF2Size bounds the size of decoded fonts, to protect against corrupt
+// or malicious headers.
+const maxWOFF2Size = 1 << 30
+
+var errWOFF2Truncated = errors.New("woff2: truncated data")
+
+// woff2Table is an entry of the WOFF2 table directory.
+type woff2Table struct {
+	tag         uint32
+	transformed bool
+	origLength  uint32
+	// length is the size of the table in the decompressed stream.
+	length uint32
+	// data is the decoded table.
+	data []byte
+}
+
+// woff2Font is a font of a WOFF2 file. Fonts of a collection may share tables.
+type woff2Font struct {
+	flavor uint32
+	// tables are indices into the table directory.
+	tables []int
+}
+
+// isWOFF2 reports whether src starts with the WOFF2 signature.
+func isWOFF2(src []byte) bool {
+	return len(src) >= 4 && binary.BigEndian.Uint32(src) == signatureWOFF2
+}
+
+// decodeWOFF2 converts a WOFF2 file into the equivalent sfnt font or font
+// collection file.
+func decodeWOFF2(src []byte) ([]byte, error) {
+	r := &woff2Reader{buf: src}
+	if r.u32() != signatureWOFF2 {
+		return nil, errors.New("woff2: invalid signature")
+	}
+	flavor := r.u32()
+	if length := r.u32(); int(length) != len(src) {
+		return nil, fmt.Errorf("woff2: header length %d doesn't match file length %d", length, len(src))
+	}
+	numTables := int(r.u16())
+	r.u16() // Reserved.
+	r.u32() // totalSfntSize, which is only a hint.
+	compressedSize := r.u32()
+	r.skip(2 * 2) // Major and minor version.
+	r.skip(3 * 4) // Metadata block.
+	r.skip(2 * 4) // Private data block.
+	if numTables == 0 {
+		return nil, errors.New("woff2: no tables")
+	}
+	tables := make([]woff2Table, numTables)
+	var streamSize uint64
+	for i := range tables {
+		t := &tables[i]
+		flags := r.u8()
+		if idx := flags & 0x3f; idx == 0x3f {
+			t.tag = r.u32()
+		} else {
+			t.tag = binary.BigEndian.Uint32([]byte(woff2KnownTags[idx]))
+		}
+		version := flags >> 6
+		t.origLength = r.base128()
+		t.length = t.origLength
+		// Version 0 is the transformed format of glyf and loca, but the
+		// null transform of every other table.
+		if t.tag == tagGlyf || t.tag == tagLoca {
+			t.transformed = version == 0
+		} else {
+			t.transformed = version != 0
+		}
+		if t.transformed {
+			t.length = r.base128()
+			if t.tag == tagLoca && t.length != 0 {
+				return nil, errors.New("woff2: transformed loca table is not empty")
+			}
+		}
+		if r.err != nil {
+			return nil, r.err
+		}
+		if t.origLength > maxWOFF2Size || t.length > maxWOFF2Size {
+			return nil, errors.New("woff2: table too large")
+		}
+		streamSize += uint64(t.length)
+	}
+	if streamSize > maxWOFF2Size {
+		return nil, errors.New("woff2: font too large")
+	}
+	var fonts []woff2Font
+	if flavor == tagTTCF {
+		r.u32() // Collection version.
+		numFonts := int(r.u255())
+		if numFonts == 0 {
+			return nil, errors.New("woff2: empty collection")
+		}
+		fonts = make([]woff2Font, numFonts)
+		for i := range fonts {
+			f := &fonts[i]
+			n := int(r.u255())
+			f.flavor = r.u32()
+			f.tables = make([]int, n)
+			for j := range f.tables {
+				idx := int(r.u255())
+				if idx >= numTables {
+					return nil, fmt.Errorf("woff2: table index %d out of range", idx)
+				}
+				f.tables[j] = idx
+			}
+			if r.err != nil {
+				return nil, r.err
+			}
+		}
+	} else {
+		f := woff2Font{flavor: flavor, tables: make([]int, numTables)}
+		for i := range f.tables {
+			f.tables[i] = i
+		}
+		fonts = []woff2Font{f}
+	}
+	compressed := r.bytes(int(compressedSize))
+	if r.err != nil {
+		return nil, r.err
+	}
+	stream, err := io.ReadAll(io.LimitReader(brotli.NewReader(bytes.NewReader(compressed)), int64(streamSize)+1))
+	if err != nil {
+		return nil, fmt.Errorf("woff2: %w", err)
+	}
+	if uint64(len(stream)) != streamSize {
+		return nil, errors.New("woff2: decompressed size doesn't match the table directory")
+	}
+	for i := range tables {
+		t := &tables[i]
+		t.data, stream = stream[:t.length:t.length], stream[t.length:]
+	}
+	for _, f := range fonts {
+		if err := reconstructTables(tables, f); err != nil {
+			return nil, err
+		}
+	}
+	return writeSfnt(tables, fonts)
+}
+
+// reconstructTables reverses the transforms applied to the glyf, loca and hmtx
+// tables of a font.
+func reconstructTables(tables []woff2Table, f woff2Font) error {
+	find := func(tag uint32) *woff2Table {
+		for _, idx := range f.tables {
+			if tables[idx].tag == tag {
+				return &tables[idx]
+			}
+		}
+		return nil
+	}
+	glyf, loca := find(tagGlyf), find(tagLoca)
+	if (glyf == nil) != (loca == nil) || glyf != nil && glyf.transformed != loca.transformed {
+		return errors.New("woff2: glyf and loca tables must both be present and transformed alike")
+	}
+	var xMins []int16
+	if glyf != nil && glyf.transformed {
+		// Tables shared by fonts of a collection are reconstructed by the
+		// first font.
+		glyf.transformed, loca.transformed = false, false
+		var err error
+		glyf.data, loca.data, xMins, err = reconstructGlyf(glyf.data)
+		if err != nil {
+			return err
+		}
+		if uint32(len(loca.data)) != loca.origLength {
+			return errors.New("woff2: reconstructed loca table has the wrong size")
+		}
+		if head := find(tagHead); head != nil && len(head.data) >= 52 {
+			// Ensure indexToLocFormat matches the reconstructed loca table.
+			format := uint16(0)
+			if len(loca.data) > 2*(len(xMins)+1) {
+				format = 1
+			}
+			binary.BigEndian.PutUint16(head.data[50:], format)
+		}
+	}
+	if hmtx := find(tagHmtx); hmtx != nil && hmtx.transformed {
+		hmtx.transformed = false
+		if xMins == nil {
+			return errors.New("woff2: transformed hmtx table without transformed glyf table")
+		}
+		hhea := find(tagHhea)
+		if hhea == nil || len(hhea.data) < 36 {
+			return errors.New("woff2: transformed hmtx table without hhea table")
+		}
+		numHMetrics := int(binary.BigEndian.Uint16(hhea.data[34:]))
+		var err error
+		hmtx.data, err = reconstructHmtx(hmtx.data, numHMetrics, xMins)
+		if err != nil {
+			return err
+		}
+	}
+	for _, idx := range f.tables {
+		if tables[idx].transformed {
+			return fmt.Errorf("woff2: unsupported transform of table %q", tagString(tables[idx].tag))
+		}
+	}
+	return nil
+}
+
+// Flags of simple glyph points.
+const (
+	glyfOnCurve       = 0x01
+	glyfXShort        = 0x02
+	glyfYShort        = 0x04
+	glyfXSame         = 0x10
+	glyfYSame         = 0x20
+	glyfOverlapSimple = 0x40
+)
+
+// Flags of composite glyph components.
+const (
+	glyfArgsAreWords    = 0x0001
+	glyfHaveScale       = 0x0008
+	glyfMoreComponents  = 0x0020
+	glyfHaveXYScale     = 0x0040
+	glyfHaveTwoByTwo    = 0x0080
+	glyfHaveInstruction = 0x0100
+)
+
+// reconstructGlyf decodes a transformed glyf table, returning the glyf and loca
+// tables along with the minimum x coordinate of every glyph.
+func reconstructGlyf(src []byte) (glyf, loca []byte, xMins []int16, err error) {
+	r := &woff2Reader{buf: src}
+	r.u16() // Reserved.
+	options := r.u16()
+	numGlyphs := int(r.u16())
+	indexFormat := r.u16()
+	var sizes [7]uint32
+	for i := range sizes {
+		sizes[i] = r.u32()
+	}
+	var streams [7]*woff2Reader
+	for i, size := range sizes {
+		streams[i] = &woff2Reader{buf: r.bytes(int(size))}
+	}
+	nContours, nPoints, flags, glyphs, composites, bboxes, instructions := streams[0], streams[1], streams[2], streams[3], streams[4], streams[5], streams[6]
+	var overlaps []byte
+	if options&1 != 0 {
+		overlaps = r.bytes((numGlyphs + 7) / 8)
+	}
+	bboxBitmap := bboxes.bytes(4 * ((numGlyphs + 31) / 32))
+	if r.err != nil || bboxes.err != nil {
+		return nil, nil, nil, errWOFF2Truncated
+	}
+	hasBit := func(bitmap []byte, i int) bool {
+		return bitmap[i>>3]&(0x80>>(i&7)) != 0
+	}
+
+	xMins = make([]int16, numGlyphs)
+	offsets := make([]uint32, numGlyphs+1)
+	var (
+		endPts []uint16
+		points []woff2Point
+	)
+	for i := 0; i < numGlyphs; i++ {
+		offsets[i] = uint32(len(glyf))
+		n := int16(nContours.u16())
+		hasBBox := hasBit(bboxBitmap, i)
+		var bbox [4]int16
+		if hasBBox {
+			for j := range bbox {
+				bbox[j] = int16(bboxes.u16())
+			}
+		}
+		switch {
+		case n == 0:
+			if hasBBox {
+				return nil, nil, nil, errors.New("woff2: empty glyph with bounding box")
+			}
+		case n < 0:
+			if !hasBBox {
+				return nil, nil, nil, errors.New("woff2: composite glyph without bounding box")
+			}
+			start := composites.off
+			haveInstructions := false
+			for {
+				f := composites.u16()
+				composites.skip(2) // Glyph index.
+				if f&glyfArgsAreWords != 0 {
+					composites.skip(4)
+				} else {
+					composites.skip(2)
+				}
+				switch {
+				case f&glyfHaveScale != 0:
+					composites.skip(2)
+				case f&glyfHaveXYScale != 0:
+					composites.skip(4)
+				case f&glyfHaveTwoByTwo != 0:
+					composites.skip(8)
+				}
+				if f&glyfHaveInstruction != 0 {
+					haveInstructions = true
+				}
+				if composites.err != nil || f&glyfMoreComponents == 0 {
+					break
+				}
+			}
+			if composites.err != nil {
+				return nil, nil, nil, errWOFF2Truncated
+			}
+			glyf = binary.BigEndian.AppendUint16(glyf, uint16(n))
+			glyf = appendBBox(glyf, bbox)
+			glyf = append(glyf, composites.buf[start:composites.off]...)
+			if haveInstructions {
+				size := int(glyphs.u255())
+				glyf = binary.BigEndian.AppendUint16(glyf, uint16(size))
+				glyf = append(glyf, instructions.bytes(size)...)
+			}
+			xMins[i] = bbox[0]
+		default:
+			endPts = endPts[:0]
+			total := 0
+			for j := 0; j < int(n); j++ {
+				total += int(nPoints.u255())
+				if total > 0xffff || nPoints.err != nil {
+					return nil, nil, nil, errors.New("woff2: invalid glyph point count")
+				}
+				endPts = append(endPts, uint16(total-1))
+			}
+			points = points[:0]
+			var x, y int
+			for j := 0; j < total; j++ {
+				flag := flags.u8()
+				dx, dy := decodeTriplet(flag&0x7f, glyphs)
+				x, y = x+dx, y+dy
+				points = append(points, woff2Point{x: x, y: y, onCurve: flag&0x80 == 0})
+			}
+			if flags.err != nil || glyphs.err != nil {
+				return nil, nil, nil, errWOFF2Truncated
+			}
+			if !hasBBox && len(points) > 0 {
+				bbox = [4]int16{int16(points[0].x), int16(points[0].y), int16(points[0].x), int16(points[0].y)}
+				for _, p := range points[1:] {
+					bbox[0] = min(bbox[0], int16(p.x))
+					bbox[1] = min(bbox[1], int16(p.y))
+					bbox[2] = max(bbox[2], int16(p.x))
+					bbox[3] = max(bbox[3], int16(p.y))
+				}
+			}
+			size := int(glyphs.u255())
+			glyf = binary.BigEndian.AppendUint16(glyf, uint16(n))
+			glyf = appendBBox(glyf, bbox)
+			for _, e := range endPts {
+				glyf = binary.BigEndian.AppendUint16(glyf, e)
+			}
+			glyf = binary.BigEndian.AppendUint16(glyf, uint16(size))
+			glyf = append(glyf, instructions.bytes(size)...)
+			glyf = appendPoints(glyf, points, overlaps != nil && hasBit(overlaps, i))
+			xMins[i] = bbox[0]
+		}
+		for _, s := range streams {
+			if s.err != nil {
+				return nil, nil, nil, errWOFF2Truncated
+			}
+		}
+		// Pad glyphs to 4 bytes, which satisfies the alignment requirements of
+		// both loca formats.
+		for len(glyf)%4 != 0 {
+			glyf = append(glyf, 0)
+		}
+	}
+	offsets[numGlyphs] = uint32(len(glyf))
+	for _, o := range offsets {
+		if indexFormat == 0 {
+			if o/2 > 0xffff {
+				return nil, nil, nil, errors.New("woff2: glyf table too large for short loca format")
+			}
+			loca = binary.BigEndian.AppendUint16(loca, uint16(o/2))
+		} else {
+			loca = binary.BigEndian.AppendUint32(loca, o)
+		}
+	}
+	return glyf, loca, xMins, nil
+}
+
+type woff2Point struct {
+	x, y    int
+	onCurve bool
+}
+
+func appendBBox(dst []byte, bbox [4]int16) []byte {
+	for _, v := range bbox {
+		dst = binary.BigEndian.AppendUint16(dst, uint16(v))
+	}
+	return dst
+}
+
+// appendPoints encodes the flags and coordinates of the points of a simple
+// glyph.
+func appendPoints(dst []byte, points []woff2Point, overlap bool) []byte {
+	var lastX, lastY int
+	for i, p := range points {
+		var flag byte
+		if p.onCurve {
+			flag |= glyfOnCurve
+		}
+		if i == 0 && overlap {
+			flag |= glyfOverlapSimple
+		}
+		flag |= coordFlag(p.x-lastX, glyfXShort, glyfXSame)
+		flag |= coordFlag(p.y-lastY, glyfYShort, glyfYSame)
+		dst = append(dst, flag)
+		lastX, lastY = p.x, p.y
+	}
+	lastX = 0
+	for _, p := range points {
+		dst = appendCoord(dst, p.x-lastX)
+		lastX = p.x
+	}
+	lastY = 0
+	for _, p := range points {
+		dst = appendCoord(dst, p.y-lastY)
+		lastY = p.y
+	}
+	return dst
+}
+
+// coordFlag returns the flags encoding a coordinate delta. A short delta stores
+// its sign in the same flag that marks a repeated coordinate.
+func coordFlag(d int, short, same byte) byte {
+	switch {
+	case d == 0:
+		return same
+	case d > -256 && d < 256:
+		if d > 0 {
+			return short | same
+		}
+		return short
+	default:
+		return 0
+	}
+}
+
+func appendCoord(dst []byte, d int) []byte {
+	switch {
+	case d == 0:
+		return dst
+	case d > -256 && d < 256:
+		return append(dst, byte(abs(d)))
+	default:
+		return binary.BigEndian.AppendUint16(dst, uint16(int16(d)))
+	}
+}
+
+// decodeTriplet decodes the coordinate delta of a point, as described by
+// flag and its following bytes in the glyph stream.
+func decodeTriplet(flag byte, r *woff2Reader) (dx, dy int) {
+	withSign := func(flag byte, v int) int {
+		if flag&1 != 0 {
+			return v
+		}
+		return -v
+	}
+	f := int(flag)
+	switch {
+	case flag < 10:
+		b0 := int(r.u8())
+		return 0, withSign(flag, (f&14)<<7+b0)
+	case flag < 20:
+		b0 := int(r.u8())
+		return withSign(flag, ((f-10)&14)<<7+b0), 0
+	case flag < 84:
+		b0, b1 := f-20, int(r.u8())
+		return withSign(flag, 1+(b0&0x30)+b1>>4), withSign(flag>>1, 1+(b0&0x0c)<<2+b1&0x0f)
+	case flag < 120:
+		b0 := f - 84
+		b1, b2 := int(r.u8()), int(r.u8())
+		return withSign(flag, 1+(b0/12)<<8+b1), withSign(flag>>1, 1+((b0%12)>>2)<<8+b2)
+	case flag < 124:
+		b1, b2, b3 := int(r.u8()), int(r.u8()), int(r.u8())
+		return withSign(flag, b1<<4+b2>>4), withSign(flag>>1, (b2&0x0f)<<8+b3)
+	default:
+		b1, b2, b3, b4 := int(r.u8()), int(r.u8()), int(r.u8()), int(r.u8())
+		return withSign(flag, b1<<8+b2), withSign(flag>>1, b3<<8+b4)
+	}
+}
+
+// reconstructHmtx decodes a transformed hmtx table, substituting omitted left
+// side bearings with the minimum x coordinates of the glyphs.
+func reconstructHmtx(src []byte, numHMetrics int, xMins []int16) ([]byte, error) {
+	numGlyphs := len(xMins)
+	if numHMetrics == 0 || numHMetrics > numGlyphs {
+		return nil, errors.New("woff2: invalid number of horizontal metrics")
+	}
+	r := &woff2Reader{buf: src}
+	flags := r.u8()
+	advances := make([]uint16, numHMetrics)
+	for i := range advances {
+		advances[i] = r.u16()
+	}
+	lsbs := make([]int16, numGlyphs)
+	copy(lsbs, xMins)
+	if flags&1 == 0 {
+		for i := 0; i < numHMetrics; i++ {
+			lsbs[i] = int16(r.u16())
+		}
+	}
+	if flags&2 == 0 {
+		for i := numHMetrics; i < numGlyphs; i++ {
+			lsbs[i] = int16(r.u16())
+		}
+	}
+	if r.err != nil {
+		return nil, errWOFF2Truncated
+	}
+	dst := make([]byte, 0, 4*numHMetrics+2*(numGlyphs-numHMetrics))
+	for i, lsb := range lsbs {
+		if i < numHMetrics {
+			dst = binary.BigEndian.AppendUint16(dst, advances[i])
+		}
+		dst = binary.BigEndian.AppendUint16(dst, uint16(lsb))
+	}
+	return dst, nil
+}
+
+// writeSfnt serializes the decoded tables into a font file, or a font collection
+// file if there is more than one font.
+func writeSfnt(tables []woff2Table, fonts []woff2Font) ([]byte, error) {
+	headerSize := func(f woff2Font) int { return 12 + 16*len(f.tables) }
+	var dst []byte
+	size := 0
+	if len(fonts) > 1 {
+		size = 12 + 4*len(fonts)
+		dst = binary.BigEndian.AppendUint32(dst, tagTTCF)
+		dst = binary.BigEndian.AppendUint32(dst, 0x00010000)
+		dst = binary.BigEndian.AppendUint32(dst, uint32(len(fonts)))
+		off := size
+		for _, f := range fonts {
+			dst = binary.BigEndian.AppendUint32(dst, uint32(off))
+			off += headerSize(f)
+		}
+		size = off
+	} else {
+		size = headerSize(fonts[0])
+	}
+	// Lay out the table data after the headers of every font, storing tables
+	// shared between fonts only once.
+	offsets := make([]int, len(tables))
+	for i, t := range tables {
+		offsets[i] = size
+		size += (len(t.data) + 3) &^ 3
+	}
+	if size > maxWOFF2Size {
+		return nil, errors.New("woff2: font too large")
+	}
+	for _, f := range fonts {
+		order := append([]int(nil), f.tables...)
+		sort.Slice(order, func(i, j int) bool {
+			return tables[order[i]].tag < tables[order[j]].tag
+		})
+		n := len(order)
+		entrySelector := 0
+		for 1<<(entrySelector+1) <= n {
+			entrySelector++
+		}
+		searchRange := 16 << entrySelector
+		dst = binary.BigEndian.AppendUint32(dst, f.flavor)
+		dst = binary.BigEndian.AppendUint16(dst, uint16(n))
+		dst = binary.BigEndian.AppendUint16(dst, uint16(searchRange))
+		dst = binary.BigEndian.AppendUint16(dst, uint16(entrySelector))
+		dst = binary.BigEndian.AppendUint16(dst, uint16(n*16-searchRange))
+		for _, idx := range order {
+			t := tables[idx]
+			dst = binary.BigEndian.AppendUint32(dst, t.tag)
+			dst = binary.BigEndian.AppendUint32(dst, checksum(t.data))
+			dst = binary.BigEndian.AppendUint32(dst, uint32(offsets[idx]))
+			dst = binary.BigEndian.AppendUint32(dst, uint32(len(t.data)))
+		}
+	}
+	for _, t := range tables {
+		dst = append(dst, t.data...)
+		for len(dst)%4 != 0 {
+			dst = append(dst, 0)
+		}
+	}
+	return dst, nil
+}
+
+func checksum(data []byte) uint32 {
+	var sum uint32
+	for len(data) >= 4 {
+		sum += binary.BigEndian.Uint32(data)
+		data = data[4:]
+	}
+	if len(data) > 0 {
+		var tail [4]byte
+		copy(tail[:], data)
+		sum += binary.BigEndian.Uint32(tail[:])
+	}
+	return sum
+}
+
+func tagString(tag uint32) string {
+	return string(binary.BigEndian.AppendUint32(nil, tag))
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// woff2Reader reads the big-endian and variable length integers of WOFF2 data.
+// Reading past the end of the data sets err and returns zero values.
+type woff2Reader struct {
+	buf []byte
+	off int
+	err error
+}
+
+func (r *woff2Reader) bytes(n int) []byte {
+	if r.err != nil || n < 0 || n > len(r.buf)-r.off {
+		r.err = errWOFF2Truncated
+		return nil
+	}
+	b := r.buf[r.off : r.off+n]
+	r.off += n
+	return b
+}
+
+func (r *woff2Reader) skip(n int) {
+	r.bytes(n)
+}
+
+func (r *woff2Reader) u8() byte {
+	if b := r.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *woff2Reader) u16() uint16 {
+	if b := r.bytes(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *woff2Reader) u32() uint32 {
+	if b := r.bytes(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+// base128 reads a UIntBase128 value.
+func (r *woff2Reader) base128() uint32 {
+	var v uint32
+	for i := 0; i < 5; i++ {
+		b := r.u8()
+		if r.err != nil {
+			return 0
+		}
+		if i == 0 && b == 0x80 || v&0xfe000000 != 0 {
+			// Leading zeros and overflows are invalid.
+			r.err = errors.New("woff2: invalid UIntBase128 value")
+			return 0
+		}
+		v = v<<7 | uint32(b&0x7f)
+		if b&0x80 == 0 {
+			return v
+		}
+	}
+	r.err = errors.New("woff2: invalid UIntBase128 value")
+	return 0
+}
+
+// u255 reads a 255UInt16 value.
+func (r *woff2Reader) u255() uint16 {
+	switch code := r.u8(); code {
+	case 253:
+		return r.u16()
+	case 254:
+		return uint16(r.u8()) + 253*2
+	case 255:
+		return uint16(r.u8()) + 253
+	default:
+		return uint16(code)
+	}
+}
@@ -0,0 +1,441 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package opentype
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	fontapi "github.com/go-text/typesetting/font"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestWOFF2(t *testing.T) {
+	for _, transform := range []bool{false, true} {
+		src := encodeWOFF2(t, [][]byte{goregular.TTF}, transform)
+		face, err := Parse(src)
+		if err != nil {
+			t.Fatalf("transform %v: %v", transform, err)
+		}
+		ref, err := Parse(goregular.TTF)
+		if err != nil {
+			t.Fatal(err)
+		}
+		compareFaces(t, ref.Face(), face.Face())
+		if got, want := face.Font(), ref.Font(); got != want {
+			t.Errorf("transform %v: got font %+v, want %+v", transform, got, want)
+		}
+	}
+}
+
+func TestWOFF2Collection(t *testing.T) {
+	src := encodeWOFF2(t, [][]byte{goregular.TTF, gomono.TTF}, true)
+	faces, err := ParseCollection(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(faces) != 2 {
+		t.Fatalf("got %d faces, want 2", len(faces))
+	}
+	for i, ttf := range [][]byte{goregular.TTF, gomono.TTF} {
+		ref, err := Parse(ttf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		compareFaces(t, ref.Face(), faces[i].Face.Face())
+	}
+}
+
+func TestWOFF2Corrupt(t *testing.T) {
+	src := encodeWOFF2(t, [][]byte{goregular.TTF}, true)
+	for _, n := range []int{12, 48, len(src) / 2, len(src) - 1} {
+		truncated := append([]byte(nil), src[:n]...)
+		binary.BigEndian.PutUint32(truncated[8:], uint32(n))
+		if _, err := Parse(truncated); err == nil {
+			t.Errorf("parsing WOFF2 truncated to %d bytes succeeded", n)
+		}
+	}
+}
+
+func TestTriplet(t *testing.T) {
+	for dx := -5000; dx <= 5000; dx += 7 {
+		for _, dy := range []int{-5000, -1279, -768, -64, -1, 0, 1, 63, 64, 65, 768, 769, 1279, 1280, 4095, 4096} {
+			for _, d := range [][2]int{{dx, dy}, {dy, dx}} {
+				flag, data := encodeTriplet(true, d[0], d[1])
+				x, y := decodeTriplet(flag, &woff2Reader{buf: data})
+				if x != d[0] || y != d[1] {
+					t.Fatalf("triplet (%d, %d) decoded as (%d, %d)", d[0], d[1], x, y)
+				}
+			}
+		}
+	}
+}
+
+func compareFaces(t *testing.T, ref, face *fontapi.Face) {
+	t.Helper()
+	for r := rune(0); r < 0x3000; r++ {
+		refGID, refOK := ref.NominalGlyph(r)
+		gid, ok := face.NominalGlyph(r)
+		if refGID != gid || refOK != ok {
+			t.Fatalf("rune %q maps to glyph %d, want %d", r, gid, refGID)
+		}
+		if !ok {
+			continue
+		}
+		if got, want := face.HorizontalAdvance(gid), ref.HorizontalAdvance(gid); got != want {
+			t.Errorf("glyph %d has advance %v, want %v", gid, got, want)
+		}
+		got, _ := face.GlyphExtents(gid)
+		want, _ := ref.GlyphExtents(gid)
+		if got != want {
+			t.Errorf("glyph %d has extents %+v, want %+v", gid, got, want)
+		}
+		if got, want := face.GlyphData(gid), ref.GlyphData(gid); !reflect.DeepEqual(got, want) {
+			t.Errorf("glyph %d has outline %+v, want %+v", gid, got, want)
+		}
+	}
+}
+
+type sfntTable struct {
+	tag  uint32
+	data []byte
+}
+
+// readSfnt returns the tables of a font file, sorted by tag.
+func readSfnt(src []byte) (flavor uint32, tables []sfntTable) {
+	n := int(binary.BigEndian.Uint16(src[4:]))
+	for i := 0; i < n; i++ {
+		e := src[12+16*i:]
+		off, length := binary.BigEndian.Uint32(e[8:]), binary.BigEndian.Uint32(e[12:])
+		tables = append(tables, sfntTable{
+			tag:  binary.BigEndian.Uint32(e),
+			data: src[off : off+length],
+		})
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].tag < tables[j].tag })
+	return binary.BigEndian.Uint32(src), tables
+}
+
+// encodeWOFF2 compresses TrueType fonts into a WOFF2 file, optionally
+// transforming their glyf, loca and hmtx tables.
+func encodeWOFF2(t *testing.T, fonts [][]byte, transform bool) []byte {
+	t.Helper()
+	var (
+		dir, stream bytes.Buffer
+		flavor      uint32
+		numTables   int
+		sfntSize    int
+		collection  []byte
+	)
+	if len(fonts) > 1 {
+		flavor = tagTTCF
+		collection = binary.BigEndian.AppendUint32(collection, 0x00010000)
+		collection = appendU255(collection, len(fonts))
+	}
+	for _, src := range fonts {
+		f, tables := readSfnt(src)
+		if len(fonts) == 1 {
+			flavor = f
+		} else {
+			collection = appendU255(collection, len(tables))
+			collection = binary.BigEndian.AppendUint32(collection, f)
+		}
+		var glyf, loca, hhea, head []byte
+		for _, tb := range tables {
+			switch tb.tag {
+			case tagGlyf:
+				glyf = tb.data
+			case tagLoca:
+				loca = tb.data
+			case tagHhea:
+				hhea = tb.data
+			case tagHead:
+				head = tb.data
+			}
+		}
+		var xMins []int16
+		for _, tb := range tables {
+			data := tb.data
+			flags := byte(0x3f)
+			for i, tag := range woff2KnownTags {
+				if binary.BigEndian.Uint32([]byte(tag)) == tb.tag {
+					flags = byte(i)
+				}
+			}
+			transformed := false
+			switch {
+			case tb.tag == tagGlyf || tb.tag == tagLoca:
+				if transform {
+					transformed = true
+				} else {
+					flags |= 3 << 6
+				}
+			case tb.tag == tagHmtx && transform:
+				transformed = true
+				flags |= 1 << 6
+			}
+			if transformed {
+				switch tb.tag {
+				case tagGlyf:
+					longLoca := binary.BigEndian.Uint16(head[50:]) == 1
+					data, xMins = transformGlyf(glyf, loca, longLoca)
+				case tagLoca:
+					data = nil
+				case tagHmtx:
+					data = transformHmtx(tb.data, int(binary.BigEndian.Uint16(hhea[34:])), xMins)
+				}
+			}
+			dir.WriteByte(flags)
+			if flags&0x3f == 0x3f {
+				binary.Write(&dir, binary.BigEndian, tb.tag)
+			}
+			dir.Write(appendBase128(nil, len(tb.data)))
+			if transformed {
+				dir.Write(appendBase128(nil, len(data)))
+			}
+			stream.Write(data)
+			if len(fonts) > 1 {
+				collection = appendU255(collection, numTables)
+			}
+			numTables++
+			sfntSize += (len(tb.data) + 3) &^ 3
+		}
+	}
+	var compressed bytes.Buffer
+	w := brotli.NewWriter(&compressed)
+	w.Write(stream.Bytes())
+	w.Close()
+
+	var out []byte
+	out = binary.BigEndian.AppendUint32(out, signatureWOFF2)
+	out = binary.BigEndian.AppendUint32(out, flavor)
+	out = binary.BigEndian.AppendUint32(out, 0) // Length, set below.
+	out = binary.BigEndian.AppendUint16(out, uint16(numTables))
+	out = binary.BigEndian.AppendUint16(out, 0)
+	out = binary.BigEndian.AppendUint32(out, uint32(sfntSize))
+	out = binary.BigEndian.AppendUint32(out, uint32(compressed.Len()))
+	out = append(out, make([]byte, 24)...)
+	out = append(out, dir.Bytes()...)
+	out = append(out, collection...)
+	out = append(out, compressed.Bytes()...)
+	binary.BigEndian.PutUint32(out[8:], uint32(len(out)))
+	return out
+}
+
+// transformGlyf applies the WOFF2 glyf transform.
+func transformGlyf(glyf, loca []byte, longLoca bool) ([]byte, []int16) {
+	numGlyphs := len(loca)/2 - 1
+	offset := func(i int) int { return int(binary.BigEndian.Uint16(loca[2*i:])) * 2 }
+	if longLoca {
+		numGlyphs = len(loca)/4 - 1
+		offset = func(i int) int { return int(binary.BigEndian.Uint32(loca[4*i:])) }
+	}
+	var nContours, nPoints, flags, glyphs, composites, bboxes, instructions []byte
+	bboxBitmap := make([]byte, 4*((numGlyphs+31)/32))
+	xMins := make([]int16, numGlyphs)
+	for i := 0; i < numGlyphs; i++ {
+		g := glyf[offset(i):offset(i+1)]
+		if len(g) == 0 {
+			nContours = binary.BigEndian.AppendUint16(nContours, 0)
+			continue
+		}
+		n := int16(binary.BigEndian.Uint16(g))
+		nContours = binary.BigEndian.AppendUint16(nContours, uint16(n))
+		xMins[i] = int16(binary.BigEndian.Uint16(g[2:]))
+		// Always store the bounding box explicitly.
+		bboxBitmap[i>>3] |= 0x80 >> (i & 7)
+		bboxes = append(bboxes, g[2:10]...)
+		g = g[10:]
+		if n < 0 {
+			start := g
+			more, haveInstructions := true, false
+			for more {
+				f := binary.BigEndian.Uint16(g)
+				size := 4 + 2
+				if f&glyfArgsAreWords != 0 {
+					size += 2
+				}
+				switch {
+				case f&glyfHaveScale != 0:
+					size += 2
+				case f&glyfHaveXYScale != 0:
+					size += 4
+				case f&glyfHaveTwoByTwo != 0:
+					size += 8
+				}
+				g = g[size:]
+				more = f&glyfMoreComponents != 0
+				haveInstructions = haveInstructions || f&glyfHaveInstruction != 0
+			}
+			composites = append(composites, start[:len(start)-len(g)]...)
+			if haveInstructions {
+				size := int(binary.BigEndian.Uint16(g))
+				glyphs = appendU255(glyphs, size)
+				instructions = append(instructions, g[2:2+size]...)
+			}
+			continue
+		}
+		var total int
+		last := -1
+		for j := 0; j < int(n); j++ {
+			end := int(binary.BigEndian.Uint16(g[2*j:]))
+			nPoints = appendU255(nPoints, end-last)
+			last = end
+			total = end + 1
+		}
+		g = g[2*int(n):]
+		insLen := int(binary.BigEndian.Uint16(g))
+		ins := g[2 : 2+insLen]
+		g = g[2+insLen:]
+		pointFlags := make([]byte, 0, total)
+		for len(pointFlags) < total {
+			f := g[0]
+			g = g[1:]
+			pointFlags = append(pointFlags, f)
+			if f&0x08 != 0 {
+				repeat := int(g[0])
+				g = g[1:]
+				for k := 0; k < repeat; k++ {
+					pointFlags = append(pointFlags, f)
+				}
+			}
+		}
+		readCoords := func(short, same byte) []int {
+			deltas := make([]int, total)
+			for j, f := range pointFlags {
+				switch {
+				case f&short != 0:
+					d := int(g[0])
+					g = g[1:]
+					if f&same == 0 {
+						d = -d
+					}
+					deltas[j] = d
+				case f&same == 0:
+					deltas[j] = int(int16(binary.BigEndian.Uint16(g)))
+					g = g[2:]
+				}
+			}
+			return deltas
+		}
+		dxs := readCoords(glyfXShort, glyfXSame)
+		dys := readCoords(glyfYShort, glyfYSame)
+		for j := range pointFlags {
+			flag, data := encodeTriplet(pointFlags[j]&glyfOnCurve != 0, dxs[j], dys[j])
+			flags = append(flags, flag)
+			glyphs = append(glyphs, data...)
+		}
+		glyphs = appendU255(glyphs, insLen)
+		instructions = append(instructions, ins...)
+	}
+	bboxes = append(bboxBitmap, bboxes...)
+	var out []byte
+	out = binary.BigEndian.AppendUint16(out, 0)
+	out = binary.BigEndian.AppendUint16(out, 0)
+	out = binary.BigEndian.AppendUint16(out, uint16(numGlyphs))
+	if longLoca {
+		out = binary.BigEndian.AppendUint16(out, 1)
+	} else {
+		out = binary.BigEndian.AppendUint16(out, 0)
+	}
+	streams := [][]byte{nContours, nPoints, flags, glyphs, composites, bboxes, instructions}
+	for _, s := range streams {
+		out = binary.BigEndian.AppendUint32(out, uint32(len(s)))
+	}
+	for _, s := range streams {
+		out = append(out, s...)
+	}
+	return out, xMins
+}
+
+// transformHmtx applies the WOFF2 hmtx transform, omitting the left side
+// bearings that match the minimum x coordinates of the glyphs.
+func transformHmtx(hmtx []byte, numHMetrics int, xMins []int16) []byte {
+	numGlyphs := len(xMins)
+	lsb := func(i int) int16 {
+		if i < numHMetrics {
+			return int16(binary.BigEndian.Uint16(hmtx[4*i+2:]))
+		}
+		return int16(binary.BigEndian.Uint16(hmtx[4*numHMetrics+2*(i-numHMetrics):]))
+	}
+	flags := byte(3)
+	for i := 0; i < numGlyphs; i++ {
+		if lsb(i) != xMins[i] {
+			if i < numHMetrics {
+				flags &^= 1
+			} else {
+				flags &^= 2
+			}
+		}
+	}
+	out := []byte{flags}
+	for i := 0; i < numHMetrics; i++ {
+		out = append(out, hmtx[4*i:4*i+2]...)
+	}
+	for i := 0; i < numGlyphs; i++ {
+		if i < numHMetrics && flags&1 == 0 || i >= numHMetrics && flags&2 == 0 {
+			out = binary.BigEndian.AppendUint16(out, uint16(lsb(i)))
+		}
+	}
+	return out
+}
+
+// encodeTriplet is the inverse of decodeTriplet.
+func encodeTriplet(onCurve bool, x, y int) (byte, []byte) {
+	ax, ay := abs(x), abs(y)
+	var flag int
+	if !onCurve {
+		flag = 128
+	}
+	xSign, ySign := 0, 0
+	if x >= 0 {
+		xSign = 1
+	}
+	if y >= 0 {
+		ySign = 1
+	}
+	signs := xSign + 2*ySign
+	switch {
+	case x == 0 && ay < 1280:
+		return byte(flag + (ay&0xf00)>>7 + ySign), []byte{byte(ay)}
+	case y == 0 && ax < 1280:
+		return byte(flag + 10 + (ax&0xf00)>>7 + xSign), []byte{byte(ax)}
+	case ax < 65 && ay < 65:
+		return byte(flag + 20 + (ax-1)&0x30 + ((ay-1)&0x30)>>2 + signs), []byte{byte((ax-1)&0xf<<4 | (ay-1)&0xf)}
+	case ax < 769 && ay < 769:
+		return byte(flag + 84 + 12*(((ax-1)&0x300)>>8) + ((ay-1)&0x300)>>6 + signs), []byte{byte(ax - 1), byte(ay - 1)}
+	case ax < 4096 && ay < 4096:
+		return byte(flag + 120 + signs), []byte{byte(ax >> 4), byte(ax&0xf<<4 | ay>>8), byte(ay)}
+	default:
+		return byte(flag + 124 + signs), []byte{byte(ax >> 8), byte(ax), byte(ay >> 8), byte(ay)}
+	}
+}
+
+func appendU255(dst []byte, v int) []byte {
+	switch {
+	case v < 253:
+		return append(dst, byte(v))
+	case v < 253*2:
+		return append(dst, 255, byte(v-253))
+	case v < 253*3:
+		return append(dst, 254, byte(v-253*2))
+	default:
+		return append(append(dst, 253), byte(v>>8), byte(v))
+	}
+}
+
+func appendBase128(dst []byte, v int) []byte {
+	var buf [5]byte
+	i := len(buf) - 1
+	buf[i] = byte(v & 0x7f)
+	for v >>= 7; v > 0; v >>= 7 {
+		i--
+		buf[i] = byte(v&0x7f) | 0x80
+	}
+	return append(dst, buf[i:]...)
+}
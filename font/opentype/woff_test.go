@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package opentype
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestWOFF(t *testing.T) {
+	src := encodeWOFF(t, goregular.TTF)
+	ref, err := Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	face, err := Parse(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareFaces(t, ref.Face(), face.Face())
+	if got, want := face.Font(), ref.Font(); got != want {
+		t.Errorf("got font %+v, want %+v", got, want)
+	}
+	lazy, err := ParseCollectionLazy(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareFaces(t, ref.Face(), lazy[0].Face.Face())
+}
+
+// encodeWOFF compresses a TrueType font into a WOFF file.
+func encodeWOFF(t *testing.T, src []byte) []byte {
+	t.Helper()
+	const headerSize, entrySize = 44, 20
+	flavor, tables := readSfnt(src)
+	off := headerSize + entrySize*len(tables)
+	sfntSize := 12 + 16*len(tables)
+	var dir, data bytes.Buffer
+	for _, tb := range tables {
+		var z bytes.Buffer
+		w := zlib.NewWriter(&z)
+		if _, err := w.Write(tb.data); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		// Tables that don't compress are stored as is.
+		comp := z.Bytes()
+		if len(comp) >= len(tb.data) {
+			comp = tb.data
+		}
+		padded := append(tb.data[:len(tb.data):len(tb.data)], make([]byte, (4-len(tb.data)%4)%4)...)
+		var sum uint32
+		for i := 0; i < len(padded); i += 4 {
+			sum += binary.BigEndian.Uint32(padded[i:])
+		}
+		for _, v := range []uint32{tb.tag, uint32(off + data.Len()), uint32(len(comp)), uint32(len(tb.data)), sum} {
+			dir.Write(binary.BigEndian.AppendUint32(nil, v))
+		}
+		data.Write(comp)
+		for data.Len()%4 != 0 {
+			data.WriteByte(0)
+		}
+		sfntSize += len(padded)
+	}
+	var out []byte
+	out = binary.BigEndian.AppendUint32(out, 0x774F4646) // "wOFF"
+	out = binary.BigEndian.AppendUint32(out, flavor)
+	out = binary.BigEndian.AppendUint32(out, uint32(off+data.Len()))
+	out = binary.BigEndian.AppendUint16(out, uint16(len(tables)))
+	out = binary.BigEndian.AppendUint16(out, 0)
+	out = binary.BigEndian.AppendUint32(out, uint32(sfntSize))
+	out = binary.BigEndian.AppendUint16(out, 1)
+	out = binary.BigEndian.AppendUint16(out, 0)
+	// No metadata or private data.
+	out = append(out, make([]byte, 5*4)...)
+	out = append(out, dir.Bytes()...)
+	return append(out, data.Bytes()...)
+}
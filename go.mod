@@ -5,6 +5,7 @@ go 1.24.0
 require (
 	eliasnaur.com/font v0.0.0-20230308162249-dd43949cb42d
 	gioui.org/shader v1.0.8
+	github.com/andybalholm/brotli v1.1.1
 	github.com/go-text/typesetting v0.3.0
 	golang.org/x/exp v0.0.0-20250408133849-7e4ce0ab07d0
 	golang.org/x/exp/shiny v0.0.0-20250408133849-7e4ce0ab07d0
@@ -12,13 +12,54 @@ import (
 type WriteCmd struct {
 	Type string
 	Data io.ReadCloser
+	// Formats offers the content in additional formats, such as an HTML
+	// table or a PNG image along with its text. Applications reading the
+	// clipboard pick the format they prefer. Only X11 and Wayland support
+	// additional formats; other platforms receive Type and Data only.
+	Formats []Format
+}
+
+// Format is the content of the clipboard in a particular format.
+type Format struct {
+	// Type is the MIME type of Data, such as "text/html" or "image/png".
+	// Text is identified by "application/text".
+	Type string
+	Data io.ReadCloser
 }
 
 // ReadCmd requests the text of the clipboard, delivered to
 // the handler through an [io/transfer.DataEvent].
 type ReadCmd struct {
 	Tag event.Tag
+	// Types lists the MIME types accepted by the handler, in order of
+	// preference. The content is delivered in the first type offered by
+	// the clipboard. If Types is empty, or the platform supports text only,
+	// the content is delivered as "application/text".
+	Types []string
+}
+
+// TypesCmd requests the MIME types offered by the clipboard, delivered
+// to the handler through a [TypesEvent]. Only X11 and Wayland report the
+// types of the clipboard.
+type TypesCmd struct {
+	Tag event.Tag
+}
+
+// TypesFilter matches [TypesEvent]s for Target.
+type TypesFilter struct {
+	Target event.Tag
+}
+
+// TypesEvent lists the MIME types offered by the clipboard, as requested
+// by a [TypesCmd]. Text is listed as "application/text".
+type TypesEvent struct {
+	Types []string
 }
 
 func (WriteCmd) ImplementsCommand() {}
 func (ReadCmd) ImplementsCommand()  {}
+func (TypesCmd) ImplementsCommand() {}
+
+func (TypesFilter) ImplementsFilter() {}
+
+func (TypesEvent) ImplementsEvent() {}
@@ -8,11 +8,29 @@ import (
 
 	"gioui.org/io/clipboard"
 	"gioui.org/io/event"
+	"gioui.org/io/transfer"
 )
 
+// ClipboardFormat is clipboard content in a particular format.
+type ClipboardFormat struct {
+	// Type is the MIME type of Data.
+	Type string
+	Data []byte
+}
+
 // clipboardState contains the state for clipboard event routing.
 type clipboardState struct {
-	receivers []event.Tag
+	receivers []clipboardReceiver
+	// typeReceivers are waiting for a clipboard.TypesEvent.
+	typeReceivers []event.Tag
+}
+
+// clipboardReceiver is a handler waiting to read the clipboard.
+type clipboardReceiver struct {
+	tag event.Tag
+	// types are the MIME types accepted by the handler, in order of
+	// preference.
+	types []string
 }
 
 type clipboardQueue struct {
@@ -20,6 +38,12 @@ type clipboardQueue struct {
 	requested bool
 	mime      string
 	text      []byte
+	// formats are the additional formats of text.
+	formats        []ClipboardFormat
+	typesRequested bool
+	// textOnly is set while the clipboard is read by a platform that
+	// offers text only.
+	textOnly bool
 }
 
 // WriteClipboard returns the most recent data to be copied
@@ -30,9 +54,21 @@ func (q *clipboardQueue) WriteClipboard() (mime string, content []byte, ok bool)
 	}
 	content = q.text
 	q.text = nil
+	q.formats = nil
 	return q.mime, content, true
 }
 
+// WriteClipboardFormats is like WriteClipboard, but returns the data
+// in every format, starting with the primary format.
+func (q *clipboardQueue) WriteClipboardFormats() ([]ClipboardFormat, bool) {
+	formats := q.formats
+	mime, content, ok := q.WriteClipboard()
+	if !ok {
+		return nil, false
+	}
+	return append([]ClipboardFormat{{Type: mime, Data: content}}, formats...), true
+}
+
 // ClipboardRequested reports if any new handler is waiting
 // to read the clipboard.
 func (q *clipboardQueue) ClipboardRequested(state clipboardState) bool {
@@ -41,31 +77,130 @@ func (q *clipboardQueue) ClipboardRequested(state clipboardState) bool {
 	return req
 }
 
-func (q *clipboardQueue) Push(state clipboardState, e event.Event) (clipboardState, []taggedEvent) {
+// ClipboardTextRequested is like ClipboardRequested, for platforms that
+// offer the clipboard as text only.
+func (q *clipboardQueue) ClipboardTextRequested(state clipboardState) bool {
+	req := q.ClipboardRequested(state)
+	if req {
+		q.textOnly = true
+	}
+	return req
+}
+
+// ClipboardTypes returns the MIME types requested by the handlers
+// waiting to read the clipboard, in order of preference.
+func (q *clipboardQueue) ClipboardTypes(state clipboardState) []string {
+	var types []string
+	for _, r := range state.receivers {
+		for _, t := range r.types {
+			if !slices.Contains(types, t) {
+				types = append(types, t)
+			}
+		}
+	}
+	return types
+}
+
+// ClipboardTypesRequested reports if any new handler is waiting
+// for the types offered by the clipboard.
+func (q *clipboardQueue) ClipboardTypesRequested(state clipboardState) bool {
+	req := len(state.typeReceivers) > 0 && q.typesRequested
+	q.typesRequested = false
+	return req
+}
+
+// Push delivers the clipboard content to the receivers accepting its type.
+// The clipboard is read again for the remaining receivers, in the types
+// they accept, unless the platform offers text only. A DataEvent without
+// a Type reports that the clipboard offers none of the types requested,
+// and releases every receiver.
+func (q *clipboardQueue) Push(state clipboardState, e transfer.DataEvent) (clipboardState, []taggedEvent) {
+	textOnly := q.textOnly
+	q.textOnly = false
+	if e.Type == "" {
+		state.receivers = nil
+		return state, nil
+	}
 	var evts []taggedEvent
+	var waiting []clipboardReceiver
 	for _, r := range state.receivers {
+		if slices.Contains(r.types, e.Type) {
+			evts = append(evts, taggedEvent{tag: r.tag, event: e})
+		} else {
+			waiting = append(waiting, r)
+		}
+	}
+	if textOnly {
+		// The other types can't be read.
+		waiting = nil
+	}
+	state.receivers = waiting
+	q.requested = len(waiting) > 0
+	return state, evts
+}
+
+func (q *clipboardQueue) PushTypes(state clipboardState, e clipboard.TypesEvent) (clipboardState, []taggedEvent) {
+	var evts []taggedEvent
+	for _, r := range state.typeReceivers {
 		evts = append(evts, taggedEvent{tag: r, event: e})
 	}
-	state.receivers = nil
+	state.typeReceivers = nil
 	return state, evts
 }
 
 func (q *clipboardQueue) ProcessWriteClipboard(req clipboard.WriteCmd) {
 	defer req.Data.Close()
+	for _, f := range req.Formats {
+		defer f.Data.Close()
+	}
 	content, err := io.ReadAll(req.Data)
 	if err != nil {
 		return
 	}
+	var formats []ClipboardFormat
+	for _, f := range req.Formats {
+		data, err := io.ReadAll(f.Data)
+		if err != nil {
+			return
+		}
+		formats = append(formats, ClipboardFormat{Type: f.Type, Data: data})
+	}
 	q.mime = req.Type
 	q.text = content
+	q.formats = formats
 }
 
-func (q *clipboardQueue) ProcessReadClipboard(state clipboardState, tag event.Tag) clipboardState {
-	if slices.Contains(state.receivers, tag) {
-		return state
+func (q *clipboardQueue) ProcessReadClipboard(state clipboardState, req clipboard.ReadCmd) clipboardState {
+	types := req.Types
+	if len(types) == 0 {
+		types = []string{"application/text"}
 	}
 	n := len(state.receivers)
-	state.receivers = append(state.receivers[:n:n], tag)
-	q.requested = true
+	receivers := append([]clipboardReceiver(nil), state.receivers...)
+	i := slices.IndexFunc(receivers, func(r clipboardReceiver) bool {
+		return r.tag == req.Tag
+	})
+	if i == -1 {
+		receivers = append(receivers, clipboardReceiver{tag: req.Tag})
+		i = n
+		q.requested = true
+	}
+	r := &receivers[i]
+	for _, t := range types {
+		if !slices.Contains(r.types, t) {
+			r.types = append(r.types[:len(r.types):len(r.types)], t)
+		}
+	}
+	state.receivers = receivers
+	return state
+}
+
+func (q *clipboardQueue) ProcessTypesClipboard(state clipboardState, tag event.Tag) clipboardState {
+	if slices.Contains(state.typeReceivers, tag) {
+		return state
+	}
+	n := len(state.typeReceivers)
+	state.typeReceivers = append(state.typeReceivers[:n:n], tag)
+	q.typesRequested = true
 	return state
 }
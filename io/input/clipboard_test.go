@@ -4,6 +4,7 @@ package input
 
 import (
 	"io"
+	"slices"
 	"strings"
 	"testing"
 
@@ -123,3 +124,120 @@ func assertClipboardWriteCmd(t *testing.T, router *Router, mimeExp, expected str
 		t.Errorf("got text %s, expected %s", text, expected)
 	}
 }
+
+func TestQueueProcessWriteClipboardFormats(t *testing.T) {
+	r := new(Router)
+
+	r.Source().Execute(clipboard.WriteCmd{
+		Type: "application/text",
+		Data: io.NopCloser(strings.NewReader("a\tb")),
+		Formats: []clipboard.Format{
+			{Type: "text/html", Data: io.NopCloser(strings.NewReader("<table><tr><td>a<td>b</table>"))},
+		},
+	})
+	formats, ok := r.WriteClipboardFormats()
+	if !ok {
+		t.Fatal("no clipboard write")
+	}
+	want := []ClipboardFormat{
+		{Type: "application/text", Data: []byte("a\tb")},
+		{Type: "text/html", Data: []byte("<table><tr><td>a<td>b</table>")},
+	}
+	if len(formats) != len(want) {
+		t.Fatalf("got %d formats, want %d", len(formats), len(want))
+	}
+	for i, f := range formats {
+		if f.Type != want[i].Type || string(f.Data) != string(want[i].Data) {
+			t.Errorf("got format %s %q, want %s %q", f.Type, f.Data, want[i].Type, want[i].Data)
+		}
+	}
+	if _, ok := r.WriteClipboardFormats(); ok {
+		t.Error("duplicated write")
+	}
+}
+
+func TestClipboardTypes(t *testing.T) {
+	ops, r, handlers := new(op.Ops), new(Router), make([]int, 2)
+
+	r.Source().Execute(clipboard.ReadCmd{Tag: &handlers[0], Types: []string{"image/png", "text/html"}})
+	r.Source().Execute(clipboard.ReadCmd{Tag: &handlers[1]})
+	want := []string{"image/png", "text/html", "application/text"}
+	if got := r.ClipboardTypes(); !slices.Equal(got, want) {
+		t.Errorf("got requested types %v, want %v", got, want)
+	}
+	r.Queue(transfer.DataEvent{
+		Type: "image/png",
+		Open: func() io.ReadCloser {
+			return io.NopCloser(strings.NewReader("PNG"))
+		},
+	})
+	assertEventTypeSequence(t, events(r, -1, transfer.TargetFilter{Target: &handlers[0], Type: "image/png"}), transfer.DataEvent{})
+	// The text reader is still waiting, and the clipboard is read again
+	// for its type.
+	assertClipboardReadCmd(t, r, 1)
+	if got, want := r.ClipboardTypes(), []string{"application/text"}; !slices.Equal(got, want) {
+		t.Errorf("got requested types %v after read, want %v", got, want)
+	}
+	r.Queue(transfer.DataEvent{
+		Type: "application/text",
+		Open: func() io.ReadCloser {
+			return io.NopCloser(strings.NewReader("text"))
+		},
+	})
+	assertEventTypeSequence(t, events(r, -1, transfer.TargetFilter{Target: &handlers[1], Type: "application/text"}), transfer.DataEvent{})
+	assertClipboardReadCmd(t, r, 0)
+	if got := r.ClipboardTypes(); len(got) != 0 {
+		t.Errorf("got requested types %v after read", got)
+	}
+
+	r.Source().Execute(clipboard.TypesCmd{Tag: &handlers[0]})
+	if !r.ClipboardTypesRequested() {
+		t.Error("missing types request")
+	}
+	r.Frame(ops)
+	if r.ClipboardTypesRequested() {
+		t.Error("duplicated types request")
+	}
+	r.Queue(clipboard.TypesEvent{Types: want})
+	evts := events(r, -1, clipboard.TypesFilter{Target: &handlers[0]})
+	assertEventTypeSequence(t, evts, clipboard.TypesEvent{})
+	if got := evts[0].(clipboard.TypesEvent).Types; !slices.Equal(got, want) {
+		t.Errorf("got types %v, want %v", got, want)
+	}
+	assertEventTypeSequence(t, events(r, -1, clipboard.TypesFilter{Target: &handlers[0]}))
+}
+
+func TestClipboardNoMatchingType(t *testing.T) {
+	ops, r, handler := new(op.Ops), new(Router), new(int)
+
+	r.Source().Execute(clipboard.ReadCmd{Tag: handler, Types: []string{"image/png"}})
+	assertClipboardReadCmd(t, r, 1)
+	r.Frame(ops)
+	// The clipboard offers no PNG image.
+	r.Queue(transfer.DataEvent{})
+	assertEventTypeSequence(t, events(r, -1, transfer.TargetFilter{Target: handler, Type: "image/png"}))
+	assertClipboardReadCmd(t, r, 0)
+
+	// Later reads are requested again.
+	r.Source().Execute(clipboard.ReadCmd{Tag: handler, Types: []string{"image/png"}})
+	assertClipboardReadCmd(t, r, 1)
+}
+
+func TestClipboardTextOnly(t *testing.T) {
+	ops, r, handler := new(op.Ops), new(Router), new(int)
+
+	r.Source().Execute(clipboard.ReadCmd{Tag: handler, Types: []string{"image/png"}})
+	if !r.ClipboardTextRequested() {
+		t.Fatal("missing request")
+	}
+	r.Frame(ops)
+	// A platform with text only answers with text.
+	r.Queue(transfer.DataEvent{
+		Type: "application/text",
+		Open: func() io.ReadCloser {
+			return io.NopCloser(strings.NewReader("text"))
+		},
+	})
+	assertEventTypeSequence(t, events(r, -1, transfer.TargetFilter{Target: handler, Type: "image/png"}))
+	assertClipboardReadCmd(t, r, 0)
+}
@@ -135,6 +135,8 @@ type handler struct {
 type filter struct {
 	pointer   pointerFilter
 	focusable bool
+	// clipboardTypes matches clipboard.TypesEvent.
+	clipboardTypes bool
 }
 
 // taggedFilter is a filter for a particular tag.
@@ -228,6 +230,8 @@ func (q *Router) Event(filters ...event.Filter) (event.Event, bool) {
 			t = f.Target
 		case pointer.Filter:
 			t = f.Target
+		case clipboard.TypesFilter:
+			t = f.Target
 		}
 		if t == nil {
 			continue
@@ -408,12 +412,15 @@ func (f *filter) Add(flt event.Filter) {
 		f.pointer.Add(flt)
 	case transfer.SourceFilter, transfer.TargetFilter:
 		f.pointer.Add(flt)
+	case clipboard.TypesFilter:
+		f.clipboardTypes = true
 	}
 }
 
 // Merge f2 into f.
 func (f *filter) Merge(f2 filter) {
 	f.focusable = f.focusable || f2.focusable
+	f.clipboardTypes = f.clipboardTypes || f2.clipboardTypes
 	f.pointer.Merge(f2.pointer)
 }
 
@@ -421,6 +428,8 @@ func (f *filter) Matches(e event.Event) bool {
 	switch e.(type) {
 	case key.FocusEvent, key.SnippetEvent, key.EditEvent, key.SelectionEvent:
 		return f.focusable
+	case clipboard.TypesEvent:
+		return f.clipboardTypes
 	default:
 		return f.pointer.Matches(e)
 	}
@@ -473,6 +482,10 @@ func (q *Router) processEvent(e event.Event, system bool) {
 		cstate, evts := q.cqueue.Push(state.clipboardState, e)
 		state.clipboardState = cstate
 		q.changeState(e, state, evts)
+	case clipboard.TypesEvent:
+		cstate, evts := q.cqueue.PushTypes(state.clipboardState, e)
+		state.clipboardState = cstate
+		q.changeState(e, state, evts)
 	default:
 		panic("unknown event type")
 	}
@@ -549,7 +562,9 @@ func (q *Router) executeCommand(c Command) stateChange {
 	case clipboard.WriteCmd:
 		q.cqueue.ProcessWriteClipboard(req)
 	case clipboard.ReadCmd:
-		state.clipboardState = q.cqueue.ProcessReadClipboard(state.clipboardState, req.Tag)
+		state.clipboardState = q.cqueue.ProcessReadClipboard(state.clipboardState, req)
+	case clipboard.TypesCmd:
+		state.clipboardState = q.cqueue.ProcessTypesClipboard(state.clipboardState, req.Tag)
 	case pointer.GrabCmd:
 		state.pointerState, evts = q.pointer.queue.grab(state.pointerState, req)
 	case op.InvalidateCmd:
@@ -732,6 +747,34 @@ func (q *Router) ClipboardRequested() bool {
 	return q.cqueue.ClipboardRequested(q.lastState().clipboardState)
 }
 
+// ClipboardTextRequested is like ClipboardRequested, for platforms that
+// offer the clipboard as text only. The next [transfer.DataEvent] is
+// delivered to the handlers accepting text, and releases the others.
+func (q *Router) ClipboardTextRequested() bool {
+	return q.cqueue.ClipboardTextRequested(q.lastState().clipboardState)
+}
+
+// WriteClipboardFormats is like WriteClipboard, but returns the content
+// in every format offered by the most recent [clipboard.WriteCmd], starting
+// with its Type.
+func (q *Router) WriteClipboardFormats() ([]ClipboardFormat, bool) {
+	return q.cqueue.WriteClipboardFormats()
+}
+
+// ClipboardTypes returns the MIME types accepted by the handlers waiting
+// to read the clipboard, in order of preference. If the clipboard offers
+// none of them, queue a [transfer.DataEvent] without a Type to release the
+// handlers.
+func (q *Router) ClipboardTypes() []string {
+	return q.cqueue.ClipboardTypes(q.lastState().clipboardState)
+}
+
+// ClipboardTypesRequested reports if any new handler is waiting for
+// the types offered by the clipboard.
+func (q *Router) ClipboardTypesRequested() bool {
+	return q.cqueue.ClipboardTypesRequested(q.lastState().clipboardState)
+}
+
 // Cursor returns the last cursor set.
 func (q *Router) Cursor() pointer.Cursor {
 	return q.state().cursor
@@ -17,6 +17,7 @@ import (
 	"github.com/go-text/typesetting/fontscan"
 	"github.com/go-text/typesetting/language"
 	"github.com/go-text/typesetting/shaping"
+	"github.com/go-text/typesetting/unicodedata"
 	"golang.org/x/image/math/fixed"
 	"golang.org/x/text/unicode/bidi"
 
@@ -197,6 +198,8 @@ type runLayout struct {
 	// truncator indicates that this run is a text truncator standing in for remaining
 	// text.
 	truncator bool
+	// sideways indicates that the glyphs of this vertical run are rotated 90° clockwise.
+	sideways bool
 }
 
 // shaperImpl implements the shaping and line-wrapping of opentype fonts.
@@ -225,6 +228,24 @@ type shaperImpl struct {
 
 	// bitmapGlyphCache caches extracted bitmap glyph images.
 	bitmapGlyphCache bitmapCache
+
+	// hyphenators maps languages to the hyphenators used for them.
+	hyphenators map[language.Language]Hyphenator
+
+	// fonts indexes the faces known to the shaper, and fontsByFamily maps
+	// normalized family names to indices into fonts.
+	fonts         []fontEntry
+	fontsByFamily map[string][]int
+	// fallbacks maps scripts to the normalized families tried for them
+	// before the default fallback rules.
+	fallbacks map[language.Script][]string
+	// query is the font query of the text being shaped.
+	query fontscan.Query
+	// unloaded is the number of lazily parsed faces that are yet to be loaded.
+	unloaded int
+	// collections holds the faces of the system font files loaded most
+	// recently, most recent first.
+	collections []fontCollection
 }
 
 // debugLogger only logs messages if debug.Text is true.
@@ -242,19 +263,24 @@ func (d debugLogger) Printf(format string, args ...any) {
 	}
 }
 
-func newShaperImpl(systemFonts bool, collection []FontFace) *shaperImpl {
+func newShaperImpl(systemFonts bool, cacheDir string, collection []FontFace) *shaperImpl {
 	var shaper shaperImpl
 	shaper.logger = newDebugLogger()
 	shaper.fontMap = fontscan.NewFontMap(shaper.logger)
 	shaper.faceToIndex = make(map[*font.Font]int)
 	if systemFonts {
-		str, err := os.UserCacheDir()
-		if err != nil {
-			shaper.logger.Printf("failed resolving font cache dir: %v", err)
-			shaper.logger.Printf("skipping system font load")
+		if cacheDir == "" {
+			var err error
+			cacheDir, err = os.UserCacheDir()
+			if err != nil {
+				shaper.logger.Printf("failed resolving font cache dir: %v", err)
+				shaper.logger.Printf("skipping system font load")
+			}
 		}
-		if err := shaper.fontMap.UseSystemFonts(str); err != nil {
+		if err := shaper.fontMap.UseSystemFonts(cacheDir); err != nil {
 			shaper.logger.Printf("failed loading system fonts: %v", err)
+		} else {
+			shaper.addSystemFonts(cacheDir)
 		}
 	}
 	for _, f := range collection {
@@ -270,8 +296,31 @@ func newShaperImpl(systemFonts bool, collection []FontFace) *shaperImpl {
 // in the order in which they are loaded, with the first face being the default.
 func (s *shaperImpl) Load(f FontFace) {
 	desc := opentype.FontToDescription(f.Font)
-	s.fontMap.AddFace(f.Face.Face(), fontscan.Location{File: fmt.Sprint(desc)}, desc)
-	s.addFace(f.Face.Face(), f.Font)
+	if lazy, ok := f.Face.(lazyFace); ok && !lazy.Loaded() {
+		// Defer parsing the face until it is needed by ResolveFace.
+		s.addFont(fontEntry{
+			info:   FontInfo{Font: f.Font},
+			family: font.NormalizeFamily(desc.Family),
+			aspect: desc.Aspect,
+			lazy:   lazy,
+		})
+		s.unloaded++
+		return
+	}
+	face := f.Face.Face()
+	if face == nil {
+		// Lazily parsed faces that fail to parse have no face.
+		s.logger.Printf("skipping face %s(style:%s, weight:%d): failed to parse", f.Font.Typeface, f.Font.Style, f.Font.Weight)
+		return
+	}
+	s.fontMap.AddFace(face, fontscan.Location{File: fmt.Sprint(desc)}, desc)
+	s.addFace(face, f.Font)
+	s.addFont(fontEntry{
+		info:   FontInfo{Font: f.Font},
+		family: font.NormalizeFamily(desc.Family),
+		aspect: desc.Aspect,
+		face:   face,
+	})
 }
 
 func (s *shaperImpl) addFace(f *font.Face, md giofont.Font) {
@@ -335,6 +384,43 @@ func splitByScript(inputs []shaping.Input, documentDir di.Direction, buf []shapi
 	return splitInputs
 }
 
+// splitByOrientation divides vertical inputs into runs of upright and sideways text
+// according to the Unicode vertical orientation of their runes. It must be called
+// after splitByScript. It will use buf as the backing memory for the returned slice
+// if buf is non-nil.
+func splitByOrientation(inputs []shaping.Input, buf []shaping.Input) []shaping.Input {
+	split := buf
+	for _, input := range inputs {
+		vo := unicodedata.LookupVerticalOrientation(input.Script)
+		current := input
+		for i := input.RunStart; i < input.RunEnd; i++ {
+			sideways := vo.Orientation(input.Text[i])
+			if i == input.RunStart {
+				current.Direction.SetSideways(sideways)
+				continue
+			}
+			if sideways != current.Direction.IsSideways() {
+				current.RunEnd = i
+				split = append(split, current)
+				current.RunStart = i
+				current.Direction.SetSideways(sideways)
+			}
+		}
+		current.RunEnd = input.RunEnd
+		split = append(split, current)
+	}
+	return split
+}
+
+// flipVerticalAdvances converts the advances of a vertical run, which the shaper reports
+// along an upward pointing y axis, to positive distances down the page.
+func flipVerticalAdvances(out *shaping.Output) {
+	for i := range out.Glyphs {
+		out.Glyphs[i].YAdvance = -out.Glyphs[i].YAdvance
+	}
+	out.Advance = -out.Advance
+}
+
 func (s *shaperImpl) splitBidi(input shaping.Input) []shaping.Input {
 	var splitInputs []shaping.Input
 	if input.Direction.Axis() != di.Horizontal || input.RunStart == input.RunEnd {
@@ -368,11 +454,26 @@ func (s *shaperImpl) splitBidi(input shaping.Input) []shaping.Input {
 
 // ResolveFace allows shaperImpl to implement shaping.FontMap, wrapping its fontMap
 // field and ensuring that any faces loaded as part of the search are registered with
-// ids so that they can be referred to by a GlyphID.
+// ids so that they can be referred to by a GlyphID. Faces from the fallback chain
+// configured for the script of r take precedence over the fontMap's own fallbacks,
+// and lazily parsed faces are loaded when they are a better match than the faces
+// known to the fontMap.
 func (s *shaperImpl) ResolveFace(r rune) *font.Face {
 	face := s.fontMap.ResolveFace(r)
+	var family string
+	var aspect font.Aspect
+	if face != nil {
+		family, aspect = s.fontMap.FontMetadata(face.Font)
+	}
+	if fallback, md, ok := s.resolveFallback(r, face, family); ok {
+		s.addFace(fallback, md)
+		return fallback
+	}
+	if lazy, md, ok := s.resolveLazy(r, face, family); ok {
+		s.addFace(lazy, md)
+		return lazy
+	}
 	if face != nil {
-		family, aspect := s.fontMap.FontMetadata(face.Font)
 		md := opentype.DescriptionToFont(font.Description{
 			Family: family,
 			Aspect: aspect,
@@ -417,6 +518,9 @@ func (s *shaperImpl) shapeText(ppem fixed.Int26_6, lc system.Locale, txt []rune)
 	inputs := s.splitBidi(input)
 	inputs = s.splitByFaces(inputs, s.splitScratch1[:0])
 	inputs = splitByScript(inputs, lcfg.Direction, s.splitScratch2[:0])
+	if lcfg.Direction.IsVertical() {
+		inputs = splitByOrientation(inputs, s.splitScratch1[:0])
+	}
 	// Shape all inputs.
 	if needed := len(inputs) - len(s.outScratchBuf); needed > 0 {
 		s.outScratchBuf = slices.Grow(s.outScratchBuf, needed)
@@ -424,7 +528,11 @@ func (s *shaperImpl) shapeText(ppem fixed.Int26_6, lc system.Locale, txt []rune)
 	s.outScratchBuf = s.outScratchBuf[:0]
 	for _, input := range inputs {
 		if input.Face != nil {
-			s.outScratchBuf = append(s.outScratchBuf, s.shaper.Shape(input))
+			out := s.shaper.Shape(input)
+			if out.Direction.IsVertical() {
+				flipVerticalAdvances(&out)
+			}
+			s.outScratchBuf = append(s.outScratchBuf, out)
 		} else {
 			s.outScratchBuf = append(s.outScratchBuf, shaping.Output{
 				// Use the text size as the advance of the entire fake run so that
@@ -498,20 +606,83 @@ func (s *shaperImpl) shapeAndWrapText(params Parameters, txt []rune) (_ []shapin
 			families = parsed
 		}
 	}
-	s.fontMap.SetQuery(fontscan.Query{
+	s.query = fontscan.Query{
 		Families: families,
 		Aspect:   opentype.FontToDescription(params.Font).Aspect,
-	})
+	}
+	s.fontMap.SetQuery(s.query)
 	if wc.TruncateAfterLines > 0 {
 		if len(params.Truncator) == 0 {
 			params.Truncator = "…"
 		}
 		// We only permit a single run as the truncator, regardless of whether more were generated.
 		// Just use the first one.
-		wc.Truncator = s.shapeText(params.PxPerEm, params.Locale, []rune(params.Truncator))[0]
+		truncator := []rune(params.Truncator)
+		outs := s.shapeText(params.PxPerEm, params.Locale, truncator)
+		applySpacing(outs, truncator, params.LetterSpacing, params.WordSpacing)
+		wc.Truncator = outs[0]
 	}
+	hyphenated, hyphens := s.hyphenate(params, txt)
+	outs := s.shapeText(params.PxPerEm, params.Locale, hyphenated)
+	reserveHyphens(outs, hyphens)
+	applySpacing(outs, hyphenated, params.LetterSpacing, params.WordSpacing)
 	// Wrap outputs into lines.
-	return s.wrapper.WrapParagraph(wc, params.MaxWidth, txt, shaping.NewSliceIterator(s.shapeText(params.PxPerEm, params.Locale, txt)))
+	lines, truncated := s.wrapper.WrapParagraph(wc, params.MaxWidth, hyphenated, shaping.NewSliceIterator(outs))
+	if wc.Direction.IsVertical() {
+		for _, l := range lines {
+			l.AdjustBaselines()
+		}
+	}
+	hasTruncator := wc.TruncateAfterLines > 0 && (truncated > 0 || (params.forceTruncate && len(lines) == params.MaxLines))
+	truncated = resolveHyphens(lines, hyphens, len(hyphenated), truncated, hasTruncator)
+	if params.Alignment == Justify {
+		justify(lines, txt, params.MaxWidth)
+	}
+	return lines, truncated
+}
+
+// applySpacing widens the advances of the glyph clusters within outs by letterSpacing,
+// and the advances of word separators by wordSpacing. txt must be the text the outputs
+// were shaped from. The extra space is added after each cluster without moving glyph
+// outlines, so that the line wrapper accounts for it when breaking lines.
+func applySpacing(outs []shaping.Output, txt []rune, letterSpacing, wordSpacing fixed.Int26_6) {
+	if letterSpacing == 0 && wordSpacing == 0 {
+		return
+	}
+	for i := range outs {
+		run := &outs[i]
+		for j := range run.Glyphs {
+			g := &run.Glyphs[j]
+			if g.GlyphCount == 0 {
+				// Synthetic glyphs for missing faces have no cluster structure to follow.
+				continue
+			}
+			lastInCluster := j == len(run.Glyphs)-1 || run.Glyphs[j+1].ClusterIndex != g.ClusterIndex
+			if !lastInCluster {
+				continue
+			}
+			extra := letterSpacing
+			if g.RuneCount == 1 && g.ClusterIndex < len(txt) && isWordSeparator(txt[g.ClusterIndex]) {
+				extra += wordSpacing
+			}
+			if run.Direction.IsVertical() {
+				g.YAdvance += extra
+			} else {
+				g.XAdvance += extra
+			}
+		}
+		run.RecomputeAdvance()
+	}
+}
+
+// isWordSeparator reports whether r separates words for the purpose of word spacing.
+// See https://www.w3.org/TR/css-text-3/#word-separator.
+func isWordSeparator(r rune) bool {
+	switch r {
+	case ' ', '\u00A0', '\u1361', '\U00010100', '\U00010101', '\U0001039F', '\U0001091F':
+		return true
+	}
+	return false
 }
 
 // replaceControlCharacters replaces problematic unicode
@@ -557,6 +728,10 @@ func calculateYOffsets(lines []line) {
 	if len(lines) < 1 {
 		return
 	}
+	if lines[0].direction.Axis() == system.Vertical {
+		calculateXOffsets(lines)
+		return
+	}
 	// Ceil the first value to ensure that we don't baseline it too close to the top of the
 	// viewport and cut off the top pixel.
 	currentY := lines[0].ascent.Ceil()
@@ -568,6 +743,24 @@ func calculateYOffsets(lines []line) {
 	}
 }
 
+// calculateXOffsets positions the columns of vertical text, storing the x coordinate
+// of the center line of each column in its yOffset. Columns progress from right to
+// left, with the left edge of the final column at zero.
+func calculateXOffsets(lines []line) {
+	total := lines[0].ascent.Ceil()
+	for i := 1; i < len(lines); i++ {
+		total += lines[i].lineHeight.Round()
+	}
+	total += lines[len(lines)-1].descent.Ceil()
+	current := lines[0].ascent.Ceil()
+	for i := range lines {
+		if i > 0 {
+			current += lines[i].lineHeight.Round()
+		}
+		lines[i].yOffset = total - current
+	}
+}
+
 // LayoutRunes shapes and wraps the text, and returns the result in Gio's shaped text format.
 func (s *shaperImpl) LayoutRunes(params Parameters, txt []rune) document {
 	hasNewline := len(txt) > 0 && txt[len(txt)-1] == '\n'
@@ -640,11 +833,13 @@ func alignWidth(minWidth int, lines []line) int {
 func (s *shaperImpl) Shape(pathOps *op.Ops, gs []Glyph) clip.PathSpec {
 	var lastPos f32.Point
 	var x fixed.Int26_6
+	var y int32
 	var builder clip.Path
 	builder.Begin(pathOps)
 	for i, g := range gs {
 		if i == 0 {
 			x = g.X
+			y = g.Y
 		}
 		ppem, faceIdx, gid := splitGlyphID(g.ID)
 		if faceIdx >= len(s.faces) {
@@ -662,11 +857,12 @@ func (s *shaperImpl) Shape(pathOps *op.Ops, gs []Glyph) clip.PathSpec {
 			// Move to glyph position.
 			pos := f32.Point{
 				X: fixedToFloat((g.X - x) - g.Offset.X),
-				Y: -fixedToFloat(g.Offset.Y),
+				Y: float32(g.Y-y) - fixedToFloat(g.Offset.Y),
 			}
 			builder.Move(pos.Sub(lastPos))
 			lastPos = pos
 			var lastArg f32.Point
+			sideways := g.Flags&FlagSideways != 0
 
 			// Convert fonts.Segments to relative segments.
 			for _, fseg := range outline.Segments {
@@ -683,6 +879,13 @@ func (s *shaperImpl) Shape(pathOps *op.Ops, gs []Glyph) clip.PathSpec {
 						X: fseg.Args[i].X * scaleFactor,
 						Y: -fseg.Args[i].Y * scaleFactor,
 					}
+					if sideways {
+						// Rotate the outline 90° clockwise around the dot.
+						a = f32.Point{
+							X: fseg.Args[i].Y * scaleFactor,
+							Y: fseg.Args[i].X * scaleFactor,
+						}
+					}
 					args[i] = a.Sub(lastArg)
 					if i == nargs-1 {
 						lastArg = a
@@ -707,6 +910,125 @@ func (s *shaperImpl) Shape(pathOps *op.Ops, gs []Glyph) clip.PathSpec {
 	return builder.End()
 }
 
+// Decorations returns a path enclosing the decoration lines described by d for
+// every glyph within gs. Adjacent glyphs sharing decoration metrics are merged
+// into a single rectangle to avoid visible seams. The positioning of the path uses
+// the same logic as Shape(), so the returned path can be added at the same offset
+// and will align correctly.
+func (s *shaperImpl) Decorations(pathOps *op.Ops, gs []Glyph, d Decoration) clip.PathSpec {
+	var builder clip.Path
+	builder.Begin(pathOps)
+	for _, r := range s.decorationRects(gs, d) {
+		builder.MoveTo(r.min)
+		builder.LineTo(f32.Point{X: r.max.X, Y: r.min.Y})
+		builder.LineTo(r.max)
+		builder.LineTo(f32.Point{X: r.min.X, Y: r.max.Y})
+		builder.Close()
+	}
+	return builder.End()
+}
+
+// decorationRect is a rectangle of a decoration line.
+type decorationRect struct {
+	min, max f32.Point
+}
+
+// decorationRects returns the rectangles of the decoration lines described by d
+// for the glyphs within gs, relative to the first glyph. Vertical glyphs are not
+// decorated.
+func (s *shaperImpl) decorationRects(gs []Glyph, d Decoration) []decorationRect {
+	var rects []decorationRect
+	var x fixed.Int26_6
+	for _, kind := range [...]Decoration{Underline, Strikethrough} {
+		if d&kind == 0 {
+			continue
+		}
+		var (
+			open                bool
+			start, end          float32
+			top, bottom         float32
+			spanTop, spanBottom float32
+			lastFace            int
+			lastPPEM            fixed.Int26_6
+		)
+		flush := func() {
+			if open && end > start {
+				rects = append(rects, decorationRect{
+					min: f32.Point{X: start, Y: spanTop},
+					max: f32.Point{X: end, Y: spanBottom},
+				})
+			}
+			open = false
+		}
+		for i, g := range gs {
+			if i == 0 {
+				x = g.X
+			}
+			if g.Flags&(FlagParagraphBreak|FlagVertical) != 0 || g.Advance == 0 {
+				continue
+			}
+			ppem, faceIdx, _ := splitGlyphID(g.ID)
+			if faceIdx >= len(s.faces) || s.faces[faceIdx] == nil {
+				continue
+			}
+			if !open || faceIdx != lastFace || ppem != lastPPEM {
+				offset, thickness := decorationMetrics(s.faces[faceIdx], ppem, kind)
+				top = -fixedToFloat(offset) - fixedToFloat(thickness)/2
+				bottom = top + fixedToFloat(thickness)
+			}
+			left := fixedToFloat(g.X - x)
+			right := left + fixedToFloat(g.Advance)
+			if open && (left != end || top != spanTop || bottom != spanBottom) {
+				flush()
+			}
+			if !open {
+				open = true
+				start = left
+				spanTop, spanBottom = top, bottom
+			}
+			end = right
+			lastFace, lastPPEM = faceIdx, ppem
+		}
+		flush()
+	}
+	return rects
+}
+
+// decorationMetrics returns the distance above the baseline of the center of the
+// decoration line described by kind and its thickness, scaled to ppem. Fonts
+// lacking the relevant metrics fall back to values derived from the em size.
+func decorationMetrics(face *font.Face, ppem fixed.Int26_6, kind Decoration) (offset, thickness fixed.Int26_6) {
+	upem := float32(face.Upem())
+	var pos, size float32
+	switch kind {
+	case Strikethrough:
+		pos = face.LineMetric(font.StrikethroughPosition)
+		size = face.LineMetric(font.StrikethroughThickness)
+		if size <= 0 {
+			size = upem / 14
+		}
+		if pos == 0 {
+			pos = upem / 4
+		}
+		// The strikeout position locates the top of the stroke.
+		pos -= size / 2
+	default:
+		pos = face.LineMetric(font.UnderlinePosition)
+		size = face.LineMetric(font.UnderlineThickness)
+		if size <= 0 {
+			size = upem / 14
+		}
+		if pos == 0 {
+			pos = -upem / 10
+		}
+	}
+	scale := fixedToFloat(ppem) / upem
+	offset = floatToFixed(pos * scale)
+	// Ensure the line never disappears entirely at small sizes.
+	thickness = max(floatToFixed(size*scale), fixed.I(1))
+	return offset, thickness
+}
+
 func fixedToFloat(i fixed.Int26_6) float32 {
 	return float32(i) / 64.0
 }
@@ -721,10 +1043,12 @@ func floatToFixed(f float32) fixed.Int26_6 {
 // and will align correctly.
 func (s *shaperImpl) Bitmaps(ops *op.Ops, gs []Glyph) op.CallOp {
 	var x fixed.Int26_6
+	var y int32
 	bitmapMacro := op.Record(ops)
 	for i, g := range gs {
 		if i == 0 {
 			x = g.X
+			y = g.Y
 		}
 		_, faceIdx, gid := splitGlyphID(g.ID)
 		if faceIdx >= len(s.faces) {
@@ -762,7 +1086,7 @@ func (s *shaperImpl) Bitmaps(ops *op.Ops, gs []Glyph) op.CallOp {
 			}
 			off := op.Affine(f32.AffineId().Offset(f32.Point{
 				X: fixedToFloat((g.X - x) - g.Offset.X),
-				Y: fixedToFloat(g.Offset.Y + g.Bounds.Min.Y),
+				Y: float32(g.Y-y) + fixedToFloat(g.Offset.Y+g.Bounds.Min.Y),
 			})).Push(ops)
 			cl := clip.Rect{Max: imgSize}.Push(ops)
 
@@ -820,11 +1144,16 @@ func mapDirection(d system.TextDirection) di.Direction {
 		return di.DirectionLTR
 	case system.RTL:
 		return di.DirectionRTL
+	case system.TTB:
+		return di.DirectionTTB
 	}
 	return di.DirectionLTR
 }
 
 func unmapDirection(d di.Direction) system.TextDirection {
+	if d.IsVertical() {
+		return system.TTB
+	}
 	switch d {
 	case di.DirectionLTR:
 		return system.LTR
@@ -887,6 +1216,7 @@ func toLine(faceToIndex map[*font.Font]int, o shaping.Line, dir system.TextDirec
 				Offset: line.runeCount,
 			},
 			Direction:      unmapDirection(run.Direction),
+			sideways:       run.Direction.IsSideways(),
 			face:           run.Face,
 			Advance:        run.Advance,
 			PPEM:           run.Size,
@@ -9,6 +9,7 @@ import (
 
 	nsareg "eliasnaur.com/font/noto/sans/arabic/regular"
 	"github.com/go-text/typesetting/font"
+	"github.com/go-text/typesetting/language"
 	"github.com/go-text/typesetting/shaping"
 	"golang.org/x/image/font/gofont/goregular"
 	"golang.org/x/image/math/fixed"
@@ -33,7 +34,7 @@ func testShaper(faces ...giofont.Face) *shaperImpl {
 	for _, face := range faces {
 		ff = append(ff, FontFace{Face: face})
 	}
-	shaper := newShaperImpl(false, ff)
+	shaper := newShaperImpl(false, "", ff)
 	return shaper
 }
 
@@ -593,3 +594,155 @@ func TestGlyphIDPacking(t *testing.T) {
 		})
 	}
 }
+
+// TestSpacing checks that letter and word spacing widen shaped lines by the
+// expected amounts.
+func TestSpacing(t *testing.T) {
+	ltrFace, _ := opentype.Parse(goregular.TTF)
+	shaper := testShaper(ltrFace)
+	params := Parameters{
+		PxPerEm:  fixed.I(16),
+		MaxWidth: 10000,
+		Locale:   english,
+	}
+	const txt = "ab cd"
+	width := func(p Parameters) fixed.Int26_6 {
+		doc := shaper.LayoutRunes(p, []rune(txt))
+		if len(doc.lines) != 1 {
+			t.Fatalf("expected 1 line, got %d", len(doc.lines))
+		}
+		return doc.lines[0].width
+	}
+	base := width(params)
+
+	letter := params
+	letter.LetterSpacing = fixed.I(2)
+	if got, want := width(letter)-base, fixed.I(2*len(txt)); got != want {
+		t.Errorf("letter spacing added %v, expected %v", got, want)
+	}
+
+	word := params
+	word.WordSpacing = fixed.I(3)
+	if got, want := width(word)-base, fixed.I(3); got != want {
+		t.Errorf("word spacing added %v, expected %v", got, want)
+	}
+}
+
+func TestDecorationMetrics(t *testing.T) {
+	ltrFace, _ := opentype.Parse(goregular.TTF)
+	face := ltrFace.Face()
+	ppem := fixed.I(16)
+	underline, uThick := decorationMetrics(face, ppem, Underline)
+	strike, sThick := decorationMetrics(face, ppem, Strikethrough)
+	if underline >= 0 {
+		t.Errorf("expected underline below the baseline, got offset %v", underline)
+	}
+	if strike <= 0 || strike >= ppem {
+		t.Errorf("expected strikethrough within the em box, got offset %v", strike)
+	}
+	if uThick <= 0 || sThick <= 0 {
+		t.Errorf("expected positive thicknesses, got %v and %v", uThick, sThick)
+	}
+}
+
+func TestVerticalDecorations(t *testing.T) {
+	ltrFace, _ := opentype.Parse(goregular.TTF)
+	shaper := NewShaper(NoSystemFonts(), WithCollection([]FontFace{{Face: ltrFace}}))
+	decorated := func(locale system.Locale) int {
+		shaper.LayoutString(Parameters{
+			PxPerEm:  fixed.I(16),
+			MaxWidth: 1000,
+			Locale:   locale,
+		}, "decorated")
+		var gs []Glyph
+		for g, ok := shaper.NextGlyph(); ok; g, ok = shaper.NextGlyph() {
+			gs = append(gs, g)
+		}
+		return len(shaper.shaper.decorationRects(gs, Underline|Strikethrough))
+	}
+	if got := decorated(english); got != 2 {
+		t.Errorf("expected an underline and a strikethrough, got %d lines", got)
+	}
+	// Vertical text isn't decorated.
+	if got := decorated(system.Locale{Language: "ja", Direction: system.TTB}); got != 0 {
+		t.Errorf("expected no decorations of vertical text, got %d lines", got)
+	}
+}
+
+func TestJustify(t *testing.T) {
+	ltrFace, _ := opentype.Parse(goregular.TTF)
+	shaper := testShaper(ltrFace)
+	params := Parameters{
+		PxPerEm:   fixed.I(16),
+		MaxWidth:  150,
+		Locale:    english,
+		Alignment: Justify,
+	}
+	doc := shaper.LayoutRunes(params, []rune("the quick brown fox jumps over the lazy dog"))
+	if len(doc.lines) < 2 {
+		t.Fatalf("expected multiple lines, got %d", len(doc.lines))
+	}
+	for i, l := range doc.lines[:len(doc.lines)-1] {
+		if l.width != fixed.I(params.MaxWidth) {
+			t.Errorf("line %d: expected width %v, got %v", i, fixed.I(params.MaxWidth), l.width)
+		}
+	}
+	if last := doc.lines[len(doc.lines)-1]; last.width >= fixed.I(params.MaxWidth) {
+		t.Errorf("expected final line to remain unjustified, got width %v", last.width)
+	}
+}
+
+func TestHyphenation(t *testing.T) {
+	ltrFace, _ := opentype.Parse(goregular.TTF)
+	shaper := testShaper(ltrFace)
+	shaper.hyphenators = map[language.Language]Hyphenator{
+		language.NewLanguage("en"): NewPatterns(liangExample, nil),
+	}
+	const txt = "hyphenation hyphenation"
+	params := Parameters{
+		PxPerEm:    fixed.I(16),
+		MaxWidth:   70,
+		Locale:     english,
+		WrapPolicy: WrapHyphenated,
+	}
+	doc := shaper.LayoutRunes(params, []rune(txt))
+	if len(doc.lines) < 2 {
+		t.Fatalf("expected multiple lines, got %d", len(doc.lines))
+	}
+	hyphen, _ := ltrFace.Face().NominalGlyph('-')
+	runes := 0
+	for i, l := range doc.lines {
+		runes += l.runeCount
+		if l.width > fixed.I(params.MaxWidth) {
+			t.Errorf("line %d: width %v exceeds max width", i, l.width)
+		}
+	}
+	if runes != len([]rune(txt)) {
+		t.Errorf("expected lines to contain %d runes, got %d", len([]rune(txt)), runes)
+	}
+	var hyphens int
+	for _, l := range doc.lines {
+		for _, run := range l.runs {
+			for _, g := range run.Glyphs {
+				if _, _, gid := splitGlyphID(g.id); gid == hyphen {
+					hyphens++
+				}
+			}
+		}
+	}
+	if hyphens == 0 {
+		t.Errorf("expected a visible hyphen at a line break")
+	}
+
+	params.WrapPolicy = WrapHeuristically
+	doc = shaper.LayoutRunes(params, []rune(txt))
+	for _, l := range doc.lines {
+		for _, run := range l.runs {
+			for _, g := range run.Glyphs {
+				if _, _, gid := splitGlyphID(g.id); gid == hyphen {
+					t.Errorf("unexpected hyphen without hyphenation enabled")
+				}
+			}
+		}
+	}
+}
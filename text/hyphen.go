@@ -0,0 +1,438 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package text
+
+import (
+	"bufio"
+	"io"
+	"slices"
+	"strings"
+	"unicode"
+
+	"gioui.org/io/system"
+	"github.com/go-text/typesetting/di"
+	"github.com/go-text/typesetting/language"
+	"github.com/go-text/typesetting/shaping"
+	"golang.org/x/image/math/fixed"
+)
+
+// Hyphenator locates the positions within words at which they may be broken
+// across lines. Hyphenators are registered per language with [WithHyphenator]
+// and are only consulted for text shaped with the [WrapHyphenated] policy.
+type Hyphenator interface {
+	// Hyphenate returns the indices of the runes in word before which a hyphen
+	// may be inserted, in increasing order. word contains only letters.
+	Hyphenate(word []rune) []int
+}
+
+// WithHyphenator registers h as the hyphenator for text in the given BCP-47
+// language. Text whose language has no exact match falls back to the
+// hyphenator registered for its primary language subtag, if any.
+func WithHyphenator(lang string, h Hyphenator) ShaperOption {
+	return func(s *Shaper) {
+		if s.config.hyphenators == nil {
+			s.config.hyphenators = make(map[language.Language]Hyphenator)
+		}
+		s.config.hyphenators[language.NewLanguage(lang)] = h
+	}
+}
+
+// Patterns is a Hyphenator implementing Liang's algorithm, as used by TeX. Pattern
+// files for many languages are available from the TeX hyphenation project.
+type Patterns struct {
+	// LeftMin is the minimum number of runes kept before the first hyphen of a word.
+	// If zero, a default of 2 is used.
+	LeftMin int
+	// RightMin is the minimum number of runes kept after the last hyphen of a word.
+	// If zero, a default of 3 is used.
+	RightMin int
+
+	patterns   map[string][]uint8
+	maxLen     int
+	exceptions map[string][]int
+}
+
+// NewPatterns builds a Patterns hyphenator from Liang patterns such as "hy3ph" and
+// exceptions such as "ta-ble", which list the complete hyphenation of a word.
+func NewPatterns(patterns, exceptions []string) *Patterns {
+	p := &Patterns{
+		patterns:   make(map[string][]uint8, len(patterns)),
+		exceptions: make(map[string][]int, len(exceptions)),
+	}
+	for _, pat := range patterns {
+		p.addPattern(pat)
+	}
+	for _, ex := range exceptions {
+		p.addException(ex)
+	}
+	return p
+}
+
+// ParsePatterns reads whitespace-separated Liang patterns from r. Entries containing
+// a '-' are treated as exceptions, and text following a '%' on a line is ignored.
+func ParsePatterns(r io.Reader) (*Patterns, error) {
+	var patterns, exceptions []string
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.IndexByte(line, '%'); i != -1 {
+			line = line[:i]
+		}
+		for _, f := range strings.Fields(line) {
+			if strings.ContainsRune(f, '-') {
+				exceptions = append(exceptions, f)
+			} else {
+				patterns = append(patterns, f)
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return NewPatterns(patterns, exceptions), nil
+}
+
+func (p *Patterns) addPattern(pat string) {
+	var letters []rune
+	values := []uint8{0}
+	for _, r := range pat {
+		if r >= '0' && r <= '9' {
+			values[len(values)-1] = uint8(r - '0')
+			continue
+		}
+		letters = append(letters, unicode.ToLower(r))
+		values = append(values, 0)
+	}
+	if len(letters) == 0 {
+		return
+	}
+	p.patterns[string(letters)] = values
+	p.maxLen = max(p.maxLen, len(letters))
+}
+
+func (p *Patterns) addException(ex string) {
+	var word []rune
+	var points []int
+	for _, r := range ex {
+		if r == '-' {
+			points = append(points, len(word))
+			continue
+		}
+		word = append(word, unicode.ToLower(r))
+	}
+	p.exceptions[string(word)] = points
+}
+
+// Hyphenate implements [Hyphenator].
+func (p *Patterns) Hyphenate(word []rune) []int {
+	leftMin, rightMin := p.LeftMin, p.RightMin
+	if leftMin == 0 {
+		leftMin = 2
+	}
+	if rightMin == 0 {
+		rightMin = 3
+	}
+	if len(word) < leftMin+rightMin {
+		return nil
+	}
+	lower := make([]rune, 0, len(word)+2)
+	lower = append(lower, '.')
+	for _, r := range word {
+		lower = append(lower, unicode.ToLower(r))
+	}
+	lower = append(lower, '.')
+	if points, ok := p.exceptions[string(lower[1:len(lower)-1])]; ok {
+		return slices.Clone(points)
+	}
+	// values[i] is the priority of a break before lower[i].
+	values := make([]uint8, len(lower)+1)
+	for i := range lower {
+		for l := 1; l <= p.maxLen && i+l <= len(lower); l++ {
+			pat, ok := p.patterns[string(lower[i:i+l])]
+			if !ok {
+				continue
+			}
+			for k, v := range pat {
+				values[i+k] = max(values[i+k], v)
+			}
+		}
+	}
+	var points []int
+	for j := leftMin; j <= len(word)-rightMin; j++ {
+		// Rune j of the word is lower[j+1].
+		if values[j+1]%2 == 1 {
+			points = append(points, j)
+		}
+	}
+	return points
+}
+
+// softHyphen is inserted into text at hyphenation opportunities, providing
+// a line breaking opportunity to the line wrapper.
+const softHyphen = '\u00AD'
+
+// hyphenator returns the hyphenator to use for the given language, if any.
+func (s *shaperImpl) hyphenator(lang string) Hyphenator {
+	if len(s.hyphenators) == 0 {
+		return nil
+	}
+	l := language.NewLanguage(lang)
+	if h, ok := s.hyphenators[l]; ok {
+		return h
+	}
+	return s.hyphenators[l.Primary()]
+}
+
+// hyphenate inserts soft hyphens into txt wherever the hyphenator for the language
+// of params permits breaking a word. It returns the resulting text along with the
+// sorted indices of the inserted soft hyphens. If hyphenation is disabled, txt is
+// returned unchanged.
+func (s *shaperImpl) hyphenate(params Parameters, txt []rune) ([]rune, []int) {
+	if params.WrapPolicy != WrapHyphenated || params.Locale.Direction.Axis() == system.Vertical {
+		return txt, nil
+	}
+	h := s.hyphenator(params.Locale.Language)
+	if h == nil {
+		return txt, nil
+	}
+	var out []rune
+	var hyphens []int
+	for i := 0; i < len(txt); {
+		if !unicode.IsLetter(txt[i]) {
+			out = append(out, txt[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(txt) && unicode.IsLetter(txt[j]) {
+			j++
+		}
+		word := txt[i:j]
+		prev := 0
+		for _, p := range h.Hyphenate(word) {
+			if p <= prev || p >= len(word) {
+				continue
+			}
+			out = append(out, word[prev:p]...)
+			hyphens = append(hyphens, len(out))
+			out = append(out, softHyphen)
+			prev = p
+		}
+		out = append(out, word[prev:]...)
+		i = j
+	}
+	if len(hyphens) == 0 {
+		return txt, nil
+	}
+	return out, hyphens
+}
+
+// hyphenGlyph returns the glyph used to display a hyphen in the face of run,
+// along with its advance.
+func hyphenGlyph(run *shaping.Output) (shaping.Glyph, bool) {
+	if run.Face == nil {
+		return shaping.Glyph{}, false
+	}
+	gid, ok := run.Face.NominalGlyph('-')
+	if !ok {
+		return shaping.Glyph{}, false
+	}
+	scale := fixedToFloat(run.Size) / float32(run.Face.Upem())
+	g := shaping.Glyph{
+		GlyphID:  gid,
+		XAdvance: floatToFixed(run.Face.HorizontalAdvance(gid) * scale),
+	}
+	if ext, ok := run.Face.GlyphExtents(gid); ok {
+		g.XBearing = floatToFixed(ext.XBearing * scale)
+		g.YBearing = floatToFixed(ext.YBearing * scale)
+		g.Width = floatToFixed(ext.Width * scale)
+		g.Height = floatToFixed(ext.Height * scale)
+	}
+	return g, true
+}
+
+// reserveHyphens gives every soft hyphen glyph in outs the advance of a visible
+// hyphen, ensuring that lines broken at a hyphenation point never exceed the
+// maximum width once the hyphen is displayed.
+func reserveHyphens(outs []shaping.Output, hyphens []int) {
+	if len(hyphens) == 0 {
+		return
+	}
+	for i := range outs {
+		run := &outs[i]
+		hyphen, ok := hyphenGlyph(run)
+		if !ok {
+			continue
+		}
+		for j := range run.Glyphs {
+			if _, found := slices.BinarySearch(hyphens, run.Glyphs[j].ClusterIndex); found {
+				run.Glyphs[j].XAdvance = hyphen.XAdvance
+			}
+		}
+		run.RecomputeAdvance()
+	}
+}
+
+// resolveHyphens turns the soft hyphens inserted by hyphenate into visible hyphens
+// at the end of lines and invisible zero-width glyphs elsewhere. It merges each soft
+// hyphen into the cluster preceding it and maps all cluster indices back to the text
+// prior to hyphenation, so that the result accounts for the original runes only.
+// hasTruncator indicates that the final run of the final line is a truncator.
+// It returns the adjusted count of truncated runes.
+func resolveHyphens(lines []shaping.Line, hyphens []int, textLen, truncated int, hasTruncator bool) int {
+	if len(hyphens) == 0 {
+		return truncated
+	}
+	if truncated > 0 {
+		first, _ := slices.BinarySearch(hyphens, textLen-truncated)
+		truncated -= len(hyphens) - first
+	}
+	isTruncator := func(line, run int) bool {
+		return hasTruncator && line == len(lines)-1 && run == len(lines[line])-1
+	}
+	for l, line := range lines {
+		lineEnd := -1
+		for r, run := range line {
+			if isTruncator(l, r) {
+				continue
+			}
+			for _, g := range run.Glyphs {
+				lineEnd = max(lineEnd, g.ClusterIndex)
+			}
+		}
+		type softHyphenGlyph struct {
+			idx   int
+			atEnd bool
+		}
+		for r := range line {
+			if isTruncator(l, r) {
+				continue
+			}
+			run := &line[r]
+			var shys []softHyphenGlyph
+			for j := range run.Glyphs {
+				g := &run.Glyphs[j]
+				idx, found := slices.BinarySearch(hyphens, g.ClusterIndex)
+				if found {
+					shys = append(shys, softHyphenGlyph{idx: j, atEnd: g.ClusterIndex == lineEnd})
+					continue
+				}
+				g.ClusterIndex -= idx
+			}
+			if len(shys) == 0 {
+				continue
+			}
+			hyphen, hasHyphen := hyphenGlyph(run)
+			rtl := run.Direction.Progression() == di.TowardTopLeft
+			for _, shy := range shys {
+				g := &run.Glyphs[shy.idx]
+				// The logically preceding glyph is visually before in LTR runs and
+				// visually after in RTL runs.
+				pred := shy.idx - 1
+				if rtl {
+					pred = shy.idx + 1
+				}
+				old := g.XAdvance
+				if shy.atEnd && hasHyphen {
+					g.GlyphID = hyphen.GlyphID
+					g.XAdvance = hyphen.XAdvance
+					g.XBearing, g.YBearing = hyphen.XBearing, hyphen.YBearing
+					g.Width, g.Height = hyphen.Width, hyphen.Height
+				} else {
+					g.XAdvance = 0
+					g.Width = 0
+					g.Height = 0
+				}
+				run.Advance += g.XAdvance - old
+				run.Runes.Count--
+				if pred >= 0 && pred < len(run.Glyphs) {
+					p := run.Glyphs[pred]
+					g.ClusterIndex = p.ClusterIndex
+					g.RuneCount = p.RuneCount
+					g.GlyphCount = p.GlyphCount + 1
+				} else {
+					// There is no preceding glyph within the run to merge with, so make
+					// the soft hyphen a cluster that represents no text.
+					idx, _ := slices.BinarySearch(hyphens, g.ClusterIndex)
+					g.ClusterIndex -= idx
+					g.RuneCount = 0
+				}
+			}
+		}
+	}
+	return truncated
+}
+
+// justify widens the non-final lines of a paragraph to fill maxWidth. Extra space is
+// distributed across the word separators within each line, or across all of its
+// glyph clusters if it has no word separators (as is common in CJK text). Trailing
+// whitespace does not receive extra space. txt must be the text the lines were
+// shaped from.
+func justify(lines []shaping.Line, txt []rune, maxWidth int) {
+	if len(lines) < 2 {
+		return
+	}
+	type cluster struct {
+		index int
+		run   *shaping.Output
+		glyph int
+	}
+	var clusters []cluster
+	for _, line := range lines[:len(lines)-1] {
+		var width fixed.Int26_6
+		clusters = clusters[:0]
+		for r := range line {
+			run := &line[r]
+			width += run.Advance
+			if run.Direction.IsVertical() {
+				continue
+			}
+			for j, g := range run.Glyphs {
+				if g.GlyphCount == 0 {
+					continue
+				}
+				if j+1 < len(run.Glyphs) && run.Glyphs[j+1].ClusterIndex == g.ClusterIndex {
+					continue
+				}
+				clusters = append(clusters, cluster{index: g.ClusterIndex, run: run, glyph: j})
+			}
+		}
+		extra := fixed.I(maxWidth) - width
+		if extra <= 0 {
+			continue
+		}
+		slices.SortFunc(clusters, func(a, b cluster) int { return a.index - b.index })
+		// Ignore trailing whitespace and the final visible cluster, after which
+		// no space should be inserted.
+		for len(clusters) > 0 {
+			idx := clusters[len(clusters)-1].index
+			if idx >= len(txt) || !unicode.IsSpace(txt[idx]) {
+				break
+			}
+			clusters = clusters[:len(clusters)-1]
+		}
+		if len(clusters) < 2 {
+			continue
+		}
+		clusters = clusters[:len(clusters)-1]
+		targets := clusters[:0:0]
+		for _, c := range clusters {
+			if c.index < len(txt) && isWordSeparator(txt[c.index]) {
+				targets = append(targets, c)
+			}
+		}
+		if len(targets) == 0 {
+			targets = clusters
+		}
+		per := extra / fixed.Int26_6(len(targets))
+		rem := int(extra % fixed.Int26_6(len(targets)))
+		for i, c := range targets {
+			add := per
+			if i < rem {
+				add++
+			}
+			c.run.Glyphs[c.glyph].XAdvance += add
+			c.run.Advance += add
+		}
+	}
+}
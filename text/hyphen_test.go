@@ -0,0 +1,43 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package text
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// liangExample contains the patterns used to hyphenate "hyphenation" in Liang's thesis.
+var liangExample = []string{"hy3ph", "he2n", "hena4", "hen5at", "1na", "n2at", "1tio", "2io", "o2n"}
+
+func TestPatternsHyphenate(t *testing.T) {
+	p := NewPatterns(liangExample, []string{"ta-ble"})
+	for _, tc := range []struct {
+		word string
+		want []int
+	}{
+		{word: "hyphenation", want: []int{2, 6}},
+		{word: "Hyphenation", want: []int{2, 6}},
+		{word: "table", want: []int{2}},
+		{word: "hy", want: nil},
+	} {
+		if got := p.Hyphenate([]rune(tc.word)); !slices.Equal(got, tc.want) {
+			t.Errorf("Hyphenate(%q) = %v, expected %v", tc.word, got, tc.want)
+		}
+	}
+}
+
+func TestParsePatterns(t *testing.T) {
+	src := "% Liang's example\n" + strings.Join(liangExample, " ") + "\nta-ble % exception\n"
+	p, err := ParsePatterns(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := p.Hyphenate([]rune("hyphenation")), []int{2, 6}; !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if got, want := p.Hyphenate([]rune("table")), []int{2}; !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
@@ -25,10 +25,20 @@ type entry[K comparable, V any] struct {
 type lru[K comparable, V any] struct {
 	m          map[K]*entry[K, V]
 	head, tail *entry[K, V]
+	// size is the maximum number of entries, or zero for maxSize.
+	size  int
+	stats CacheStats
 }
 
 // Get fetches the value associated with the given key, if any.
 func (l *lru[K, V]) Get(k K) (V, bool) {
+	v, ok := l.lookup(k)
+	l.record(ok)
+	return v, ok
+}
+
+// lookup is like Get, but doesn't update the cache statistics.
+func (l *lru[K, V]) lookup(k K) (V, bool) {
 	if lt, ok := l.m[k]; ok {
 		l.remove(lt)
 		l.insert(lt)
@@ -38,6 +48,36 @@ func (l *lru[K, V]) Get(k K) (V, bool) {
 	return v, false
 }
 
+// record counts a cache hit or miss.
+func (l *lru[K, V]) record(hit bool) {
+	if hit {
+		l.stats.Hits++
+	} else {
+		l.stats.Misses++
+	}
+}
+
+// capacity returns the maximum number of entries of the cache.
+func (l *lru[K, V]) capacity() int {
+	if l.size > 0 {
+		return l.size
+	}
+	return maxSize
+}
+
+// Stats returns the statistics of the cache.
+func (l *lru[K, V]) Stats() CacheStats {
+	s := l.stats
+	s.Len = len(l.m)
+	s.Capacity = l.capacity()
+	return s
+}
+
+// Clear removes every entry from the cache, preserving its size and statistics.
+func (l *lru[K, V]) Clear() {
+	l.m, l.head, l.tail = nil, nil, nil
+}
+
 // Put inserts the given value with the given key, evicting old
 // cache entries if necessary.
 func (l *lru[K, V]) Put(k K, v V) {
@@ -48,13 +88,17 @@ func (l *lru[K, V]) Put(k K, v V) {
 		l.head.prev = l.tail
 		l.tail.next = l.head
 	}
+	if old, ok := l.m[k]; ok {
+		l.remove(old)
+	}
 	val := &entry[K, V]{key: k, v: v}
 	l.m[k] = val
 	l.insert(val)
-	if len(l.m) > maxSize {
+	for len(l.m) > l.capacity() {
 		oldest := l.tail.next
 		l.remove(oldest)
 		delete(l.m, oldest.key)
+		l.stats.Evictions++
 	}
 }
 
@@ -104,34 +148,45 @@ func (c *glyphLRU[V]) hashGlyphs(gs []Glyph) uint64 {
 	}
 
 	h := c.seed
-	firstX := gs[0].X
+	firstX, firstY := gs[0].X, gs[0].Y
 	for _, g := range gs {
 		h += uint64(g.X - firstX)
 		h *= 6585573582091643
 		h += uint64(g.ID)
 		h *= 3650802748644053
+		if g.Flags&FlagVertical != 0 {
+			h += uint64(g.Y-firstY)<<32 | uint64(uint32(g.Offset.Y))
+			h *= 6585573582091643
+			h += uint64(g.Flags & FlagSideways)
+			h *= 3650802748644053
+		}
 	}
 
 	return h
 }
 
 func (c *glyphLRU[V]) Get(key uint64, gs []Glyph) (V, bool) {
-	if v, ok := c.cache.Get(key); ok && gidsEqual(v.glyphs, gs) {
+	v, ok := c.cache.lookup(key)
+	// Hash collisions count as misses.
+	ok = ok && gidsEqual(v.glyphs, gs)
+	c.cache.record(ok)
+	if ok {
 		return v.v, true
 	}
-	var v V
-	return v, false
+	var zero V
+	return zero, false
 }
 
 func (c *glyphLRU[V]) Put(key uint64, glyphs []Glyph, v V) {
 	gids := make([]glyphInfo, len(glyphs))
 	firstX := fixed.I(0)
+	var firstY int32
 	for i, glyph := range glyphs {
 		if i == 0 {
-			firstX = glyph.X
+			firstX, firstY = glyph.X, glyph.Y
 		}
-		// Cache glyph X offsets relative to the first glyph.
-		gids[i] = glyphInfo{ID: glyph.ID, X: glyph.X - firstX}
+		// Cache glyph offsets relative to the first glyph.
+		gids[i] = newGlyphInfo(glyph, firstX, firstY)
 	}
 	val := glyphValue[V]{
 		glyphs: gids,
@@ -147,6 +202,21 @@ type bitmapShapeCache = glyphLRU[op.CallOp]
 type glyphInfo struct {
 	ID GlyphID
 	X  fixed.Int26_6
+	// Y, OffsetY and Flags are only set for vertical glyphs, whose
+	// positions vary along the y axis.
+	Y       int32
+	OffsetY fixed.Int26_6
+	Flags   Flags
+}
+
+func newGlyphInfo(g Glyph, firstX fixed.Int26_6, firstY int32) glyphInfo {
+	info := glyphInfo{ID: g.ID, X: g.X - firstX}
+	if g.Flags&FlagVertical != 0 {
+		info.Y = g.Y - firstY
+		info.OffsetY = g.Offset.Y
+		info.Flags = g.Flags & (FlagVertical | FlagSideways)
+	}
+	return info
 }
 
 type layoutKey struct {
@@ -161,6 +231,9 @@ type layoutKey struct {
 	wrapPolicy         WrapPolicy
 	lineHeight         fixed.Int26_6
 	lineHeightScale    float32
+	letterSpacing      fixed.Int26_6
+	wordSpacing        fixed.Int26_6
+	justify            bool
 }
 
 const maxSize = 1000
@@ -170,12 +243,13 @@ func gidsEqual(a []glyphInfo, glyphs []Glyph) bool {
 		return false
 	}
 	firstX := fixed.Int26_6(0)
+	var firstY int32
 	for i := range a {
 		if i == 0 {
-			firstX = glyphs[i].X
+			firstX, firstY = glyphs[i].X, glyphs[i].Y
 		}
-		// Cache glyph X offsets relative to the first glyph.
-		if a[i].ID != glyphs[i].ID || a[i].X != (glyphs[i].X-firstX) {
+		// Cache glyph offsets relative to the first glyph.
+		if a[i] != newGlyphInfo(glyphs[i], firstX, firstY) {
 			return false
 		}
 	}
@@ -6,7 +6,10 @@ import (
 	"strconv"
 	"testing"
 
+	"gioui.org/font/opentype"
 	"gioui.org/op/clip"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/math/fixed"
 )
 
 func TestLayoutLRU(t *testing.T) {
@@ -53,3 +56,38 @@ func testLRU(t *testing.T, put func(i int), get func(i int) bool) {
 		t.Fatalf("key %d was not evicted", i)
 	}
 }
+
+func TestLRUStats(t *testing.T) {
+	c := &layoutCache{size: 2}
+	for i := range 3 {
+		c.Put(layoutKey{str: strconv.Itoa(i)}, document{})
+	}
+	c.Get(layoutKey{str: "0"})
+	c.Get(layoutKey{str: "2"})
+	want := CacheStats{Len: 2, Capacity: 2, Hits: 1, Misses: 1, Evictions: 1}
+	if got := c.Stats(); got != want {
+		t.Errorf("got stats %+v, want %+v", got, want)
+	}
+	c.Clear()
+	want.Len = 0
+	if got := c.Stats(); got != want {
+		t.Errorf("got stats %+v after clearing, want %+v", got, want)
+	}
+}
+
+func TestShaperCacheSizes(t *testing.T) {
+	ltrFace, _ := opentype.Parse(goregular.TTF)
+	shaper := NewShaper(NoSystemFonts(), WithCollection([]FontFace{{Face: ltrFace}}), WithCacheSizes(CacheSizes{Layout: 2}))
+	params := Parameters{PxPerEm: fixed.I(16), MaxWidth: 1000}
+	for _, s := range []string{"a", "b", "c", "a"} {
+		shaper.LayoutString(params, s)
+	}
+	stats := shaper.Stats()
+	want := CacheStats{Len: 2, Capacity: 2, Misses: 4, Evictions: 2}
+	if stats.Layout != want {
+		t.Errorf("got layout stats %+v, want %+v", stats.Layout, want)
+	}
+	if got := stats.Path.Capacity; got != 2*maxSize {
+		t.Errorf("got default path cache capacity %d, want %d", got, 2*maxSize)
+	}
+}
@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package text
+
+import (
+	"io"
+
+	"gioui.org/io/system"
+	"golang.org/x/image/math/fixed"
+)
+
+// LineMetrics describes a single line of laid out text. Coordinates match those
+// of the Glyphs returned by NextGlyph for the same text. For vertical text, X and
+// Width are measured down the column, Baseline is the x coordinate of the center
+// line of the column, and Ascent and Descent are the extents of the column to the
+// right and to the left of its center line.
+type LineMetrics struct {
+	// X is the horizontal offset of the start of the line, determined by the
+	// text alignment.
+	X fixed.Int26_6
+	// Baseline is the vertical position of the baseline of the line.
+	Baseline int32
+	// Width is the logical width of the line.
+	Width fixed.Int26_6
+	// Ascent is the height of the line above its baseline.
+	Ascent fixed.Int26_6
+	// Descent is the depth of the line below its baseline, including the line gap.
+	Descent fixed.Int26_6
+	// Runes is the number of runes of text displayed by the line, excluding
+	// any runes replaced by a truncator.
+	Runes int
+}
+
+// Measurement describes the dimensions of laid out text.
+type Measurement struct {
+	// Lines holds the metrics of each line of text.
+	Lines []LineMetrics
+	// Bounds is the logical bounding box of the text.
+	Bounds fixed.Rectangle26_6
+	// Truncated is the number of runes of text replaced by a truncator symbol.
+	Truncated int
+}
+
+// Ascent returns the height of the first line above its baseline.
+func (m Measurement) Ascent() fixed.Int26_6 {
+	if len(m.Lines) == 0 {
+		return 0
+	}
+	return m.Lines[0].Ascent
+}
+
+// Descent returns the depth of the final line below its baseline.
+func (m Measurement) Descent() fixed.Int26_6 {
+	if len(m.Lines) == 0 {
+		return 0
+	}
+	return m.Lines[len(m.Lines)-1].Descent
+}
+
+// Measure lays out text from an io.Reader and returns its dimensions without
+// disturbing the results of the most recent Layout call. Measurements share the
+// shaping cache used by Layout, making repeated measurements of the same text
+// cheap.
+func (l *Shaper) Measure(params Parameters, txt io.Reader) Measurement {
+	l.init()
+	return l.measure(params, txt, "")
+}
+
+// MeasureString is Measure for strings.
+func (l *Shaper) MeasureString(params Parameters, str string) Measurement {
+	l.init()
+	return l.measure(params, nil, str)
+}
+
+func (l *Shaper) measure(params Parameters, txt io.Reader, str string) Measurement {
+	doc := &l.measureDoc
+	doc.reset()
+	doc.alignment = params.Alignment
+	l.layoutDocument(doc, params, txt, str)
+	m := Measurement{
+		Lines:     make([]LineMetrics, len(doc.lines)),
+		Truncated: doc.unreadRuneCount,
+	}
+	for i, line := range doc.lines {
+		lm := LineMetrics{
+			X:        doc.alignment.Align(line.direction, line.width, doc.alignWidth),
+			Baseline: int32(line.yOffset),
+			Width:    line.width,
+			Ascent:   line.ascent,
+			Descent:  line.descent,
+		}
+		for _, run := range line.runs {
+			if run.truncator {
+				m.Truncated += run.Runes.Count
+			} else {
+				lm.Runes += run.Runes.Count
+			}
+		}
+		m.Lines[i] = lm
+		b := fixed.Rectangle26_6{
+			Min: fixed.Point26_6{X: lm.X, Y: fixed.I(line.yOffset) - lm.Ascent},
+			Max: fixed.Point26_6{X: lm.X + lm.Width, Y: fixed.I(line.yOffset) + lm.Descent},
+		}
+		if line.direction.Axis() == system.Vertical {
+			b = fixed.Rectangle26_6{
+				Min: fixed.Point26_6{X: fixed.I(line.yOffset) - lm.Descent, Y: lm.X},
+				Max: fixed.Point26_6{X: fixed.I(line.yOffset) + lm.Ascent, Y: lm.X + lm.Width},
+			}
+		}
+		if i == 0 {
+			m.Bounds = b
+		} else {
+			m.Bounds = m.Bounds.Union(b)
+		}
+	}
+	// Release references to the shaped text held by the scratch document.
+	doc.reset()
+	return m
+}
@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package text
+
+import (
+	"testing"
+
+	"gioui.org/font/opentype"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/math/fixed"
+)
+
+// TestMeasure checks that measurements agree with the glyphs produced by Layout,
+// and that measuring does not disturb glyph iteration.
+func TestMeasure(t *testing.T) {
+	const txt = "Lorem ipsum dolor sit amet, consectetur adipiscing elit,\nsed do eiusmod tempor incididunt ut labore et\ndolore magna aliqua."
+	ltrFace, _ := opentype.Parse(goregular.TTF)
+	shaper := NewShaper(NoSystemFonts(), WithCollection([]FontFace{{Face: ltrFace}}))
+	for _, maxLines := range []int{0, 2} {
+		params := Parameters{
+			Alignment: Middle,
+			PxPerEm:   fixed.I(10),
+			MaxWidth:  200,
+			MaxLines:  maxLines,
+			Locale:    english,
+		}
+		shaper.LayoutString(params, txt)
+		// Measure between layout and iteration, which must not affect the glyphs.
+		m := shaper.MeasureString(params, txt)
+		var (
+			widths    []fixed.Int26_6
+			width     fixed.Int26_6
+			baselines []int32
+			runes     int
+			truncated int
+		)
+		for g, ok := shaper.NextGlyph(); ok; g, ok = shaper.NextGlyph() {
+			width += g.Advance
+			if g.Flags&FlagTruncator != 0 && g.Flags&FlagClusterBreak != 0 {
+				truncated += int(g.Runes)
+			} else {
+				runes += int(g.Runes)
+			}
+			if g.Flags&FlagLineBreak != 0 {
+				widths = append(widths, width)
+				baselines = append(baselines, g.Y)
+				width = 0
+			}
+		}
+		if len(m.Lines) != len(widths) {
+			t.Fatalf("maxLines=%d: measured %d lines, iterated %d", maxLines, len(m.Lines), len(widths))
+		}
+		measuredRunes := 0
+		for i, l := range m.Lines {
+			measuredRunes += l.Runes
+			if l.Width != widths[i] {
+				t.Errorf("maxLines=%d: line %d measured width %v, iterated %v", maxLines, i, l.Width, widths[i])
+			}
+			if l.Baseline != baselines[i] {
+				t.Errorf("maxLines=%d: line %d measured baseline %d, iterated %d", maxLines, i, l.Baseline, baselines[i])
+			}
+			if l.X < m.Bounds.Min.X || l.X+l.Width > m.Bounds.Max.X {
+				t.Errorf("maxLines=%d: line %d lies outside of bounds %v", maxLines, i, m.Bounds)
+			}
+		}
+		if measuredRunes != runes || m.Truncated != truncated {
+			t.Errorf("maxLines=%d: measured %d runes and %d truncated, iterated %d and %d", maxLines, measuredRunes, m.Truncated, runes, truncated)
+		}
+		if got, want := m.Bounds.Min.Y, fixed.I(int(m.Lines[0].Baseline))-m.Ascent(); got != want {
+			t.Errorf("maxLines=%d: bounds top %v, expected %v", maxLines, got, want)
+		}
+	}
+}
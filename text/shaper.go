@@ -13,6 +13,7 @@ import (
 	"gioui.org/op"
 	"gioui.org/op/clip"
 	"github.com/go-text/typesetting/font"
+	"github.com/go-text/typesetting/language"
 	"golang.org/x/image/math/fixed"
 )
 
@@ -35,6 +36,11 @@ const (
 	// breaking any word across lines on UAX#29 grapheme cluster boundaries to maximize the number of
 	// grapheme clusters on each line.
 	WrapGraphemes
+	// WrapHyphenated behaves like WrapHeuristically, but additionally permits words to be
+	// broken at the hyphenation points reported by the [Hyphenator] registered for the
+	// language of the text, displaying a hyphen at the end of the broken line. If
+	// no hyphenator is registered for the language, it is equivalent to WrapHeuristically.
+	WrapHyphenated
 )
 
 // Parameters are static text shaping attributes applied to the entire shaped text.
@@ -57,9 +63,12 @@ type Parameters struct {
 	WrapPolicy WrapPolicy
 
 	// MinWidth and MaxWidth provide the minimum and maximum horizontal space constraints
-	// for the shaped text.
+	// for the shaped text. For vertical text, they constrain the length of the columns
+	// instead.
 	MinWidth, MaxWidth int
 	// Locale provides primary direction and language information for the shaped text.
+	// A direction of [system.TTB] lays the text out vertically, in columns that
+	// progress from right to left.
 	Locale system.Locale
 
 	// LineHeightScale is a scaling factor applied to the LineHeight of a paragraph. If zero, a default
@@ -72,6 +81,12 @@ type Parameters struct {
 	// should set LineHeightScale to 1.
 	LineHeight fixed.Int26_6
 
+	// LetterSpacing is extra space added after every glyph cluster, also known as tracking.
+	// It may be negative to tighten text.
+	LetterSpacing fixed.Int26_6
+	// WordSpacing is extra space added to every word separator, such as the space character.
+	WordSpacing fixed.Int26_6
+
 	// forceTruncate controls whether the truncator string is inserted on the final line of
 	// text with a MaxLines. It is unexported because this behavior only makes sense for the
 	// shaper to control when it iterates paragraphs of text.
@@ -160,6 +175,14 @@ const (
 	// FlagTruncator and FlagClusterBreak will have a Runes field accounting for all
 	// runes truncated.
 	FlagTruncator
+	// FlagVertical indicates that the glyph belongs to vertical text. The X of
+	// vertical glyphs is the center line of their column, Y is the top of the
+	// glyph's advance, and Advance extends downwards. Ascent and Descent are the
+	// extents of the column to the right and to the left of X, respectively.
+	FlagVertical
+	// FlagSideways indicates that a vertical glyph is displayed rotated 90°
+	// clockwise, as is customary for Latin text within vertical CJK text.
+	FlagSideways
 )
 
 func (f Flags) String() string {
@@ -199,6 +222,11 @@ func (f Flags) String() string {
 	} else {
 		b.WriteString("_")
 	}
+	if f&FlagSideways != 0 {
+		b.WriteString("W")
+	} else if f&FlagVertical != 0 {
+		b.WriteString("V")
+	}
 	return b.String()
 }
 
@@ -217,12 +245,19 @@ type Shaper struct {
 	config struct {
 		disableSystemFonts bool
 		collection         []FontFace
+		hyphenators        map[language.Language]Hyphenator
+		cacheDir           string
+		fallbacks          map[string]giofont.Typeface
+		bitmapCacheSize    int
 	}
 	initialized      bool
 	shaper           shaperImpl
 	pathCache        pathCache
 	bitmapShapeCache bitmapShapeCache
+	decorationCache  pathCache
 	layoutCache      layoutCache
+	// measureDoc is scratch space for measuring text.
+	measureDoc document
 
 	reader    *bufio.Reader
 	paragraph []byte
@@ -258,6 +293,72 @@ func WithCollection(collection []FontFace) ShaperOption {
 	}
 }
 
+// CacheSizes configures the number of entries of the caches of a Shaper.
+// Larger caches speed up applications that display lots of distinct text,
+// at the cost of memory. Zero sizes select the default of 1000 entries.
+type CacheSizes struct {
+	// Layout is the number of laid out paragraphs cached.
+	Layout int
+	// Path is the number of glyph outlines cached, for both Shape and
+	// Decorations.
+	Path int
+	// Bitmap is the number of bitmap glyph images cached, for both Bitmaps
+	// and the individual images extracted from fonts.
+	Bitmap int
+}
+
+// WithCacheSizes configures the sizes of the caches of the shaper.
+func WithCacheSizes(sizes CacheSizes) ShaperOption {
+	return func(s *Shaper) {
+		s.layoutCache.size = sizes.Layout
+		s.pathCache.cache.size = sizes.Path
+		s.decorationCache.cache.size = sizes.Path
+		s.bitmapShapeCache.cache.size = sizes.Bitmap
+		s.config.bitmapCacheSize = sizes.Bitmap
+	}
+}
+
+// CacheStats describes the use of a cache.
+type CacheStats struct {
+	// Len is the number of entries in the cache, and Capacity is the maximum
+	// number of entries.
+	Len, Capacity int
+	// Hits and Misses count the lookups that found and failed to find an entry.
+	Hits, Misses uint64
+	// Evictions counts the entries discarded to make room for new entries.
+	Evictions uint64
+}
+
+func (c CacheStats) add(o CacheStats) CacheStats {
+	return CacheStats{
+		Len:       c.Len + o.Len,
+		Capacity:  c.Capacity + o.Capacity,
+		Hits:      c.Hits + o.Hits,
+		Misses:    c.Misses + o.Misses,
+		Evictions: c.Evictions + o.Evictions,
+	}
+}
+
+// ShaperStats describes the use of the caches of a Shaper. The caches are
+// grouped like the fields of [CacheSizes], and the statistics of each group
+// are the sum of the statistics of its caches.
+type ShaperStats struct {
+	Layout CacheStats
+	Path   CacheStats
+	Bitmap CacheStats
+}
+
+// Stats returns the cache statistics of the shaper, accumulated since it
+// was created.
+func (l *Shaper) Stats() ShaperStats {
+	l.init()
+	return ShaperStats{
+		Layout: l.layoutCache.Stats(),
+		Path:   l.pathCache.cache.Stats().add(l.decorationCache.cache.Stats()),
+		Bitmap: l.bitmapShapeCache.cache.Stats().add(l.shaper.bitmapGlyphCache.Stats()),
+	}
+}
+
 // NewShaper constructs a shaper with the provided options.
 //
 // NewShaper must be called after [app.NewWindow], unless the [NoSystemFonts]
@@ -278,7 +379,10 @@ func (l *Shaper) init() {
 	}
 	l.initialized = true
 	l.reader = bufio.NewReader(nil)
-	l.shaper = *newShaperImpl(!l.config.disableSystemFonts, l.config.collection)
+	l.shaper = *newShaperImpl(!l.config.disableSystemFonts, l.config.cacheDir, l.config.collection)
+	l.shaper.hyphenators = l.config.hyphenators
+	l.shaper.bitmapGlyphCache.size = l.config.bitmapCacheSize
+	l.shaper.setFallbacks(l.config.fallbacks)
 }
 
 // Layout text from an io.Reader according to a set of options. Results can be retrieved by
@@ -306,8 +410,14 @@ func (l *Shaper) reset(align Alignment) {
 // by paragraph. Only one of txt and str should be provided.
 func (l *Shaper) layoutText(params Parameters, txt io.Reader, str string) {
 	l.reset(params.Alignment)
+	l.layoutDocument(&l.txt, params, txt, str)
+}
+
+// layoutDocument appends the paragraphs of the text to doc, which must be empty.
+// Only one of txt and str should be provided.
+func (l *Shaper) layoutDocument(doc *document, params Parameters, txt io.Reader, str string) {
 	if txt == nil && len(str) == 0 {
-		l.txt.append(l.layoutParagraph(params, "", nil))
+		doc.append(l.layoutParagraph(params, "", nil))
 		return
 	}
 	l.reader.Reset(txt)
@@ -346,7 +456,7 @@ func (l *Shaper) layoutText(params Parameters, txt io.Reader, str string) {
 				done = endByte == len(str)
 			}
 		}
-		if len(str[:endByte]) > 0 || (len(l.paragraph) > 0 || len(l.txt.lines) == 0) {
+		if len(str[:endByte]) > 0 || (len(l.paragraph) > 0 || len(doc.lines) == 0) {
 			params.forceTruncate = truncating && !done
 			lines := l.layoutParagraph(params, str[:endByte], l.paragraph)
 			if truncating {
@@ -367,10 +477,10 @@ func (l *Shaper) layoutText(params Parameters, txt io.Reader, str string) {
 							unreadRunes++
 						}
 					}
-					l.txt.unreadRuneCount = unreadRunes
+					doc.unreadRuneCount = unreadRunes
 				}
 			}
-			l.txt.append(lines)
+			doc.append(lines)
 		}
 		if done {
 			return
@@ -389,7 +499,8 @@ func (l *Shaper) layoutParagraph(params Parameters, asStr string, asBytes []byte
 	if len(asStr) == 0 && len(asBytes) > 0 {
 		asStr = string(asBytes)
 	}
-	// Alignment is not part of the cache key because changing it does not impact shaping.
+	// Alignment is not part of the cache key because changing it does not impact shaping,
+	// with the exception of Justify.
 	lk := layoutKey{
 		ppem:            params.PxPerEm,
 		maxWidth:        params.MaxWidth,
@@ -403,6 +514,9 @@ func (l *Shaper) layoutParagraph(params Parameters, asStr string, asBytes []byte
 		str:             asStr,
 		lineHeight:      params.LineHeight,
 		lineHeightScale: params.LineHeightScale,
+		letterSpacing:   params.LetterSpacing,
+		wordSpacing:     params.WordSpacing,
+		justify:         params.Alignment == Justify,
 	}
 	if l, ok := l.layoutCache.Get(lk); ok {
 		return l
@@ -443,14 +557,19 @@ func (l *Shaper) NextGlyph() (_ Glyph, ok bool) {
 			// entire text is a shaped empty string. Return a single synthetic
 			// glyph to provide ascent/descent information to the caller.
 			l.done = true
-			return Glyph{
+			g := Glyph{
 				X:       align,
 				Y:       int32(line.yOffset),
 				Runes:   0,
 				Flags:   FlagLineBreak | FlagClusterBreak | FlagRunBreak,
 				Ascent:  line.ascent,
 				Descent: line.descent,
-			}, true
+			}
+			if line.direction.Axis() == system.Vertical {
+				g.X, g.Y = fixed.I(line.yOffset), int32(align.Floor())
+				g.Flags |= FlagVertical
+			}
+			return g, true
 		}
 		if l.glyph == len(run.Glyphs) {
 			l.run++
@@ -465,10 +584,15 @@ func (l *Shaper) NextGlyph() (_ Glyph, ok bool) {
 			glyphIdx = len(run.Glyphs) - 1 - glyphIdx
 		}
 		g := run.Glyphs[glyphIdx]
+		vertical := run.Direction.Axis() == system.Vertical
+		advance := g.xAdvance
+		if vertical {
+			advance = g.yAdvance
+		}
 		if rtl {
 			// Modify the advance prior to computing runOffset to ensure that the
 			// current glyph's width is subtracted in RTL.
-			l.advance += g.xAdvance
+			l.advance += advance
 		}
 		// runOffset computes how far into the run the dot should be positioned.
 		runOffset := l.advance
@@ -489,12 +613,29 @@ func (l *Shaper) NextGlyph() (_ Glyph, ok bool) {
 			},
 			Bounds: g.bounds,
 		}
+		if vertical {
+			// Vertical glyphs flow down the column centered on the line's baseline. The
+			// fractional part of their position is carried by the offset.
+			pos := glyph.X
+			y := pos.Floor()
+			frac := pos - fixed.I(y)
+			glyph.X = fixed.I(line.yOffset)
+			glyph.Y = int32(y)
+			glyph.Advance = g.yAdvance
+			glyph.Offset = fixed.Point26_6{X: -g.xOffset, Y: g.yOffset - frac}
+			glyph.Bounds = g.bounds.Add(fixed.Point26_6{X: g.xOffset, Y: frac - g.yOffset})
+			glyph.Flags |= FlagVertical
+			if run.sideways {
+				glyph.Offset.Y = -frac
+				glyph.Flags |= FlagSideways
+			}
+		}
 		if run.truncator {
 			glyph.Flags |= FlagTruncator
 		}
 		l.glyph++
 		if !rtl {
-			l.advance += g.xAdvance
+			l.advance += advance
 		}
 
 		endOfRun := l.glyph == len(run.Glyphs)
@@ -545,6 +686,11 @@ func (l *Shaper) NextGlyph() (_ Glyph, ok bool) {
 				// taking text alignment into account.
 				l.pararagraphStart.X = l.txt.alignment.Align(line.direction, 0, l.txt.alignWidth)
 				l.pararagraphStart.Y = glyph.Y + int32(line.lineHeight.Round())
+				if vertical {
+					l.pararagraphStart.X = glyph.X - fixed.I(line.lineHeight.Round())
+					l.pararagraphStart.Y = int32(l.txt.alignment.Align(line.direction, 0, l.txt.alignWidth).Floor())
+					l.pararagraphStart.Flags |= FlagVertical
+				}
 			}
 		}
 		return glyph, true
@@ -614,3 +760,24 @@ func (l *Shaper) Bitmaps(gs []Glyph) op.CallOp {
 	l.bitmapShapeCache.Put(key, gs, call)
 	return call
 }
+
+// Decorations converts the provided glyphs into a path enclosing the lines
+// described by d, using the underline and strikethrough metrics of the font
+// that provided each glyph. The returned path aligns with the return value of
+// Shape() for the same gs slice.
+// All glyphs are expected to be from a single line of text (their Y offsets are ignored).
+// Vertical glyphs are skipped, so the decorations of vertical text are empty.
+func (l *Shaper) Decorations(gs []Glyph, d Decoration) clip.PathSpec {
+	l.init()
+	// Mix the decoration into the key so that different decorations of the same
+	// glyphs are cached independently.
+	key := l.decorationCache.hashGlyphs(gs) ^ uint64(d)*0x9e3779b97f4a7c15
+	shape, ok := l.decorationCache.Get(key, gs)
+	if ok {
+		return shape
+	}
+	pathOps := new(op.Ops)
+	shape = l.shaper.Decorations(pathOps, gs, d)
+	l.decorationCache.Put(key, gs, shape)
+	return shape
+}
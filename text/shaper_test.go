@@ -581,3 +581,59 @@ func TestShapeStringRuneAccounting(t *testing.T) {
 		})
 	}
 }
+
+// TestVerticalLayout checks that text shaped with a vertical direction is laid out in
+// columns flowing down the page and progressing leftwards.
+func TestVerticalLayout(t *testing.T) {
+	ltrFace, _ := opentype.Parse(goregular.TTF)
+	shaper := NewShaper(NoSystemFonts(), WithCollection([]FontFace{{Face: ltrFace}}))
+	shaper.LayoutString(Parameters{
+		PxPerEm:  fixed.I(16),
+		MaxWidth: 60,
+		Locale:   system.Locale{Language: "ja", Direction: system.TTB},
+	}, "lorem ipsum dolor sit")
+	var columns []fixed.Int26_6
+	var lastY int32
+	newColumn := true
+	for g, ok := shaper.NextGlyph(); ok; g, ok = shaper.NextGlyph() {
+		if g.Flags&FlagVertical == 0 {
+			t.Fatalf("glyph %+v is not vertical", g)
+		}
+		if g.Advance < 0 {
+			t.Errorf("glyph has negative advance %v", g.Advance)
+		}
+		if newColumn {
+			columns = append(columns, g.X)
+			newColumn = false
+		} else {
+			if g.X != columns[len(columns)-1] {
+				t.Errorf("glyph X %v differs from its column %v", g.X, columns[len(columns)-1])
+			}
+			if g.Y < lastY {
+				t.Errorf("glyph Y %d is above the previous glyph at %d", g.Y, lastY)
+			}
+		}
+		lastY = g.Y
+		// Latin text is set sideways in vertical lines.
+		if g.Flags&FlagSideways == 0 {
+			t.Errorf("glyph %+v is not sideways", g)
+		}
+		if g.Y+int32(g.Advance.Ceil()) > 60+1 {
+			t.Errorf("glyph at %d with advance %v exceeds the column length", g.Y, g.Advance)
+		}
+		if g.Flags&FlagLineBreak != 0 {
+			newColumn = true
+		}
+	}
+	if len(columns) < 2 {
+		t.Fatalf("expected multiple columns, got %d", len(columns))
+	}
+	for i := 1; i < len(columns); i++ {
+		if columns[i] >= columns[i-1] {
+			t.Errorf("column %d at %v is not left of column %d at %v", i, columns[i], i-1, columns[i-1])
+		}
+	}
+	if columns[len(columns)-1] < 0 {
+		t.Errorf("final column at %v lies left of the origin", columns[len(columns)-1])
+	}
+}
@@ -0,0 +1,445 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package text
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	giofont "gioui.org/font"
+	"gioui.org/font/opentype"
+	"github.com/go-text/typesetting/font"
+	"github.com/go-text/typesetting/fontscan"
+	"github.com/go-text/typesetting/language"
+)
+
+// FontInfo describes a font face available to a Shaper.
+type FontInfo struct {
+	// Font describes the face. The Typeface of system faces is their family name
+	// in normalized form (lower case without spaces), which can be used anywhere
+	// a typeface is expected.
+	Font giofont.Font
+	// Path is the file containing the face. It is empty for faces provided with
+	// [WithCollection].
+	Path string
+	// Index is the index of the face within a font collection file.
+	Index int
+}
+
+// WithFontCacheDir sets the directory used to persist the index of system fonts,
+// which avoids re-scanning the font directories every time a program starts. If
+// unset, the directory returned by [os.UserCacheDir] is used. System fonts are
+// indexed once per process, so only the first Shaper to load them determines
+// the location of the index.
+func WithFontCacheDir(dir string) ShaperOption {
+	return func(s *Shaper) {
+		s.config.cacheDir = dir
+	}
+}
+
+// WithFallback configures the typefaces tried, in order, for text in the given
+// script whenever the typeface requested for the text cannot display it.
+// script is an ISO 15924 code such as "Hani" or "Arab", and typeface is a
+// comma-separated list of families in the format of [giofont.Typeface].
+// Scripts without a fallback configured, and runes not covered by any of the
+// fallback typefaces, are resolved with the default fallback rules.
+func WithFallback(script string, typeface giofont.Typeface) ShaperOption {
+	return func(s *Shaper) {
+		if s.config.fallbacks == nil {
+			s.config.fallbacks = make(map[string]giofont.Typeface)
+		}
+		s.config.fallbacks[script] = typeface
+	}
+}
+
+// Faces returns the font faces available to the shaper, including the system
+// faces discovered when system fonts are enabled.
+func (l *Shaper) Faces() []FontInfo {
+	l.init()
+	infos := make([]FontInfo, len(l.shaper.fonts))
+	for i, f := range l.shaper.fonts {
+		infos[i] = f.info
+	}
+	return infos
+}
+
+// AddFontDir scans dir and its subdirectories for font files and makes their
+// faces available to the shaper. Unreadable font files are skipped. Text laid out
+// after AddFontDir returns may use the new faces.
+func (l *Shaper) AddFontDir(dir string) error {
+	l.init()
+	err := l.shaper.addFontDir(dir)
+	// The new faces may change the result of face resolution for any text.
+	l.layoutCache.Clear()
+	return err
+}
+
+// fontEntry is a font face known to the shaper.
+type fontEntry struct {
+	info FontInfo
+	// family is the normalized family name of the face.
+	family string
+	aspect font.Aspect
+	// runes is the coverage of system faces that have not been loaded.
+	runes fontscan.RuneSet
+	// lazy is the source of faces whose parsing is deferred until they are
+	// first needed.
+	lazy lazyFace
+	// face is the loaded face, or nil if it has not been loaded yet.
+	face *font.Face
+	// failed is set if loading the face failed.
+	failed bool
+}
+
+// lazyFace is implemented by faces that are parsed when first used, such as
+// those returned by [opentype.ParseCollectionLazy].
+type lazyFace interface {
+	giofont.Face
+	// Loaded reports whether the face has been parsed successfully.
+	Loaded() bool
+	// Load parses the face.
+	Load() error
+	// Covers reports whether the face can display a rune, without parsing
+	// more than its character map.
+	Covers(r rune) bool
+}
+
+// covers reports whether the face can display r.
+func (f *fontEntry) covers(r rune) bool {
+	if f.face != nil {
+		_, ok := f.face.NominalGlyph(r)
+		return ok
+	}
+	if f.lazy != nil {
+		return f.lazy.Covers(r)
+	}
+	return f.runes.Contains(r)
+}
+
+// load returns the face, parsing it from disk if necessary. The faces of font
+// files are looked up with collection.
+func (f *fontEntry) load(collection func(path string) ([]giofont.FontFace, error)) (*font.Face, error) {
+	if f.face != nil {
+		return f.face, nil
+	}
+	if f.lazy != nil {
+		if err := f.lazy.Load(); err != nil {
+			return nil, err
+		}
+		f.face = f.lazy.Face()
+		return f.face, nil
+	}
+	faces, err := collection(f.info.Path)
+	if err != nil {
+		return nil, err
+	}
+	if f.info.Index >= len(faces) {
+		return nil, fmt.Errorf("reading font %s: no face at index %d", f.info.Path, f.info.Index)
+	}
+	lazy := faces[f.info.Index].Face.(*opentype.LazyFace)
+	if err := lazy.Load(); err != nil {
+		return nil, fmt.Errorf("reading font %s: %w", f.info.Path, err)
+	}
+	f.face = lazy.Face()
+	return f.face, nil
+}
+
+// maxCollections is the number of system font files whose faces are kept by
+// the shaper for loading further faces from the same file.
+const maxCollections = 4
+
+// fontCollection is the faces of a system font file.
+type fontCollection struct {
+	path  string
+	faces []giofont.FontFace
+}
+
+// collection returns the faces of the font file at path. Each face is parsed
+// only when it is loaded, and the most recently used files are kept, so that
+// resolving several faces of a large collection doesn't read it repeatedly.
+func (s *shaperImpl) collection(path string) ([]giofont.FontFace, error) {
+	for i, c := range s.collections {
+		if c.path == path {
+			copy(s.collections[1:i+1], s.collections[:i])
+			s.collections[0] = c
+			return c.faces, nil
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	faces, err := opentype.ParseCollectionLazy(data)
+	if err != nil {
+		return nil, fmt.Errorf("reading font %s: %w", path, err)
+	}
+	if len(s.collections) < maxCollections {
+		s.collections = append(s.collections, fontCollection{})
+	}
+	copy(s.collections[1:], s.collections)
+	s.collections[0] = fontCollection{path: path, faces: faces}
+	return faces, nil
+}
+
+// addFont records a face in the shaper's font index.
+func (s *shaperImpl) addFont(f fontEntry) {
+	if s.fontsByFamily == nil {
+		s.fontsByFamily = make(map[string][]int)
+	}
+	s.fontsByFamily[f.family] = append(s.fontsByFamily[f.family], len(s.fonts))
+	s.fonts = append(s.fonts, f)
+}
+
+// systemFonts holds the footprints of the system fonts, which are listed once
+// per process and shared by all shapers.
+var systemFonts struct {
+	once       sync.Once
+	footprints []fontscan.Footprint
+	err        error
+}
+
+// addSystemFonts records the faces discovered by scanning the system fonts. The
+// scan is the one done by [fontscan.FontMap.UseSystemFonts], which must be
+// called first.
+func (s *shaperImpl) addSystemFonts(cacheDir string) {
+	systemFonts.once.Do(func() {
+		systemFonts.footprints, systemFonts.err = fontscan.SystemFonts(s.logger, cacheDir)
+	})
+	if err := systemFonts.err; err != nil {
+		s.logger.Printf("failed listing system fonts: %v", err)
+		return
+	}
+	for _, fp := range systemFonts.footprints {
+		s.addFont(fontEntry{
+			info: FontInfo{
+				Font: opentype.DescriptionToFont(font.Description{
+					Family: fp.Family,
+					Aspect: fp.Aspect,
+				}),
+				Path:  fp.Location.File,
+				Index: int(fp.Location.Index),
+			},
+			family: fp.Family,
+			aspect: fp.Aspect,
+			runes:  fp.Runes,
+		})
+	}
+}
+
+// setFallbacks parses the fallback typefaces configured for each script.
+func (s *shaperImpl) setFallbacks(fallbacks map[string]giofont.Typeface) {
+	for script, typeface := range fallbacks {
+		sc, err := language.ParseScript(script)
+		if err != nil {
+			s.logger.Printf("Invalid fallback script %q: %v", script, err)
+			continue
+		}
+		families, err := s.parser.parse(string(typeface))
+		if err != nil {
+			s.logger.Printf("Unable to parse fallback typeface %q: %v", typeface, err)
+			continue
+		}
+		for i, f := range families {
+			families[i] = font.NormalizeFamily(f)
+		}
+		if s.fallbacks == nil {
+			s.fallbacks = make(map[language.Script][]string)
+		}
+		s.fallbacks[sc] = families
+	}
+}
+
+// fontExtensions are the extensions of the files loaded by addFontDir.
+var fontExtensions = []string{".ttf", ".otf", ".ttc", ".otc", ".woff", ".woff2"}
+
+// addFontDir loads the font files within dir.
+func (s *shaperImpl) addFontDir(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !isFontFile(path) {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			s.logger.Printf("failed reading font %s: %v", path, err)
+			return nil
+		}
+		faces, err := opentype.ParseCollection(data)
+		if err != nil {
+			s.logger.Printf("failed parsing font %s: %v", path, err)
+			return nil
+		}
+		for i, ff := range faces {
+			desc := opentype.FontToDescription(ff.Font)
+			face := ff.Face.Face()
+			s.fontMap.AddFace(face, fontscan.Location{File: path, Index: uint16(i)}, desc)
+			s.addFont(fontEntry{
+				info:   FontInfo{Font: ff.Font, Path: path, Index: i},
+				family: font.NormalizeFamily(desc.Family),
+				aspect: desc.Aspect,
+				face:   face,
+			})
+		}
+		return nil
+	})
+}
+
+func isFontFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range fontExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveFallback returns the face of the configured fallback chain for the script
+// of r, if the face resolved by the font map is not one of the queried families or
+// cannot display r.
+func (s *shaperImpl) resolveFallback(r rune, resolved *font.Face, resolvedFamily string) (*font.Face, giofont.Font, bool) {
+	chain := s.fallbacks[language.LookupScript(r)]
+	if len(chain) == 0 {
+		return nil, giofont.Font{}, false
+	}
+	if s.satisfies(r, resolved, resolvedFamily) {
+		return nil, giofont.Font{}, false
+	}
+	for _, family := range chain {
+		if face, md, ok := s.loadBest(family, r, false); ok {
+			return face, md, true
+		}
+	}
+	return nil, giofont.Font{}, false
+}
+
+// resolveLazy returns a face from the lazily parsed faces that have not been
+// loaded yet, if one of them is a better match for r than the face resolved by
+// the font map. Unloaded faces of the queried families are preferred to faces of
+// other families, and are otherwise tried in the order they were added.
+func (s *shaperImpl) resolveLazy(r rune, resolved *font.Face, resolvedFamily string) (*font.Face, giofont.Font, bool) {
+	if s.unloaded == 0 {
+		return nil, giofont.Font{}, false
+	}
+	if s.satisfies(r, resolved, resolvedFamily) {
+		return nil, giofont.Font{}, false
+	}
+	for _, family := range s.query.Families {
+		if face, md, ok := s.loadBest(font.NormalizeFamily(family), r, true); ok {
+			return face, md, true
+		}
+	}
+	if resolved != nil {
+		if _, ok := resolved.NominalGlyph(r); ok {
+			return nil, giofont.Font{}, false
+		}
+	}
+	for i := range s.fonts {
+		f := &s.fonts[i]
+		if f.lazy == nil || f.face != nil || !f.covers(r) {
+			continue
+		}
+		if face, err := s.loadFont(f); err == nil {
+			return face, f.info.Font, true
+		}
+	}
+	return nil, giofont.Font{}, false
+}
+
+// loadBest loads the face of family that can display r and best matches the
+// queried aspect, considering only unloaded lazy faces if onlyLazy is set.
+func (s *shaperImpl) loadBest(family string, r rune, onlyLazy bool) (*font.Face, giofont.Font, bool) {
+	for {
+		best := -1
+		bestScore := 0
+		for _, idx := range s.fontsByFamily[family] {
+			f := &s.fonts[idx]
+			if onlyLazy && (f.lazy == nil || f.face != nil) || f.failed || !f.covers(r) {
+				continue
+			}
+			score := aspectDistance(s.query.Aspect, f.aspect)
+			if best == -1 || score < bestScore || (score == bestScore && f.face != nil && s.fonts[best].face == nil) {
+				best, bestScore = idx, score
+			}
+		}
+		if best == -1 {
+			return nil, giofont.Font{}, false
+		}
+		f := &s.fonts[best]
+		face, err := s.loadFont(f)
+		if err != nil {
+			// Try the next best face.
+			continue
+		}
+		return face, f.info.Font, true
+	}
+}
+
+// loadFont returns the face of f, loading it if necessary. Lazy faces are added
+// to the font map once loaded, to take part in regular face resolution.
+func (s *shaperImpl) loadFont(f *fontEntry) (*font.Face, error) {
+	if f.face != nil {
+		return f.face, nil
+	}
+	face, err := f.load(s.collection)
+	if err != nil {
+		s.logger.Printf("failed loading face: %v", err)
+		f.failed = true
+		if f.lazy != nil {
+			s.unloaded--
+		}
+		return nil, err
+	}
+	if f.lazy != nil {
+		s.unloaded--
+		desc := opentype.FontToDescription(f.info.Font)
+		s.fontMap.AddFace(face, fontscan.Location{File: fmt.Sprint(desc)}, desc)
+	}
+	return face, nil
+}
+
+// satisfies reports whether face belongs to one of the queried families and can
+// display r.
+func (s *shaperImpl) satisfies(r rune, face *font.Face, family string) bool {
+	if face == nil {
+		return false
+	}
+	if _, ok := face.NominalGlyph(r); !ok {
+		return false
+	}
+	for _, f := range s.query.Families {
+		if font.NormalizeFamily(f) == font.NormalizeFamily(family) {
+			return true
+		}
+	}
+	return false
+}
+
+// aspectDistance scores how far the aspect of a face is from the queried aspect. Lower
+// scores are better matches.
+func aspectDistance(query, a font.Aspect) int {
+	d := 0
+	if query.Style != 0 && query.Style != a.Style {
+		d += 10000
+	}
+	if query.Stretch != 0 {
+		d += int(abs(query.Stretch-a.Stretch) * 1000)
+	}
+	if query.Weight != 0 {
+		d += int(abs(query.Weight - a.Weight))
+	}
+	return d
+}
+
+func abs[T ~float32](v T) T {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,206 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package text
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/math/fixed"
+
+	giofont "gioui.org/font"
+	"gioui.org/font/opentype"
+)
+
+func TestAddFontDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sub", "gomono.ttf"), gomono.TTF, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a font"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	regular, _ := opentype.Parse(goregular.TTF)
+	shaper := NewShaper(NoSystemFonts(), WithCollection([]FontFace{{Font: regular.Font(), Face: regular}}))
+	if got := len(shaper.Faces()); got != 1 {
+		t.Fatalf("expected 1 face before scanning, got %d", got)
+	}
+	if err := shaper.AddFontDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	faces := shaper.Faces()
+	if len(faces) != 2 {
+		t.Fatalf("expected 2 faces after scanning, got %d", len(faces))
+	}
+	if got, want := faces[1].Path, filepath.Join(dir, "sub", "gomono.ttf"); got != want {
+		t.Errorf("expected face path %q, got %q", want, got)
+	}
+	if err := shaper.AddFontDir(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("expected error scanning a missing directory")
+	}
+}
+
+func TestFallback(t *testing.T) {
+	regular, _ := opentype.Parse(goregular.TTF)
+	mono, _ := opentype.Parse(gomono.TTF)
+	collection := []FontFace{
+		{Font: giofont.Font{Typeface: "Go"}, Face: regular},
+		{Font: giofont.Font{Typeface: "Go Mono"}, Face: mono},
+	}
+	params := Parameters{
+		PxPerEm:  fixed.I(16),
+		MaxWidth: 1000,
+		Locale:   english,
+		Font:     giofont.Font{Typeface: "Missing"},
+	}
+	faceOf := func(s *shaperImpl) giofont.Typeface {
+		doc := s.LayoutRunes(params, []rune("a"))
+		g := doc.lines[0].runs[0].Glyphs[0]
+		_, faceIdx, _ := splitGlyphID(g.id)
+		return s.faceMeta[faceIdx].Typeface
+	}
+	if got := faceOf(newShaperImpl(false, "", collection)); got != "Go" {
+		t.Errorf("expected default fallback to the first face, got %q", got)
+	}
+	s := newShaperImpl(false, "", collection)
+	s.setFallbacks(map[string]giofont.Typeface{"Latn": "Nonexistent, Go Mono"})
+	if got := faceOf(s); got != "Go Mono" {
+		t.Errorf("expected configured fallback face, got %q", got)
+	}
+	params.Font.Typeface = "Go"
+	if got := faceOf(s); got != "Go" {
+		t.Errorf("expected queried face to take precedence over fallback, got %q", got)
+	}
+}
+
+func TestLazyFaces(t *testing.T) {
+	regular, err := opentype.ParseCollectionLazy(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mono, err := opentype.ParseCollectionLazy(gomono.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collection := append(regular, mono...)
+	if got := collection[1].Font.Typeface; got != "Go Mono" {
+		t.Errorf("expected lazy face metadata to be read eagerly, got typeface %q", got)
+	}
+	loaded := func(i int) bool {
+		return collection[i].Face.(*opentype.LazyFace).Loaded()
+	}
+	s := newShaperImpl(false, "", collection)
+	if loaded(0) || loaded(1) {
+		t.Fatalf("expected faces to be parsed on demand")
+	}
+	params := Parameters{
+		PxPerEm:  fixed.I(16),
+		MaxWidth: 1000,
+		Locale:   english,
+	}
+	faceOf := func() giofont.Typeface {
+		doc := s.LayoutRunes(params, []rune("a"))
+		g := doc.lines[0].runs[0].Glyphs[0]
+		_, faceIdx, _ := splitGlyphID(g.id)
+		return s.faceMeta[faceIdx].Typeface
+	}
+	if got := faceOf(); got != "Go" {
+		t.Errorf("expected the default face, got %q", got)
+	}
+	if !loaded(0) || loaded(1) {
+		t.Errorf("expected only the default face to be loaded")
+	}
+	params.Font.Typeface = "Go Mono"
+	if got := faceOf(); got != "Go Mono" {
+		t.Errorf("expected the queried face, got %q", got)
+	}
+	if !loaded(1) {
+		t.Errorf("expected the queried face to be loaded")
+	}
+	params.Font.Typeface = "Go"
+	if got := faceOf(); got != "Go" {
+		t.Errorf("expected loaded faces to resolve through the font map, got %q", got)
+	}
+}
+
+func TestLazyFaceFailure(t *testing.T) {
+	// Rename the maximum profile table, which is required to parse the face
+	// but not to describe it.
+	data := bytes.Replace(gomono.TTF, []byte("maxp"), []byte("maxq"), 1)
+	broken, err := opentype.ParseCollectionLazy(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	regular, err := opentype.ParseCollectionLazy(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lazy := broken[0].Face.(*opentype.LazyFace)
+	if lazy.Load() == nil || lazy.Loaded() {
+		t.Fatal("expected the broken face to fail loading")
+	}
+	if lazy.Face() != nil {
+		t.Error("expected no face for the broken face")
+	}
+	// The failed face is skipped rather than registered.
+	s := newShaperImpl(false, "", append(broken, regular...))
+	params := Parameters{
+		PxPerEm:  fixed.I(16),
+		MaxWidth: 1000,
+		Locale:   english,
+		Font:     giofont.Font{Typeface: "Go Mono"},
+	}
+	doc := s.LayoutRunes(params, []rune("a"))
+	_, faceIdx, _ := splitGlyphID(doc.lines[0].runs[0].Glyphs[0].id)
+	if got := s.faceMeta[faceIdx].Typeface; got != "Go" {
+		t.Errorf("expected the working face, got %q", got)
+	}
+}
+
+func TestSystemFaceCollectionCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gomono.ttf")
+	if err := os.WriteFile(path, gomono.TTF, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := newShaperImpl(false, "", nil)
+	first := &fontEntry{info: FontInfo{Path: path}}
+	if _, err := s.loadFont(first); err != nil {
+		t.Fatal(err)
+	}
+	// Faces of a file already read are loaded without reading it again.
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	second := &fontEntry{info: FontInfo{Path: path}}
+	if _, err := s.loadFont(second); err != nil {
+		t.Errorf("expected the collection to be cached: %v", err)
+	}
+	missing := &fontEntry{info: FontInfo{Path: path, Index: 1}}
+	if _, err := s.loadFont(missing); err == nil {
+		t.Errorf("expected an error loading a missing face index")
+	}
+	// Only the most recently used files are kept.
+	for i := 0; i < maxCollections; i++ {
+		other := filepath.Join(t.TempDir(), "gomono.ttf")
+		if err := os.WriteFile(other, gomono.TTF, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.loadFont(&fontEntry{info: FontInfo{Path: other}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(s.collections); n != maxCollections {
+		t.Errorf("got %d cached collections, expected %d", n, maxCollections)
+	}
+	evicted := &fontEntry{info: FontInfo{Path: path}}
+	if _, err := s.loadFont(evicted); err == nil {
+		t.Errorf("expected the least recently used collection to be evicted")
+	}
+}
@@ -15,6 +15,9 @@ const (
 	Start Alignment = iota
 	End
 	Middle
+	// Justify aligns text to the start of each line, and then widens every line
+	// except the final line of each paragraph to fill the available width.
+	Justify
 )
 
 func (a Alignment) String() string {
@@ -25,6 +28,8 @@ func (a Alignment) String() string {
 		return "End"
 	case Middle:
 		return "Middle"
+	case Justify:
+		return "Justify"
 	default:
 		panic("invalid Alignment")
 	}
@@ -35,6 +40,9 @@ func (a Alignment) String() string {
 // text direction dir.
 func (a Alignment) Align(dir system.TextDirection, width fixed.Int26_6, maxWidth int) fixed.Int26_6 {
 	mw := fixed.I(maxWidth)
+	if a == Justify {
+		a = Start
+	}
 	if dir.Progression() == system.TowardOrigin {
 		switch a {
 		case Start:
@@ -54,3 +62,31 @@ func (a Alignment) Align(dir system.TextDirection, width fixed.Int26_6, maxWidth
 		panic(fmt.Errorf("unknown alignment %v", a))
 	}
 }
+
+// Decoration is a set of lines drawn alongside shaped text. Decorations do
+// not impact shaping, and are positioned using the metrics of the font that
+// provided each glyph.
+// Glyphs of vertical text, marked with [FlagVertical], are not decorated.
+type Decoration uint8
+
+const (
+	// Underline draws a line below the baseline of the text.
+	Underline Decoration = 1 << iota
+	// Strikethrough draws a line through the text.
+	Strikethrough
+)
+
+func (d Decoration) String() string {
+	switch d {
+	case 0:
+		return "None"
+	case Underline:
+		return "Underline"
+	case Strikethrough:
+		return "Strikethrough"
+	case Underline | Strikethrough:
+		return "Underline|Strikethrough"
+	default:
+		panic("invalid Decoration")
+	}
+}
@@ -0,0 +1,221 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package widget
+
+import (
+	"bytes"
+	"cmp"
+	"image"
+	"slices"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/image/math/fixed"
+)
+
+// textCaret is a caret and the selection it extends.
+type textCaret struct {
+	// xoff is the offset to the current position when moving between lines.
+	xoff fixed.Int26_6
+	// start is the current caret position in runes, and also the start position of
+	// selected text. end is the end position of selected text. If start
+	// == end, then there's no selection. Note that it's possible (and
+	// common) that the caret (start) is after the end, e.g. after
+	// Shift-DownArrow.
+	start int
+	end   int
+}
+
+// bounds returns the ordered start and end of the selection.
+func (c textCaret) bounds() (start, end int) {
+	return min(c.start, c.end), max(c.start, c.end)
+}
+
+// overlaps reports whether c and o should be merged into one caret. That
+// is, whether their selections overlap or an empty selection touches
+// the other.
+func (c textCaret) overlaps(o textCaret) bool {
+	s1, e1 := c.bounds()
+	s2, e2 := o.bounds()
+	lo, hi := max(s1, s2), min(e1, e2)
+	return lo < hi || lo == hi && (s1 == e1 || s2 == e2)
+}
+
+// union returns a caret selecting both c and o, in the direction of c
+// unless c has no selection.
+func (c textCaret) union(o textCaret) textCaret {
+	s1, e1 := c.bounds()
+	s2, e2 := o.bounds()
+	start, end := min(s1, s2), max(e1, e2)
+	dir := c
+	if c.start == c.end {
+		dir = o
+	}
+	if dir.start > dir.end {
+		start, end = end, start
+	}
+	return textCaret{start: start, end: end}
+}
+
+// addCaret adds a caret at start with its selection ending at end, and
+// makes it the primary caret. The previous primary caret becomes a
+// secondary caret.
+func (e *textView) addCaret(start, end int) {
+	e.carets = append(e.carets, e.caret)
+	e.caret = textCaret{}
+	e.SetCaret(start, end)
+	e.mergeCarets()
+}
+
+// clearCarets removes the secondary carets.
+func (e *textView) clearCarets() {
+	e.carets = e.carets[:0]
+}
+
+// eachCaret calls f once for every caret, with the caret temporarily
+// made the primary caret. Carets that overlap afterwards are merged.
+func (e *textView) eachCaret(f func()) {
+	for i := range e.carets {
+		e.caret, e.carets[i] = e.carets[i], e.caret
+		f()
+		e.caret, e.carets[i] = e.carets[i], e.caret
+	}
+	f()
+	e.mergeCarets()
+}
+
+// mergeCarets replaces overlapping carets with their union and sorts the
+// secondary carets by position. The primary caret absorbs every caret it
+// overlaps.
+func (e *textView) mergeCarets() {
+	if len(e.carets) == 0 {
+		return
+	}
+	primary := -1
+	all := append(e.carets, e.caret)
+	slices.SortStableFunc(all, func(a, b textCaret) int {
+		s1, _ := a.bounds()
+		s2, _ := b.bounds()
+		return cmp.Compare(s1, s2)
+	})
+	merged := all[:0]
+	for _, c := range all {
+		isPrimary := c == e.caret && primary == -1
+		if n := len(merged); n > 0 && merged[n-1].overlaps(c) {
+			if isPrimary {
+				merged[n-1] = c.union(merged[n-1])
+			} else {
+				merged[n-1] = merged[n-1].union(c)
+			}
+			if isPrimary || primary == n-1 {
+				primary = n - 1
+				merged[n-1].xoff = 0
+			}
+			continue
+		}
+		if isPrimary {
+			primary = len(merged)
+		}
+		merged = append(merged, c)
+	}
+	e.caret = merged[primary]
+	e.carets = slices.Delete(merged, primary, primary+1)
+}
+
+// selectColumns replaces the carets with one caret per line between the
+// document coordinates from and to, each selecting the text between the
+// horizontal positions of from and to. The caret on the line of to
+// becomes the primary caret.
+func (e *textView) selectColumns(from, to image.Point) {
+	first := e.closestToXY(fixed.I(from.X), from.Y).lineCol.line
+	last := e.closestToXY(fixed.I(to.X), to.Y).lineCol.line
+	dir := 1
+	if last < first {
+		dir = -1
+	}
+	e.clearCarets()
+	for line := first; ; line += dir {
+		y := e.closestToLineCol(line, 0).y
+		anchor := e.closestToXYGraphemes(fixed.I(from.X), y)
+		pos := e.closestToXYGraphemes(fixed.I(to.X), y)
+		if line != first {
+			e.carets = append(e.carets, e.caret)
+		}
+		e.caret = textCaret{start: pos.runes, end: anchor.runes}
+		if line == last {
+			break
+		}
+	}
+	e.mergeCarets()
+}
+
+// isWordRune reports whether r is part of a word for the purpose of
+// selecting words around the caret.
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// wordAt returns the rune range of the word containing or touching the
+// rune offset pos. The range is empty if there is no such word.
+func (e *textView) wordAt(pos int) (start, end int) {
+	pos = e.closestToRune(pos).runes
+	off := int64(e.runeOffset(pos))
+	start, end = pos, pos
+	for o := off; ; {
+		r, n, err := e.ReadRuneBefore(o)
+		if err != nil || !isWordRune(r) {
+			break
+		}
+		o -= int64(n)
+		start--
+	}
+	for o := off; ; {
+		r, n, err := e.ReadRuneAt(o)
+		if err != nil || !isWordRune(r) {
+			break
+		}
+		o += int64(n)
+		end++
+	}
+	return start, end
+}
+
+// nextMatch returns the rune range of the first occurrence of s that
+// starts at or after the rune offset from, wrapping around to the start
+// of the text. Occurrences already selected by a caret are skipped.
+func (e *textView) nextMatch(from int, s []byte) (start, end int, ok bool) {
+	if len(s) == 0 {
+		return 0, 0, false
+	}
+	text := e.Text(nil)
+	n := utf8.RuneCount(s)
+	fromOff := e.runeOffset(e.closestToRune(from).runes)
+	selected := func(start int) bool {
+		for i := -1; i < len(e.carets); i++ {
+			c := e.caret
+			if i >= 0 {
+				c = e.carets[i]
+			}
+			if lo, hi := c.bounds(); lo == start && hi == start+n {
+				return true
+			}
+		}
+		return false
+	}
+	for _, r := range [][2]int{{fromOff, len(text)}, {0, fromOff}} {
+		for off := r[0]; off < r[1]; {
+			i := bytes.Index(text[off:], s)
+			if i == -1 || off+i >= r[1] {
+				break
+			}
+			off += i
+			start := utf8.RuneCount(text[:off])
+			if !selected(start) {
+				return start, start + n, true
+			}
+			_, size := utf8.DecodeRune(text[off:])
+			off += size
+		}
+	}
+	return 0, 0, false
+}
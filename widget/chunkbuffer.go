@@ -0,0 +1,152 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package widget
+
+import (
+	"io"
+	"slices"
+	"sort"
+	"unicode/utf8"
+
+	"golang.org/x/text/runes"
+)
+
+// chunkBuffer implements a text buffer that stores its contents in a sequence
+// of bounded chunks. Unlike editBuffer, an edit only copies the chunks it
+// touches, so editing stays cheap regardless of the size of the text.
+type chunkBuffer struct {
+	chunks [][]byte
+	// starts holds the byte offset of every chunk.
+	starts []int64
+	size   int64
+
+	// changed tracks whether the buffer content
+	// has changed since the last call to Changed.
+	changed bool
+}
+
+var _ TextSource = (*chunkBuffer)(nil)
+
+// maxChunkSize is the maximum size of a chunkBuffer chunk in bytes.
+const maxChunkSize = 4096
+
+func (c *chunkBuffer) Changed() bool {
+	changed := c.changed
+	c.changed = false
+	return changed
+}
+
+func (c *chunkBuffer) Size() int64 {
+	return c.size
+}
+
+// chunk returns the index of the chunk containing the byte at offset, or
+// len(c.chunks) if offset is at or beyond the end of the buffer.
+func (c *chunkBuffer) chunk(offset int64) int {
+	return sort.Search(len(c.starts), func(i int) bool {
+		return c.starts[i]+int64(len(c.chunks[i])) > offset
+	})
+}
+
+func (c *chunkBuffer) ReadAt(p []byte, offset int64) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if offset >= c.size {
+		return 0, io.EOF
+	}
+	var total int
+	for i := c.chunk(offset); i < len(c.chunks) && len(p) > 0; i++ {
+		n := copy(p, c.chunks[i][offset-c.starts[i]:])
+		p = p[n:]
+		total += n
+		offset += int64(n)
+	}
+	return total, nil
+}
+
+// ReplaceRunes replaces runeCount runes starting at byteOffset with s. A
+// negative runeCount replaces runes before byteOffset.
+func (c *chunkBuffer) ReplaceRunes(byteOffset, runeCount int64, s string) {
+	if !utf8.ValidString(s) {
+		s = runes.ReplaceIllFormed().String(s)
+	}
+	start := min(max(byteOffset, 0), c.size)
+	end := start
+	var buf [utf8.UTFMax]byte
+	for ; runeCount < 0 && start > 0; runeCount++ {
+		b := buf[:min(start, utf8.UTFMax)]
+		n, _ := c.ReadAt(b, start-int64(len(b)))
+		_, size := utf8.DecodeLastRune(b[:n])
+		start -= int64(size)
+	}
+	for ; runeCount > 0 && end < c.size; runeCount-- {
+		n, _ := c.ReadAt(buf[:], end)
+		_, size := utf8.DecodeRune(buf[:n])
+		end += int64(size)
+	}
+	if start == end && len(s) == 0 {
+		return
+	}
+	c.replace(start, end, s)
+	c.changed = true
+}
+
+// replace replaces the bytes in [start, end) with s.
+func (c *chunkBuffer) replace(start, end int64, s string) {
+	first := min(c.chunk(start), len(c.chunks)-1)
+	if first < 0 {
+		// The buffer is empty.
+		first = 0
+	}
+	last := c.chunk(max(end-1, start))
+	last = max(min(last, len(c.chunks)-1), first)
+	var text []byte
+	if first < len(c.chunks) {
+		prefix := c.chunks[first][:start-c.starts[first]]
+		suffix := c.chunks[last][end-c.starts[last]:]
+		n := len(prefix) + len(s) + len(suffix)
+		var next []byte
+		if last+1 < len(c.chunks) && n < maxChunkSize/2 && n+len(c.chunks[last+1]) <= maxChunkSize {
+			// Merge small chunks with their successor to avoid
+			// fragmenting the buffer.
+			last++
+			next = c.chunks[last]
+		}
+		text = make([]byte, 0, n+len(next))
+		text = append(text, prefix...)
+		text = append(text, s...)
+		text = append(text, suffix...)
+		text = append(text, next...)
+	} else {
+		text = []byte(s)
+	}
+	var chunks [][]byte
+	for len(text) > 0 {
+		n := len(text)
+		if n > maxChunkSize {
+			n = maxChunkSize
+			// Avoid splitting runes between chunks.
+			for n > 0 && !utf8.RuneStart(text[n]) {
+				n--
+			}
+		}
+		chunks = append(chunks, text[:n:n])
+		text = text[n:]
+	}
+	if first < len(c.chunks) {
+		c.chunks = slices.Replace(c.chunks, first, last+1, chunks...)
+	} else {
+		c.chunks = append(c.chunks, chunks...)
+	}
+	c.starts = c.starts[:first]
+	off := int64(0)
+	if first > 0 {
+		off = c.starts[first-1] + int64(len(c.chunks[first-1]))
+	}
+	for _, chunk := range c.chunks[first:] {
+		c.starts = append(c.starts, off)
+		off += int64(len(chunk))
+	}
+	c.size = off
+}
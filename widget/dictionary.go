@@ -0,0 +1,182 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package widget
+
+import (
+	"bufio"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Dictionary is a SpellChecker that accepts the words of a word list.
+type Dictionary struct {
+	words map[string]struct{}
+	// alphabet holds the runes of the words, in increasing order. It is
+	// used for generating suggestions.
+	alphabet []rune
+}
+
+// maxSuggestions is the maximum number of suggestions returned by
+// Dictionary.Suggest.
+const maxSuggestions = 8
+
+// NewDictionary reads a dictionary from a word list with one word per
+// line. The dictionary files of Hunspell are accepted as well: a word count
+// on the first line and affix flags following a '/' are ignored.
+func NewDictionary(r io.Reader) (*Dictionary, error) {
+	d := new(Dictionary)
+	sc := bufio.NewScanner(r)
+	first := true
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if first {
+			first = false
+			if _, err := strconv.Atoi(line); err == nil {
+				continue
+			}
+		}
+		if i := strings.IndexByte(line, '/'); i != -1 {
+			line = line[:i]
+		}
+		if line != "" {
+			d.Add(line)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Add adds words to the dictionary.
+func (d *Dictionary) Add(words ...string) {
+	if d.words == nil {
+		d.words = make(map[string]struct{})
+	}
+	for _, w := range words {
+		d.words[w] = struct{}{}
+		for _, r := range w {
+			if i, found := slices.BinarySearch(d.alphabet, r); !found {
+				d.alphabet = slices.Insert(d.alphabet, i, r)
+			}
+		}
+	}
+}
+
+// Check reports whether word is in the dictionary. Words with upper case
+// letters also match their lower case form, so that capitalized words
+// match. Words without letters, such as numbers, are always accepted.
+func (d *Dictionary) Check(word string) bool {
+	if strings.IndexFunc(word, unicode.IsLetter) == -1 || d.has(word) {
+		return true
+	}
+	lower := strings.ToLower(word)
+	return lower != word && d.has(lower)
+}
+
+func (d *Dictionary) has(word string) bool {
+	_, ok := d.words[word]
+	return ok
+}
+
+// Suggest returns the dictionary words that are a single transposition of
+// adjacent letters, substitution, deletion or insertion away from word.
+// Suggestions are ranked in that order of edits, the likeliest typing
+// mistakes first, and alphabetically for the same edit. Suggestions for
+// capitalized words are capitalized, and suggestions for words in all
+// capitals are in all capitals.
+func (d *Dictionary) Suggest(word string) []string {
+	lower := strings.ToLower(word)
+	upper := lower != word
+	allCaps := upper && strings.ToUpper(word) == word
+	runes := []rune(lower)
+	seen := make(map[string]bool)
+	// found holds the suggestions, because different candidates may match
+	// the same capitalized word.
+	found := make(map[string]bool)
+	var suggestions []string
+	try := func(candidate []rune) {
+		s := string(candidate)
+		if seen[s] {
+			return
+		}
+		seen[s] = true
+		switch {
+		case upper && d.has(capitalize(s)):
+			s = capitalize(s)
+		case d.has(s):
+			if upper {
+				s = capitalize(s)
+			}
+		default:
+			return
+		}
+		if allCaps {
+			s = strings.ToUpper(s)
+		}
+		if !found[s] {
+			found[s] = true
+			suggestions = append(suggestions, s)
+		}
+	}
+	buf := make([]rune, 0, len(runes)+1)
+	edits := []func(){
+		// Transposition.
+		func() {
+			for i := range len(runes) - 1 {
+				buf = append(buf[:0], runes...)
+				buf[i], buf[i+1] = buf[i+1], buf[i]
+				try(buf)
+			}
+		},
+		// Substitution.
+		func() {
+			for i := range runes {
+				for _, r := range d.alphabet {
+					if r != runes[i] {
+						buf = append(buf[:0], runes...)
+						buf[i] = r
+						try(buf)
+					}
+				}
+			}
+		},
+		// Deletion.
+		func() {
+			for i := range runes {
+				try(append(append(buf[:0], runes[:i]...), runes[i+1:]...))
+			}
+		},
+		// Insertion.
+		func() {
+			for i := range len(runes) + 1 {
+				for _, r := range d.alphabet {
+					buf = append(append(append(buf[:0], runes[:i]...), r), runes[i:]...)
+					try(buf)
+				}
+			}
+		},
+	}
+	for _, edit := range edits {
+		n := len(suggestions)
+		edit()
+		slices.Sort(suggestions[n:])
+		if len(suggestions) >= maxSuggestions {
+			break
+		}
+	}
+	if len(suggestions) > maxSuggestions {
+		suggestions = suggestions[:maxSuggestions]
+	}
+	return suggestions
+}
+
+// capitalize returns s with its first letter in upper case.
+func capitalize(s string) string {
+	r, n := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[n:]
+}
@@ -0,0 +1,508 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package widget
+
+import (
+	"image"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gioui.org/gesture"
+	"gioui.org/io/event"
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/io/semantic"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+)
+
+// Dropdown is the state of a field for choosing one of a list of options.
+// While the dropdown is active, the options are displayed in a popup list
+// below the field, above all other content. When the field has the
+// keyboard focus, the arrow keys move through the options, and typing
+// selects the next option that starts with the typed text.
+//
+// An Editable dropdown is a combo box: the field is an Editor whose text
+// filters the options, and the text may match no option at all.
+type Dropdown struct {
+	Options []string
+	// Editable makes the field an editor.
+	Editable bool
+	// Editor is the field of an Editable dropdown. It is always single
+	// line, and selecting an option replaces its text.
+	Editor Editor
+	// List is the popup list of options. Its axis is always vertical.
+	List List
+
+	selected    int
+	hasSelected bool
+	changed     bool
+	active      bool
+	focused     bool
+	// highlighted is the option chosen by the return key, or -1.
+	highlighted int
+	// hovered is the option under the pointer, or -1.
+	hovered int
+	// filter is the text the options are filtered by.
+	filter  string
+	visible []int
+	click   gesture.Click
+	dismiss gesture.Click
+	options []gesture.Click
+	// typed is the text typed for typeahead at typedAt.
+	typed   string
+	typedAt time.Time
+	// fieldSize is the size of the field in the last layout.
+	fieldSize image.Point
+	// viewSize is the height of the popup list in the last layout.
+	viewSize int
+	// reveal is set when the highlighted option must be scrolled into
+	// view by the next layout.
+	reveal bool
+}
+
+// DropdownOption is an option of a Dropdown displayed in its popup list.
+type DropdownOption struct {
+	// Index is the index of the option in Options.
+	Index int
+	// Highlighted reports whether the option is under the pointer or
+	// was moved to by the keyboard.
+	Highlighted bool
+	// Selected reports whether the option is the selected option.
+	Selected bool
+}
+
+// DropdownElement lays out an option of a Dropdown.
+type DropdownElement func(gtx layout.Context, opt DropdownOption) layout.Dimensions
+
+// typeaheadTimeout is the time after which typed text no longer extends
+// the previously typed text.
+const typeaheadTimeout = time.Second
+
+// Update the dropdown state by processing events, and report whether the
+// selection changed by user interaction.
+func (d *Dropdown) Update(gtx layout.Context) bool {
+	d.update(gtx)
+	changed := d.changed
+	d.changed = false
+	return changed
+}
+
+func (d *Dropdown) update(gtx layout.Context) {
+	if !gtx.Enabled() {
+		d.active = false
+	}
+	focused := gtx.Focused(d.focusTag())
+	if d.focused && !focused {
+		// Dismiss the popup when the focus moves elsewhere.
+		d.active = false
+	}
+	d.focused = focused
+	d.updateVisible()
+	hovered := -1
+	for i := range d.options {
+		for {
+			ev, ok := d.options[i].Update(gtx.Source)
+			if !ok {
+				break
+			}
+			if ev.Kind == gesture.KindClick && d.active {
+				d.choose(i)
+			}
+		}
+		if d.options[i].Hovered() {
+			hovered = i
+		}
+	}
+	if hovered != d.hovered && hovered != -1 {
+		d.highlighted = hovered
+	}
+	d.hovered = hovered
+	for {
+		ev, ok := d.dismiss.Update(gtx.Source)
+		if !ok {
+			break
+		}
+		if ev.Kind == gesture.KindPress {
+			d.active = false
+		}
+	}
+	for {
+		ev, ok := d.click.Update(gtx.Source)
+		if !ok {
+			break
+		}
+		switch ev.Kind {
+		case gesture.KindPress:
+			if !d.Editable && ev.Source == pointer.Mouse {
+				gtx.Execute(key.FocusCmd{Tag: d})
+			}
+		case gesture.KindClick:
+			switch {
+			case !d.active:
+				d.Open()
+			case !d.Editable:
+				d.active = false
+			}
+		}
+	}
+	tag := d.focusTag()
+	for {
+		ev, ok := gtx.Event(
+			condFilter(!d.Editable, key.Filter{Focus: tag, Name: key.NameSpace}),
+			condFilter(d.active || !d.Editable, key.Filter{Focus: tag, Name: key.NameReturn}),
+			condFilter(d.active, key.Filter{Focus: tag, Name: key.NameEscape}),
+			key.Filter{Focus: tag, Name: key.NameUpArrow, Optional: key.ModAlt},
+			key.Filter{Focus: tag, Name: key.NameDownArrow, Optional: key.ModAlt},
+			condFilter(d.active, key.Filter{Focus: tag, Name: key.NameHome}),
+			condFilter(d.active, key.Filter{Focus: tag, Name: key.NameEnd}),
+		)
+		if !ok {
+			break
+		}
+		e, ok := ev.(key.Event)
+		if !ok || e.State != key.Press {
+			continue
+		}
+		if e.Name == key.NameSpace && d.typed != "" && gtx.Now.Sub(d.typedAt) <= typeaheadTimeout {
+			// The space is part of the typeahead text.
+			continue
+		}
+		d.command(e)
+	}
+	if d.Editable {
+		// The editor processes events after the dropdown, such that
+		// the dropdown keys don't reach the editor.
+		for {
+			ev, ok := d.Editor.Update(gtx)
+			if !ok {
+				break
+			}
+			if _, ok := ev.(ChangeEvent); ok {
+				d.edited()
+			}
+		}
+	} else {
+		for {
+			ev, ok := gtx.Event(key.FocusFilter{Target: d})
+			if !ok {
+				break
+			}
+			if ev, ok := ev.(key.EditEvent); ok {
+				d.typeahead(gtx.Now, ev.Text)
+			}
+		}
+	}
+}
+
+// command handles the key event e.
+func (d *Dropdown) command(e key.Event) {
+	if !d.active {
+		switch e.Name {
+		case key.NameReturn, key.NameSpace, key.NameUpArrow, key.NameDownArrow:
+			d.Open()
+		}
+		return
+	}
+	j := slices.Index(d.visible, d.highlighted)
+	switch e.Name {
+	case key.NameReturn, key.NameSpace:
+		if j != -1 {
+			d.choose(d.highlighted)
+		} else {
+			d.active = false
+		}
+	case key.NameEscape:
+		d.active = false
+	case key.NameUpArrow:
+		if e.Modifiers.Contain(key.ModAlt) {
+			d.active = false
+			break
+		}
+		d.highlight(max(j-1, 0))
+	case key.NameDownArrow:
+		d.highlight(min(j+1, len(d.visible)-1))
+	case key.NameHome:
+		d.highlight(0)
+	case key.NameEnd:
+		d.highlight(len(d.visible) - 1)
+	}
+}
+
+// highlight the visible option j and scroll it into view.
+func (d *Dropdown) highlight(j int) {
+	if j < 0 || j >= len(d.visible) {
+		return
+	}
+	d.highlighted = d.visible[j]
+	d.reveal = true
+}
+
+// typeahead moves to the next option starting with the text typed
+// recently. Typing the same character repeatedly cycles through the
+// options starting with it.
+func (d *Dropdown) typeahead(now time.Time, txt string) {
+	if now.Sub(d.typedAt) > typeaheadTimeout {
+		d.typed = ""
+	}
+	if d.typed == "" && strings.TrimSpace(txt) == "" {
+		// Leading spaces are from the space key opening the popup.
+		return
+	}
+	d.typedAt = now
+	d.typed += strings.ToLower(txt)
+	cur := d.highlighted
+	if !d.active {
+		cur = -1
+		if d.hasSelected {
+			cur = d.selected
+		}
+	}
+	prefix := d.typed
+	// Extending the typed text may still match the current option.
+	n := utf8.RuneCountInString(prefix)
+	i := d.findPrefix(prefix, cur, n == 1)
+	if i == -1 {
+		r, _ := utf8.DecodeRuneInString(prefix)
+		if strings.Count(prefix, string(r)) == n {
+			i = d.findPrefix(string(r), cur, true)
+		}
+	}
+	if i == -1 {
+		return
+	}
+	if d.active {
+		d.highlighted = i
+		d.reveal = true
+	} else if !d.hasSelected || d.selected != i {
+		d.setSelected(i)
+		d.changed = true
+	}
+}
+
+// findPrefix returns the index of the first visible option from option
+// cur that starts with prefix, wrapping around at the end of the list, or
+// -1 if there is no such option. If next is set, the search starts after
+// option cur.
+func (d *Dropdown) findPrefix(prefix string, cur int, next bool) int {
+	n := len(d.visible)
+	j := slices.Index(d.visible, cur)
+	if j == -1 {
+		j, next = 0, false
+	}
+	if next {
+		j++
+	}
+	for k := range n {
+		i := d.visible[(j+k)%n]
+		if strings.HasPrefix(strings.ToLower(d.Options[i]), prefix) {
+			return i
+		}
+	}
+	return -1
+}
+
+// edited updates the selection and filters the options after a change of
+// the text of an Editable dropdown.
+func (d *Dropdown) edited() {
+	txt := d.Editor.Text()
+	i := slices.Index(d.Options, txt)
+	if i != d.selectedIndex() {
+		d.selected, d.hasSelected = i, i != -1
+		d.changed = true
+	}
+	d.filter = txt
+	d.updateVisible()
+	d.active = txt != "" && len(d.visible) > 0
+	d.highlighted = -1
+	if d.active {
+		d.highlighted = d.visible[0]
+		d.List.ScrollTo(0)
+	}
+}
+
+// choose selects option i on behalf of the user and dismisses the popup.
+func (d *Dropdown) choose(i int) {
+	d.active = false
+	if d.hasSelected && d.selected == i {
+		return
+	}
+	d.setSelected(i)
+	d.changed = true
+}
+
+func (d *Dropdown) setSelected(i int) {
+	d.selected, d.hasSelected = i, true
+	if d.Editable {
+		txt := d.Options[i]
+		d.Editor.SetText(txt)
+		n := utf8.RuneCountInString(txt)
+		d.Editor.SetCaret(n, n)
+		d.filter = ""
+	}
+}
+
+func (d *Dropdown) selectedIndex() int {
+	if !d.hasSelected {
+		return -1
+	}
+	return d.selected
+}
+
+// updateVisible computes the options matching the filter. An option
+// matches if it contains the filter text, ignoring case.
+func (d *Dropdown) updateVisible() {
+	d.visible = d.visible[:0]
+	filter := strings.ToLower(d.filter)
+	for i, o := range d.Options {
+		if filter == "" || strings.Contains(strings.ToLower(o), filter) {
+			d.visible = append(d.visible, i)
+		}
+	}
+	if d.hasSelected && d.selected >= len(d.Options) {
+		d.hasSelected = false
+	}
+}
+
+func (d *Dropdown) focusTag() event.Tag {
+	if d.Editable {
+		return &d.Editor
+	}
+	return d
+}
+
+// Open displays the list of all options, with the selected option
+// highlighted.
+func (d *Dropdown) Open() {
+	d.active = true
+	d.filter = ""
+	d.updateVisible()
+	d.hovered = -1
+	d.highlighted = d.selectedIndex()
+	if d.highlighted == -1 && len(d.visible) > 0 {
+		d.highlighted = d.visible[0]
+	}
+	d.reveal = true
+}
+
+// Dismiss hides the list of options.
+func (d *Dropdown) Dismiss() {
+	d.active = false
+}
+
+// Active reports whether the list of options is displayed.
+func (d *Dropdown) Active() bool {
+	return d.active
+}
+
+// Selected returns the index of the selected option, if any.
+func (d *Dropdown) Selected() (int, bool) {
+	return d.selected, d.hasSelected
+}
+
+// Select option i, or clear the selection if i is not the index of an
+// option. Selecting an option of an Editable dropdown replaces the text of
+// its Editor.
+func (d *Dropdown) Select(i int) {
+	if i < 0 || i >= len(d.Options) {
+		d.hasSelected = false
+		return
+	}
+	d.setSelected(i)
+}
+
+// Focused reports whether the field has the keyboard focus.
+func (d *Dropdown) Focused() bool {
+	return d.focused
+}
+
+// Layout the field with field, and the popup list with popup while the
+// dropdown is active. The popup is displayed below the field and is as
+// wide as it; its height is not constrained.
+//
+// The field is described to screen readers as selected while the popup is
+// displayed, and the field of a dropdown that is not Editable is labeled
+// with the selected option.
+func (d *Dropdown) Layout(gtx layout.Context, field, popup layout.Widget) layout.Dimensions {
+	d.update(gtx)
+	if d.Editable {
+		d.Editor.SingleLine = true
+	}
+	m := op.Record(gtx.Ops)
+	dims := field(gtx)
+	call := m.Stop()
+	d.fieldSize = dims.Size
+	area := clip.Rect{Max: dims.Size}.Push(gtx.Ops)
+	semantic.EnabledOp(gtx.Enabled()).Add(gtx.Ops)
+	semantic.SelectedOp(d.active).Add(gtx.Ops)
+	if !d.Editable {
+		semantic.Button.Add(gtx.Ops)
+		if i, ok := d.Selected(); ok {
+			semantic.LabelOp(d.Options[i]).Add(gtx.Ops)
+		}
+		event.Op(gtx.Ops, d)
+	}
+	d.click.Add(gtx.Ops)
+	call.Add(gtx.Ops)
+	area.Pop()
+	if d.active {
+		d.layoutPopup(gtx, popup)
+	}
+	return dims
+}
+
+func (d *Dropdown) layoutPopup(gtx layout.Context, popup layout.Widget) {
+	const inf = 1e6
+	m := op.Record(gtx.Ops)
+	// Catch presses outside the popup.
+	scrim := clip.Rect{Min: image.Pt(-inf, -inf), Max: image.Pt(inf, inf)}.Push(gtx.Ops)
+	d.dismiss.Add(gtx.Ops)
+	scrim.Pop()
+	op.Offset(image.Pt(0, d.fieldSize.Y)).Add(gtx.Ops)
+	gtx.Constraints = layout.Constraints{
+		Min: image.Pt(d.fieldSize.X, 0),
+		Max: image.Pt(d.fieldSize.X, inf),
+	}
+	popup(gtx)
+	op.Defer(gtx.Ops, m.Stop())
+}
+
+// LayoutOptions lays out the visible options with w, in a list laid out by
+// list, such as the Layout method of a list style that draws scroll bars.
+// It is meant to be called by the popup of Layout.
+func (d *Dropdown) LayoutOptions(gtx layout.Context, list func(gtx layout.Context, n int, w layout.ListElement) layout.Dimensions, w DropdownElement) layout.Dimensions {
+	d.List.Axis = layout.Vertical
+	if d.reveal {
+		d.reveal = false
+		if j := slices.Index(d.visible, d.highlighted); j != -1 {
+			scrollIntoView(&d.List.List, j, d.viewSize)
+		}
+	}
+	if n := len(d.Options); len(d.options) < n {
+		d.options = append(d.options, make([]gesture.Click, n-len(d.options))...)
+	}
+	dims := list(gtx, len(d.visible), func(gtx layout.Context, j int) layout.Dimensions {
+		return d.layoutOption(gtx, d.visible[j], w)
+	})
+	d.viewSize = dims.Size.Y
+	return dims
+}
+
+func (d *Dropdown) layoutOption(gtx layout.Context, i int, w DropdownElement) layout.Dimensions {
+	selected := d.hasSelected && d.selected == i
+	m := op.Record(gtx.Ops)
+	dims := w(gtx, DropdownOption{
+		Index:       i,
+		Highlighted: d.highlighted == i,
+		Selected:    selected,
+	})
+	c := m.Stop()
+	defer clip.Rect{Max: dims.Size}.Push(gtx.Ops).Pop()
+	d.options[i].Add(gtx.Ops)
+	semantic.SelectedOp(selected).Add(gtx.Ops)
+	c.Add(gtx.Ops)
+	return dims
+}
@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package widget
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	"gioui.org/f32"
+	"gioui.org/font"
+	"gioui.org/font/gofont"
+	"gioui.org/io/input"
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/text"
+)
+
+func TestDropdown(t *testing.T) {
+	d := &Dropdown{
+		Options: []string{"Apple", "Banana", "Blueberry", "Cherry"},
+	}
+	r := new(input.Router)
+	gtx := layout.Context{
+		Ops:         new(op.Ops),
+		Constraints: layout.Exact(image.Pt(100, 20)),
+		Source:      r.Source(),
+		Now:         time.Unix(0, 0),
+	}
+	cache := text.NewShaper(text.NoSystemFonts(), text.WithCollection(gofont.Collection()))
+	var laidOut []int
+	frame := func() {
+		laidOut = laidOut[:0]
+		gtx.Ops.Reset()
+		d.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			if d.Editable {
+				d.Editor.Layout(gtx, cache, font.Font{}, 10, op.CallOp{}, op.CallOp{})
+			}
+			return layout.Dimensions{Size: gtx.Constraints.Max}
+		}, func(gtx layout.Context) layout.Dimensions {
+			return d.LayoutOptions(gtx, d.List.List.Layout, func(gtx layout.Context, opt DropdownOption) layout.Dimensions {
+				laidOut = append(laidOut, opt.Index)
+				return layout.Dimensions{Size: image.Pt(gtx.Constraints.Max.X, 10)}
+			})
+		})
+		r.Frame(gtx.Ops)
+	}
+	var changed bool
+	update := func() {
+		changed = d.Update(gtx)
+		frame()
+	}
+	click := func(pos f32.Point) {
+		r.Queue(
+			pointer.Event{Kind: pointer.Press, Buttons: pointer.ButtonPrimary, Position: pos},
+			pointer.Event{Kind: pointer.Release, Position: pos},
+		)
+		update()
+	}
+	keys := func(names ...key.Name) {
+		for _, n := range names {
+			r.Queue(key.Event{Name: n, State: key.Press})
+		}
+		update()
+	}
+	frame()
+	click(f32.Pt(5, 5))
+	if changed || !d.Active() {
+		t.Fatal("click didn't open the popup")
+	}
+	if !d.Focused() {
+		t.Error("click didn't focus the dropdown")
+	}
+	// The options process events from the second frame on.
+	frame()
+	if len(laidOut) != 4 {
+		t.Errorf("laid out options %v", laidOut)
+	}
+	click(f32.Pt(5, 20+15))
+	if i, ok := d.Selected(); !changed || !ok || i != 1 {
+		t.Errorf("got selection %d after clicking option, want 1", i)
+	}
+	if d.Active() {
+		t.Error("choosing an option didn't dismiss the popup")
+	}
+
+	keys(key.NameDownArrow)
+	if !d.Active() {
+		t.Fatal("down arrow didn't open the popup")
+	}
+	keys(key.NameDownArrow, key.NameDownArrow, key.NameReturn)
+	if i, _ := d.Selected(); !changed || i != 3 {
+		t.Errorf("got selection %d after moving down, want 3", i)
+	}
+	keys(key.NameSpace)
+	keys(key.NameEscape)
+	if i, _ := d.Selected(); changed || d.Active() || i != 3 {
+		t.Errorf("escape didn't dismiss the popup")
+	}
+
+	typeahead := func(txt string, want int) {
+		t.Helper()
+		r.Queue(key.EditEvent{Text: txt})
+		update()
+		if i, _ := d.Selected(); i != want {
+			t.Errorf("got selection %d after typing %q, want %d", i, txt, want)
+		}
+	}
+	typeahead("b", 1)
+	// Repeating a character cycles through the options.
+	typeahead("b", 2)
+	typeahead("b", 1)
+	gtx.Now = gtx.Now.Add(2 * typeaheadTimeout)
+	typeahead("c", 3)
+	gtx.Now = gtx.Now.Add(2 * typeaheadTimeout)
+	typeahead("b", 1)
+	typeahead("l", 2)
+
+	// The field describes the selection and whether the popup is displayed.
+	field := func() input.SemanticDesc {
+		return r.AppendSemantics(nil)[0].Children[0].Desc
+	}
+	if n := field(); n.Label != "Blueberry" || n.Selected {
+		t.Errorf("got field semantics %+v", n)
+	}
+	keys(key.NameDownArrow)
+	if n := field(); !n.Selected {
+		t.Errorf("got field semantics %+v with the popup displayed", n)
+	}
+	keys(key.NameEscape)
+	d.Select(len(d.Options))
+	if _, ok := d.Selected(); ok {
+		t.Error("selecting a missing option didn't clear the selection")
+	}
+
+	// An editable dropdown filters the options by the text.
+	d = &Dropdown{
+		Options:  d.Options,
+		Editable: true,
+	}
+	frame()
+	gtx.Execute(key.FocusCmd{Tag: &d.Editor})
+	frame()
+	r.Queue(key.EditEvent{Text: "rr"})
+	update()
+	if !d.Active() {
+		t.Fatal("typing didn't open the popup")
+	}
+	frame()
+	if len(laidOut) != 2 || laidOut[0] != 2 || laidOut[1] != 3 {
+		t.Errorf("laid out options %v, want [2 3]", laidOut)
+	}
+	keys(key.NameDownArrow, key.NameReturn)
+	if i, ok := d.Selected(); !changed || !ok || i != 3 {
+		t.Errorf("got selection %d, want 3", i)
+	}
+	if got := d.Editor.Text(); got != "Cherry" {
+		t.Errorf("got text %q after choosing option", got)
+	}
+	r.Queue(key.EditEvent{Text: "!", Range: key.Range{Start: 6, End: 6}})
+	update()
+	if _, ok := d.Selected(); !changed || ok {
+		t.Error("editing the text didn't clear the selection")
+	}
+	d.Select(len(d.Options))
+	if _, ok := d.Selected(); ok || d.Editor.Text() != "Cherry!" {
+		t.Errorf("selecting a missing option changed the text to %q", d.Editor.Text())
+	}
+}
@@ -4,9 +4,11 @@ package widget
 
 import (
 	"bufio"
+	"cmp"
 	"image"
 	"io"
 	"math"
+	"slices"
 	"strings"
 	"time"
 	"unicode"
@@ -66,8 +68,41 @@ type Editor struct {
 	Filter string
 	// WrapPolicy configures how displayed text will be broken into lines.
 	WrapPolicy text.WrapPolicy
-
-	buffer *editBuffer
+	// LetterSpacing is extra space added after every glyph cluster.
+	LetterSpacing unit.Sp
+	// WordSpacing is extra space added to every word separator.
+	WordSpacing unit.Sp
+	// Decoration configures lines drawn alongside the text, such as underlines.
+	Decoration text.Decoration
+	// LargeText optimizes the editor for very large texts. The text is stored
+	// in chunks so that edits don't move the entire text in memory, and only
+	// the paragraphs that are displayed or navigated through are shaped.
+	// Until a paragraph is shaped, it is assumed to occupy a single line, so
+	// line numbers and the text dimensions are estimates that are refined as
+	// the text is displayed. SingleLine and masked editors are always shaped
+	// in full. For editors with a source set by SetSource, LargeText only
+	// affects the layout.
+	LargeText bool
+	// MaxHistory limits the number of undo steps kept by the editor. Zero
+	// means no limit.
+	MaxHistory int
+	// Transform, if set, is called with every edit of the text made through
+	// user input or methods such as Insert and SetText, after Filter and
+	// MaxLen are applied. It returns the edit to make instead, which may
+	// replace a different range of the text, or false to reject the edit.
+	// Transform can thereby validate input, reformat it, or apply an input
+	// mask. Its result is subject to SingleLine, Filter and MaxLen as well.
+	// The caret is placed after the replacement text, and input methods are
+	// informed of the result. Undo and redo are not transformed.
+	Transform func(ed Edit) (Edit, bool)
+	// MenuItems, if set, is called with the default items of the context
+	// menu when it opens, and returns the items to display. It may add,
+	// remove or replace items.
+	MenuItems func(items []MenuItem) []MenuItem
+
+	buffer TextSource
+	// source tracks whether buffer was provided by SetSource.
+	source bool
 	// scratch is a byte buffer that is reused to efficiently read portions of text
 	// from the textView.
 	scratch    []byte
@@ -87,12 +122,33 @@ type Editor struct {
 
 	clicker gesture.Click
 
+	// columnSelect tracks whether the current drag selects a column of
+	// text, starting at the document position columnStart.
+	columnSelect bool
+	columnStart  image.Point
+
 	// history contains undo history.
 	history []modification
 	// nextHistoryIdx is the index within the history of the next modification. This
 	// is only not len(history) immediately after undo operations occur. It is framed as the "next" value
 	// to make the zero value consistent.
 	nextHistoryIdx int
+	// historyGroup identifies the modifications that are undone and redone
+	// together. It is incremented for every modification, except while
+	// grouping is positive.
+	historyGroup int
+	// grouping counts the undo groups in progress.
+	grouping int
+	// historyExternal is the count of external changes to the source the
+	// history is valid for.
+	historyExternal int
+
+	// search is the state of the search set by Search.
+	search editorSearch
+	// spell is the spell checking state set by SetSpellChecker.
+	spell editorSpell
+	// menu is the context menu opened by secondary clicks.
+	menu ContextMenu
 
 	pending []EditorEvent
 }
@@ -165,6 +221,13 @@ type EditorEvent interface {
 	isEditorEvent()
 }
 
+// Edit is a change to the text of an Editor, replacing the runes in
+// [Start, End) with Text.
+type Edit struct {
+	Start, End int
+	Text       string
+}
+
 // A ChangeEvent is generated for every user change to the text.
 type ChangeEvent struct{}
 
@@ -194,9 +257,10 @@ func (e *Editor) processEvents(gtx layout.Context) (ev EditorEvent, ok bool) {
 		return out, true
 	}
 	selStart, selEnd := e.Selection()
+	carets := len(e.text.carets)
 	defer func() {
 		afterSelStart, afterSelEnd := e.Selection()
-		if selStart != afterSelStart || selEnd != afterSelEnd {
+		if selStart != afterSelStart || selEnd != afterSelEnd || carets != len(e.text.carets) {
 			if ok {
 				e.pending = append(e.pending, SelectEvent{})
 			} else {
@@ -206,6 +270,10 @@ func (e *Editor) processEvents(gtx layout.Context) (ev EditorEvent, ok bool) {
 		}
 	}()
 
+	ev, ok = e.processMenu(gtx)
+	if ok {
+		return ev, ok
+	}
 	ev, ok = e.processPointer(gtx)
 	if ok {
 		return ev, ok
@@ -217,6 +285,73 @@ func (e *Editor) processEvents(gtx layout.Context) (ev EditorEvent, ok bool) {
 	return nil, false
 }
 
+// processMenu handles the context menu, and reports a ChangeEvent if an
+// item modified the text.
+func (e *Editor) processMenu(gtx layout.Context) (EditorEvent, bool) {
+	revision := e.text.revision
+	if e.menu.Update(gtx) {
+		e.openMenu(gtx)
+	}
+	if e.text.revision != revision {
+		return ChangeEvent{}, true
+	}
+	return nil, false
+}
+
+// openMenu prepares the context menu opened by a secondary click.
+func (e *Editor) openMenu(gtx layout.Context) {
+	gtx.Execute(key.FocusCmd{Tag: e})
+	// Move the caret to the click, unless it is within the selection.
+	off := e.text.coordRune(e.menu.Position())
+	if start, end := e.text.Selection(); off < min(start, end) || off > max(start, end) || start == end {
+		e.text.clearCarets()
+		e.text.SetCaret(off, off)
+	}
+	hasSelection := e.SelectionLen() > 0 || len(e.text.carets) > 0
+	shortcut := func(name key.Name) string {
+		return shortcutLabel(key.ModShortcut, name)
+	}
+	items := []MenuItem{
+		{Label: "Undo", Shortcut: shortcut("Z"), Disabled: e.ReadOnly || !e.CanUndo(), Action: func(gtx layout.Context) {
+			e.Undo()
+		}},
+		{Label: "Redo", Shortcut: shortcutLabel(key.ModShortcut|key.ModShift, "Z"), Disabled: e.ReadOnly || !e.CanRedo(), Action: func(gtx layout.Context) {
+			e.Redo()
+		}},
+		{},
+		{Label: "Cut", Shortcut: shortcut("X"), Disabled: e.ReadOnly || !hasSelection, Action: func(gtx layout.Context) {
+			if text := e.selectedTexts(); text != "" {
+				writeClipboard(gtx, text)
+				e.deleteSelections()
+			}
+		}},
+		{Label: "Copy", Shortcut: shortcut("C"), Disabled: !hasSelection, Action: func(gtx layout.Context) {
+			if text := e.selectedTexts(); text != "" {
+				writeClipboard(gtx, text)
+			}
+		}},
+		{Label: "Paste", Shortcut: shortcut("V"), Disabled: e.ReadOnly, Action: func(gtx layout.Context) {
+			gtx.Execute(clipboard.ReadCmd{Tag: e})
+		}},
+		{},
+		{Label: "Select All", Shortcut: shortcut("A"), Action: func(gtx layout.Context) {
+			e.text.clearCarets()
+			e.text.SetCaret(0, e.text.Len())
+		}},
+	}
+	if e.MenuItems != nil {
+		items = e.MenuItems(items)
+	}
+	e.menu.Menu.Items = items
+}
+
+// ContextMenu returns the context menu opened by secondary clicks on the
+// editor. Display it by calling its LayoutMenu method after laying out the
+// editor. Items can be customized with MenuItems.
+func (e *Editor) ContextMenu() *ContextMenu {
+	return &e.menu
+}
+
 func (e *Editor) processPointer(gtx layout.Context) (EditorEvent, bool) {
 	sbounds := e.text.ScrollBounds()
 	var smin, smax int
@@ -284,10 +419,17 @@ func (e *Editor) processPointerEvent(gtx layout.Context, ev event.Event) (Editor
 			evt.Kind == gesture.KindClick && evt.Source != pointer.Mouse:
 			prevCaretPos, _ := e.text.Selection()
 			e.blinkStart = gtx.Now
-			e.text.MoveCoord(image.Point{
+			pos := image.Point{
 				X: int(math.Round(float64(evt.Position.X))),
 				Y: int(math.Round(float64(evt.Position.Y))),
-			})
+			}
+			if evt.Modifiers == key.ModShortcut {
+				// Keep the current caret and add another.
+				e.text.carets = append(e.text.carets, e.text.caret)
+			} else {
+				e.text.clearCarets()
+			}
+			e.text.MoveCoord(pos)
 			gtx.Execute(key.FocusCmd{Tag: e})
 			if !e.ReadOnly {
 				gtx.Execute(key.SoftKeyboardCmd{Show: true})
@@ -307,6 +449,8 @@ func (e *Editor) processPointerEvent(gtx layout.Context, ev event.Event) (Editor
 				e.text.ClearSelection()
 			}
 			e.dragging = true
+			e.columnSelect = evt.Modifiers == key.ModAlt
+			e.columnStart = pos.Add(e.text.ScrollOff())
 
 			// Process multi-clicks.
 			switch {
@@ -319,6 +463,7 @@ func (e *Editor) processPointerEvent(gtx layout.Context, ev event.Event) (Editor
 				e.text.MoveLineEnd(selectionExtend)
 				e.dragging = false
 			}
+			e.text.mergeCarets()
 		}
 	case pointer.Event:
 		release := false
@@ -329,10 +474,16 @@ func (e *Editor) processPointerEvent(gtx layout.Context, ev event.Event) (Editor
 		case evt.Kind == pointer.Drag && evt.Source == pointer.Mouse:
 			if e.dragging {
 				e.blinkStart = gtx.Now
-				e.text.MoveCoord(image.Point{
+				pos := image.Point{
 					X: int(math.Round(float64(evt.Position.X))),
 					Y: int(math.Round(float64(evt.Position.Y))),
-				})
+				}
+				if e.columnSelect {
+					e.text.selectColumns(e.columnStart, pos.Add(e.text.ScrollOff()))
+				} else {
+					e.text.MoveCoord(pos)
+					e.text.mergeCarets()
+				}
 				e.scrollCaret = true
 
 				if release {
@@ -357,8 +508,9 @@ func (e *Editor) processKey(gtx layout.Context) (EditorEvent, bool) {
 		return ChangeEvent{}, true
 	}
 	caret, _ := e.text.Selection()
-	atBeginning := caret == 0
-	atEnd := caret == e.text.Len()
+	multi := len(e.text.carets) > 0
+	atBeginning := caret == 0 && !multi
+	atEnd := caret == e.text.Len() && !multi
 	if gtx.Locale.Direction.Progression() != system.FromOrigin {
 		atEnd, atBeginning = atBeginning, atEnd
 	}
@@ -373,6 +525,8 @@ func (e *Editor) processKey(gtx layout.Context) (EditorEvent, bool) {
 		key.Filter{Focus: e, Name: "V", Required: key.ModShortcut},
 		key.Filter{Focus: e, Name: "X", Required: key.ModShortcut},
 		key.Filter{Focus: e, Name: "A", Required: key.ModShortcut},
+		key.Filter{Focus: e, Name: "D", Required: key.ModShortcut},
+		condFilter(multi, key.Filter{Focus: e, Name: key.NameEscape}),
 
 		key.Filter{Focus: e, Name: key.NameDeleteBackward, Optional: key.ModShortcutAlt | key.ModShift},
 		key.Filter{Focus: e, Name: key.NameDeleteForward, Optional: key.ModShortcutAlt | key.ModShift},
@@ -440,7 +594,14 @@ func (e *Editor) processKey(gtx layout.Context) (EditorEvent, bool) {
 			case e.SingleLine:
 				s = strings.ReplaceAll(s, "\n", " ")
 			}
-			moves += e.replace(ke.Range.Start, ke.Range.End, s, true)
+			if start, end := e.text.caret.bounds(); multi && ke.Range.Start == start && ke.Range.End == end {
+				// Plain typing applies at every caret.
+				e.Insert(s)
+				moves += utf8.RuneCountInString(s)
+			} else {
+				_, _, end := e.replace(ke.Range.Start, ke.Range.End, s, true)
+				moves += end - min(ke.Range.Start, ke.Range.End)
+			}
 			adjust += utf8.RuneCountInString(ke.Text) - moves
 			// Reset caret xoff.
 			e.text.MoveCaret(0, 0)
@@ -461,7 +622,7 @@ func (e *Editor) processKey(gtx layout.Context) (EditorEvent, bool) {
 			e.scroller.Stop()
 			content, err := io.ReadAll(ke.Open())
 			if err == nil {
-				if e.Insert(string(content)) != 0 {
+				if e.paste(string(content)) != 0 {
 					return ChangeEvent{}, true
 				}
 			}
@@ -500,18 +661,21 @@ func (e *Editor) command(gtx layout.Context, k key.Event) (EditorEvent, bool) {
 			}
 		// Copy or Cut selection -- ignored if nothing selected.
 		case "C", "X":
-			e.scratch = e.text.SelectedText(e.scratch)
-			if text := string(e.scratch); text != "" {
-				gtx.Execute(clipboard.WriteCmd{Type: "application/text", Data: io.NopCloser(strings.NewReader(text))})
+			if text := e.selectedTexts(); text != "" {
+				writeClipboard(gtx, text)
 				if k.Name == "X" && !e.ReadOnly {
-					if e.Delete(1) != 0 {
+					if e.deleteSelections() != 0 {
 						return ChangeEvent{}, true
 					}
 				}
 			}
 		// Select all
 		case "A":
+			e.text.clearCarets()
 			e.text.SetCaret(0, e.text.Len())
+		// Select the next occurrence of the selection.
+		case "D":
+			e.AddNextMatch()
 		case "Z":
 			if !e.ReadOnly {
 				if k.Modifiers.Contain(key.ModShift) {
@@ -525,9 +689,9 @@ func (e *Editor) command(gtx layout.Context, k key.Event) (EditorEvent, bool) {
 				}
 			}
 		case key.NameHome:
-			e.text.MoveTextStart(selAct)
+			e.text.eachCaret(func() { e.text.MoveTextStart(selAct) })
 		case key.NameEnd:
-			e.text.MoveTextEnd(selAct)
+			e.text.eachCaret(func() { e.text.MoveTextEnd(selAct) })
 		}
 		return nil, false
 	}
@@ -562,36 +726,42 @@ func (e *Editor) command(gtx layout.Context, k key.Event) (EditorEvent, bool) {
 				}
 			}
 		}
+	case key.NameEscape:
+		e.text.clearCarets()
 	case key.NameUpArrow:
-		e.text.MoveLines(-1, selAct)
+		e.text.eachCaret(func() { e.text.MoveLines(-1, selAct) })
 	case key.NameDownArrow:
-		e.text.MoveLines(+1, selAct)
+		e.text.eachCaret(func() { e.text.MoveLines(+1, selAct) })
 	case key.NameLeftArrow:
-		if moveByWord {
-			e.text.MoveWord(-1*direction, selAct)
-		} else {
-			if selAct == selectionClear {
-				e.text.ClearSelection()
+		e.text.eachCaret(func() {
+			if moveByWord {
+				e.text.MoveWord(-1*direction, selAct)
+			} else {
+				if selAct == selectionClear {
+					e.text.ClearSelection()
+				}
+				e.text.MoveCaret(-1*direction, -1*direction*int(selAct))
 			}
-			e.text.MoveCaret(-1*direction, -1*direction*int(selAct))
-		}
+		})
 	case key.NameRightArrow:
-		if moveByWord {
-			e.text.MoveWord(1*direction, selAct)
-		} else {
-			if selAct == selectionClear {
-				e.text.ClearSelection()
+		e.text.eachCaret(func() {
+			if moveByWord {
+				e.text.MoveWord(1*direction, selAct)
+			} else {
+				if selAct == selectionClear {
+					e.text.ClearSelection()
+				}
+				e.text.MoveCaret(1*direction, int(selAct)*direction)
 			}
-			e.text.MoveCaret(1*direction, int(selAct)*direction)
-		}
+		})
 	case key.NamePageUp:
-		e.text.MovePages(-1, selAct)
+		e.text.eachCaret(func() { e.text.MovePages(-1, selAct) })
 	case key.NamePageDown:
-		e.text.MovePages(+1, selAct)
+		e.text.eachCaret(func() { e.text.MovePages(+1, selAct) })
 	case key.NameHome:
-		e.text.MoveLineStart(selAct)
+		e.text.eachCaret(func() { e.text.MoveLineStart(selAct) })
 	case key.NameEnd:
-		e.text.MoveLineEnd(selAct)
+		e.text.eachCaret(func() { e.text.MoveLineEnd(selAct) })
 	}
 	return nil, false
 }
@@ -600,16 +770,32 @@ func (e *Editor) command(gtx layout.Context, k key.Event) (EditorEvent, bool) {
 // text state. It ensures that the underlying text widget is both ready to use
 // and has its fields synced with the editor.
 func (e *Editor) initBuffer() {
-	if e.buffer == nil {
-		e.buffer = new(editBuffer)
-		e.text.SetSource(e.buffer)
+	if _, chunked := e.buffer.(*chunkBuffer); e.buffer == nil || !e.source && chunked != e.LargeText {
+		var buf TextSource = new(editBuffer)
+		if e.LargeText {
+			buf = new(chunkBuffer)
+		}
+		if e.buffer != nil {
+			// Move the contents to the new buffer, keeping the caret.
+			start, end := e.text.Selection()
+			buf.ReplaceRunes(0, 0, string(e.text.Text(nil)))
+			e.text.SetSource(buf)
+			e.text.SetCaret(start, end)
+		} else {
+			e.text.SetSource(buf)
+		}
+		e.buffer = buf
 	}
+	e.text.Incremental = e.LargeText
 	e.text.Alignment = e.Alignment
 	e.text.LineHeight = e.LineHeight
 	e.text.LineHeightScale = e.LineHeightScale
 	e.text.SingleLine = e.SingleLine
 	e.text.Mask = e.Mask
 	e.text.WrapPolicy = e.WrapPolicy
+	e.text.LetterSpacing = e.LetterSpacing
+	e.text.WordSpacing = e.WordSpacing
+	e.text.Decoration = e.Decoration
 	e.text.DisableSpaceTrim = true
 }
 
@@ -654,7 +840,7 @@ func (e *Editor) Layout(gtx layout.Context, lt *text.Shaper, font font.Font, siz
 	}
 
 	e.text.Layout(gtx, lt, font, size)
-	return e.layout(gtx, textMaterial, selectMaterial)
+	return e.layout(gtx, textMaterial, selectMaterial, op.CallOp{})
 }
 
 // updateSnippet queues a key.SnippetCmd if the snippet content or position
@@ -700,7 +886,7 @@ func (e *Editor) updateSnippet(gtx layout.Context, start, end int) {
 	gtx.Execute(key.SnippetCmd{Tag: e, Snippet: newSnip})
 }
 
-func (e *Editor) layout(gtx layout.Context, textMaterial, selectMaterial op.CallOp) layout.Dimensions {
+func (e *Editor) layout(gtx layout.Context, textMaterial, selectMaterial, matchMaterial op.CallOp) layout.Dimensions {
 	// Adjust scrolling for new viewport and layout.
 	e.text.ScrollRel(0, 0)
 
@@ -719,6 +905,7 @@ func (e *Editor) layout(gtx layout.Context, textMaterial, selectMaterial op.Call
 
 	e.clicker.Add(gtx.Ops)
 	e.dragger.Add(gtx.Ops)
+	e.menu.Add(gtx.Ops)
 	e.showCaret = false
 	if gtx.Focused(e) {
 		now := gtx.Now
@@ -733,6 +920,9 @@ func (e *Editor) layout(gtx layout.Context, textMaterial, selectMaterial op.Call
 	}
 	semantic.Editor.Add(gtx.Ops)
 	if e.Len() > 0 {
+		if matchMaterial != (op.CallOp{}) {
+			e.paintMatches(gtx, matchMaterial)
+		}
 		e.paintSelection(gtx, selectMaterial)
 		e.paintText(gtx, textMaterial)
 	}
@@ -782,6 +972,29 @@ func (e *Editor) Text() string {
 	return string(e.scratch)
 }
 
+// SetSource makes the editor display and edit src in place of its own text
+// buffer, which avoids copying texts that are already stored elsewhere, such as
+// memory-mapped files or collaboratively edited documents. Changes to src made
+// outside of the editor must be reported by src.Changed; the editor then
+// reshapes the text and reports a ChangeEvent. The caret and selection are
+// kept, but the undo history is discarded, by SetSource and by every change
+// reported by src.Changed, because its steps no longer apply to the text.
+//
+// Setting a nil src restores an empty buffer owned by the editor.
+func (e *Editor) SetSource(src TextSource) {
+	e.initBuffer()
+	start, end := e.text.Selection()
+	e.buffer, e.source = src, src != nil
+	e.ClearHistory()
+	e.text.clearCarets()
+	if src == nil {
+		e.initBuffer()
+	} else {
+		e.text.SetSource(src)
+	}
+	e.text.SetCaret(start, end)
+}
+
 func (e *Editor) SetText(s string) {
 	e.initBuffer()
 	if e.SingleLine {
@@ -789,6 +1002,7 @@ func (e *Editor) SetText(s string) {
 	}
 	e.replace(0, e.text.Len(), s, true)
 	// Reset xoff and move the caret to the beginning.
+	e.text.clearCarets()
 	e.SetCaret(0, 0)
 }
 
@@ -809,13 +1023,20 @@ func (e *Editor) CaretCoords() f32.Point {
 // direction to delete: positive is forward, negative is backward.
 //
 // If there is a selection, it is deleted and counts as a single grapheme
-// cluster.
+// cluster. With multiple carets, runes are deleted at every caret.
 func (e *Editor) Delete(graphemeClusters int) (deletedRunes int) {
 	e.initBuffer()
 	if graphemeClusters == 0 {
 		return 0
 	}
+	e.eachCaret(func() {
+		deletedRunes += e.deleteAt(graphemeClusters)
+	})
+	return deletedRunes
+}
 
+// deleteAt is like Delete for the primary caret only.
+func (e *Editor) deleteAt(graphemeClusters int) int {
 	start, end := e.text.Selection()
 	if start != end {
 		graphemeClusters -= sign(graphemeClusters)
@@ -825,28 +1046,36 @@ func (e *Editor) Delete(graphemeClusters int) (deletedRunes int) {
 	e.text.MoveCaret(0, graphemeClusters)
 	// Get the new rune offsets of the selection.
 	start, end = e.text.Selection()
-	e.replace(start, end, "", true)
+	_, deleted, _ := e.replace(start, end, "", true)
 	// Reset xoff.
 	e.text.MoveCaret(0, 0)
 	e.ClearSelection()
-	return end - start
+	return deleted
 }
 
+// Insert inserts s at the caret, replacing the selection if any. With
+// multiple carets, s is inserted at every caret and the total number of
+// runes inserted is returned.
 func (e *Editor) Insert(s string) (insertedRunes int) {
 	e.initBuffer()
 	if e.SingleLine {
 		s = strings.ReplaceAll(s, "\n", " ")
 	}
+	e.eachCaret(func() {
+		insertedRunes += e.insertAt(s)
+	})
+	return insertedRunes
+}
+
+// insertAt is like Insert for the primary caret only.
+func (e *Editor) insertAt(s string) int {
 	start, end := e.text.Selection()
-	moves := e.replace(start, end, s, true)
-	if end < start {
-		start = end
-	}
+	inserted, _, caret := e.replace(start, end, s, true)
 	// Reset xoff.
 	e.text.MoveCaret(0, 0)
-	e.SetCaret(start+moves, start+moves)
+	e.SetCaret(caret, caret)
 	e.scrollCaret = true
-	return moves
+	return inserted
 }
 
 // modification represents a change to the contents of the editor buffer.
@@ -862,46 +1091,168 @@ type modification struct {
 	// ReverseContent is the data inserted at StartRune to
 	// apply this operation. It overwrites len([]rune(ApplyContent)) runes.
 	ReverseContent string
+	// group identifies the modifications undone and redone together.
+	group int
+}
+
+// eachCaret calls f for every caret, and records the modifications made by
+// f as a single undo step.
+func (e *Editor) eachCaret(f func()) {
+	e.BeginUndoGroup()
+	defer e.EndUndoGroup()
+	e.text.eachCaret(f)
+}
+
+// Undo reverts the last undo step and reports whether there was one. An
+// undo step is a single modification of the text, or the modifications
+// grouped by BeginUndoGroup and EndUndoGroup.
+func (e *Editor) Undo() bool {
+	_, ok := e.undo()
+	return ok
+}
+
+// Redo reapplies the last undo step reverted by Undo and reports whether
+// there was one.
+func (e *Editor) Redo() bool {
+	_, ok := e.redo()
+	return ok
+}
+
+// CanUndo reports whether there is an undo step to revert.
+func (e *Editor) CanUndo() bool {
+	e.initBuffer()
+	e.validateHistory()
+	return e.nextHistoryIdx > 0
+}
+
+// CanRedo reports whether there is a reverted undo step to reapply.
+func (e *Editor) CanRedo() bool {
+	e.initBuffer()
+	e.validateHistory()
+	return e.nextHistoryIdx < len(e.history)
+}
+
+// BeginUndoGroup starts grouping the following modifications of the text
+// into a single undo step, until the matching call to EndUndoGroup. Groups
+// nest; the outermost group forms the undo step.
+func (e *Editor) BeginUndoGroup() {
+	if e.grouping == 0 {
+		e.historyGroup++
+	}
+	e.grouping++
+}
+
+// EndUndoGroup ends the group started by the matching call to
+// BeginUndoGroup.
+func (e *Editor) EndUndoGroup() {
+	if e.grouping > 0 {
+		e.grouping--
+	}
+}
+
+// ClearHistory discards every undo step, for example after the text has
+// been saved.
+func (e *Editor) ClearHistory() {
+	e.history = e.history[:0]
+	e.nextHistoryIdx = 0
+}
+
+// validateHistory discards the undo steps if the source has changed outside
+// the editor, because they no longer apply to its text.
+func (e *Editor) validateHistory() {
+	e.text.refresh()
+	if e.text.external != e.historyExternal {
+		e.historyExternal = e.text.external
+		e.ClearHistory()
+	}
+}
+
+// trimHistory discards the oldest undo steps beyond MaxHistory.
+func (e *Editor) trimHistory() {
+	if e.MaxHistory <= 0 {
+		return
+	}
+	steps := 0
+	for i := len(e.history) - 1; i >= 0; i-- {
+		if i+1 < len(e.history) && e.history[i].group == e.history[i+1].group {
+			continue
+		}
+		steps++
+		if steps > e.MaxHistory {
+			n := copy(e.history, e.history[i+1:])
+			e.history = e.history[:n]
+			e.nextHistoryIdx = max(e.nextHistoryIdx-(i+1), 0)
+			return
+		}
+	}
 }
 
-// undo applies the modification at e.history[e.historyIdx] and decrements
-// e.historyIdx.
+// undo applies the modifications of the group at e.history[e.historyIdx]
+// in reverse and decrements e.historyIdx past them. A caret is placed at
+// every reverted modification.
 func (e *Editor) undo() (EditorEvent, bool) {
 	e.initBuffer()
+	e.validateHistory()
 	if len(e.history) < 1 || e.nextHistoryIdx == 0 {
 		return nil, false
 	}
-	mod := e.history[e.nextHistoryIdx-1]
-	replaceEnd := mod.StartRune + utf8.RuneCountInString(mod.ApplyContent)
-	e.replace(mod.StartRune, replaceEnd, mod.ReverseContent, false)
-	caretEnd := mod.StartRune + utf8.RuneCountInString(mod.ReverseContent)
-	e.SetCaret(caretEnd, mod.StartRune)
-	e.nextHistoryIdx--
+	e.text.clearCarets()
+	group := e.history[e.nextHistoryIdx-1].group
+	for i := 0; e.nextHistoryIdx > 0 && e.history[e.nextHistoryIdx-1].group == group; i++ {
+		mod := e.history[e.nextHistoryIdx-1]
+		replaceEnd := mod.StartRune + utf8.RuneCountInString(mod.ApplyContent)
+		e.replace(mod.StartRune, replaceEnd, mod.ReverseContent, false)
+		caretEnd := mod.StartRune + utf8.RuneCountInString(mod.ReverseContent)
+		if i == 0 {
+			e.SetCaret(caretEnd, mod.StartRune)
+		} else {
+			e.text.carets = append(e.text.carets, textCaret{start: caretEnd, end: mod.StartRune})
+		}
+		e.nextHistoryIdx--
+	}
+	e.text.mergeCarets()
 	return ChangeEvent{}, true
 }
 
-// redo applies the modification at e.history[e.historyIdx] and increments
-// e.historyIdx.
+// redo applies the modifications of the group at e.history[e.historyIdx]
+// and increments e.historyIdx past them. A caret is placed at every applied
+// modification.
 func (e *Editor) redo() (EditorEvent, bool) {
 	e.initBuffer()
+	e.validateHistory()
 	if len(e.history) < 1 || e.nextHistoryIdx == len(e.history) {
 		return nil, false
 	}
-	mod := e.history[e.nextHistoryIdx]
-	end := mod.StartRune + utf8.RuneCountInString(mod.ReverseContent)
-	e.replace(mod.StartRune, end, mod.ApplyContent, false)
-	caretEnd := mod.StartRune + utf8.RuneCountInString(mod.ApplyContent)
-	e.SetCaret(caretEnd, mod.StartRune)
-	e.nextHistoryIdx++
+	e.text.clearCarets()
+	group := e.history[e.nextHistoryIdx].group
+	for i := 0; e.nextHistoryIdx < len(e.history) && e.history[e.nextHistoryIdx].group == group; i++ {
+		mod := e.history[e.nextHistoryIdx]
+		end := mod.StartRune + utf8.RuneCountInString(mod.ReverseContent)
+		e.replace(mod.StartRune, end, mod.ApplyContent, false)
+		caretEnd := mod.StartRune + utf8.RuneCountInString(mod.ApplyContent)
+		if i == 0 {
+			e.SetCaret(caretEnd, mod.StartRune)
+		} else {
+			e.text.carets = append(e.text.carets, textCaret{start: caretEnd, end: mod.StartRune})
+		}
+		e.nextHistoryIdx++
+	}
+	e.text.mergeCarets()
 	return ChangeEvent{}, true
 }
 
 // replace the text between start and end with s. Indices are in runes.
-// It returns the number of runes inserted.
+// It returns the number of runes inserted and deleted by the edit, after
+// Transform, and the position of the end of the inserted text, where the
+// caret belongs after the edit. A rejected edit inserts and deletes
+// nothing and leaves the caret at start.
 // addHistory controls whether this modification is recorded in the undo
-// history. replace can modify text in positions unrelated to the cursor
-// position.
-func (e *Editor) replace(start, end int, s string, addHistory bool) int {
+// history, and whether it is subject to Transform. replace can modify
+// text in positions unrelated to the cursor position.
+func (e *Editor) replace(start, end int, s string, addHistory bool) (inserted, deleted, caret int) {
+	if addHistory {
+		e.validateHistory()
+	}
 	length := e.text.Len()
 	if start > end {
 		start, end = end, start
@@ -909,21 +1260,20 @@ func (e *Editor) replace(start, end int, s string, addHistory bool) int {
 	start = min(start, length)
 	end = min(end, length)
 	replaceSize := end - start
-	el := e.Len()
-	var sc int
-	idx := 0
-	for idx < len(s) {
-		if e.MaxLen > 0 && el-replaceSize+sc >= e.MaxLen {
-			s = s[:idx]
-			break
+	s = e.constrain(s, replaceSize)
+	if addHistory && e.Transform != nil {
+		ed, ok := e.Transform(Edit{Start: start, End: end, Text: s})
+		if !ok {
+			return 0, 0, start
 		}
-		_, n := utf8.DecodeRuneInString(s[idx:])
-		if e.Filter != "" && !strings.Contains(e.Filter, s[idx:idx+n]) {
-			s = s[:idx] + s[idx+n:]
-			continue
+		start = min(max(ed.Start, 0), length)
+		end = min(max(ed.End, start), length)
+		replaceSize = end - start
+		s = ed.Text
+		if e.SingleLine {
+			s = strings.ReplaceAll(s, "\n", " ")
 		}
-		idx += n
-		sc++
+		s = e.constrain(s, replaceSize)
 	}
 
 	if addHistory {
@@ -937,16 +1287,22 @@ func (e *Editor) replace(start, end int, s string, addHistory bool) int {
 		if e.nextHistoryIdx < len(e.history) {
 			e.history = e.history[:e.nextHistoryIdx]
 		}
+		if e.grouping == 0 {
+			e.historyGroup++
+		}
 		e.history = append(e.history, modification{
 			StartRune:      start,
 			ApplyContent:   s,
 			ReverseContent: string(deleted),
+			group:          e.historyGroup,
 		})
 		e.nextHistoryIdx++
+		e.trimHistory()
 	}
 
-	sc = e.text.Replace(start, end, s)
+	sc := e.text.Replace(start, end, s)
 	newEnd := start + sc
+	e.spellReplace(start, end, newEnd)
 	adjust := func(pos int) int {
 		switch {
 		case newEnd < pos && pos <= end:
@@ -959,7 +1315,28 @@ func (e *Editor) replace(start, end int, s string, addHistory bool) int {
 	}
 	e.ime.start = adjust(e.ime.start)
 	e.ime.end = adjust(e.ime.end)
-	return sc
+	return sc, replaceSize, newEnd
+}
+
+// constrain returns the part of s that Filter and MaxLen accept as the
+// replacement of replaceSize runes.
+func (e *Editor) constrain(s string, replaceSize int) string {
+	el := e.Len()
+	sc := 0
+	idx := 0
+	for idx < len(s) {
+		if e.MaxLen > 0 && el-replaceSize+sc >= e.MaxLen {
+			return s[:idx]
+		}
+		_, n := utf8.DecodeRuneInString(s[idx:])
+		if e.Filter != "" && !strings.Contains(e.Filter, s[idx:idx+n]) {
+			s = s[:idx] + s[idx+n:]
+			continue
+		}
+		idx += n
+		sc++
+	}
+	return s
 }
 
 // MoveCaret moves the caret (aka selection start) and the selection end
@@ -981,10 +1358,17 @@ func (e *Editor) deleteWord(distance int) (deletedRunes int) {
 	if distance == 0 {
 		return
 	}
+	e.eachCaret(func() {
+		deletedRunes += e.deleteWordAt(distance)
+	})
+	return deletedRunes
+}
 
+// deleteWordAt is like deleteWord for the primary caret only.
+func (e *Editor) deleteWordAt(distance int) (deletedRunes int) {
 	start, end := e.text.Selection()
 	if start != end {
-		deletedRunes = e.Delete(1)
+		deletedRunes = e.deleteAt(1)
 		distance -= sign(distance)
 	}
 	if distance == 0 {
@@ -1028,7 +1412,7 @@ func (e *Editor) deleteWord(distance int) (deletedRunes int) {
 			runes += 1
 		}
 	}
-	deletedRunes += e.Delete(runes * direction)
+	deletedRunes += e.deleteAt(runes * direction)
 	return deletedRunes
 }
 
@@ -1069,6 +1453,132 @@ func (e *Editor) ClearSelection() {
 	e.text.ClearSelection()
 }
 
+// Caret is a caret and its selection, as rune offsets. Start is the caret
+// position and End is the other end of the selection; Start can be > End.
+type Caret struct {
+	Start, End int
+}
+
+// Carets returns every caret of the editor, starting with the primary caret
+// described by Selection. The other carets follow in text order.
+//
+// Carets are added with AddCaret, AddNextMatch, or interactively by clicking
+// with the shortcut modifier held or by dragging with the Alt modifier held
+// to select a column of text. Typing, deletion and clipboard operations
+// apply at every caret, and are undone as a single step.
+func (e *Editor) Carets() []Caret {
+	e.initBuffer()
+	carets := make([]Caret, 0, len(e.text.carets)+1)
+	carets = append(carets, Caret{Start: e.text.caret.start, End: e.text.caret.end})
+	for _, c := range e.text.carets {
+		carets = append(carets, Caret{Start: c.start, End: c.end})
+	}
+	return carets
+}
+
+// AddCaret adds a caret at start and sets its selection end to end, and
+// makes it the primary caret. Carets that overlap are merged into one.
+func (e *Editor) AddCaret(start, end int) {
+	e.initBuffer()
+	e.text.addCaret(start, end)
+	e.scrollCaret = true
+	e.scroller.Stop()
+}
+
+// ClearCarets removes every caret except the primary caret.
+func (e *Editor) ClearCarets() {
+	e.initBuffer()
+	e.text.clearCarets()
+}
+
+// AddNextMatch adds a caret selecting the next occurrence of the text
+// selected by the primary caret, wrapping around at the end of the text. If
+// the primary caret has no selection, the word around it is selected
+// instead. AddNextMatch reports whether a caret was added or changed.
+func (e *Editor) AddNextMatch() bool {
+	e.initBuffer()
+	start, end := e.text.Selection()
+	if start == end {
+		start, end = e.text.wordAt(start)
+		if start == end {
+			return false
+		}
+		e.text.SetCaret(end, start)
+		return true
+	}
+	e.scratch = e.text.SelectedText(e.scratch)
+	start, end, ok := e.text.nextMatch(max(start, end), e.scratch)
+	if !ok {
+		return false
+	}
+	e.AddCaret(end, start)
+	return true
+}
+
+// selectedTexts returns the text selected by every caret in text order,
+// separated by newlines.
+func (e *Editor) selectedTexts() string {
+	if len(e.text.carets) == 0 {
+		e.scratch = e.text.SelectedText(e.scratch)
+		return string(e.scratch)
+	}
+	carets := append([]textCaret{e.text.caret}, e.text.carets...)
+	slices.SortFunc(carets, func(a, b textCaret) int {
+		s1, _ := a.bounds()
+		s2, _ := b.bounds()
+		return cmp.Compare(s1, s2)
+	})
+	var b strings.Builder
+	for _, c := range carets {
+		if c.start == c.end {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		e.scratch = e.text.selectedText(c, e.scratch)
+		b.Write(e.scratch)
+	}
+	return b.String()
+}
+
+// writeClipboard writes text to the clipboard.
+func writeClipboard(gtx layout.Context, text string) {
+	gtx.Execute(clipboard.WriteCmd{Type: "application/text", Data: io.NopCloser(strings.NewReader(text))})
+}
+
+// deleteSelections deletes the text selected by every caret.
+func (e *Editor) deleteSelections() (deletedRunes int) {
+	e.eachCaret(func() {
+		if start, end := e.text.Selection(); start != end {
+			deletedRunes += e.deleteAt(1)
+		}
+	})
+	return deletedRunes
+}
+
+// paste inserts s at every caret. If s has a line for every caret, such as
+// when it was copied from as many selections, the lines are distributed
+// among the carets in text order.
+func (e *Editor) paste(s string) (insertedRunes int) {
+	lines := strings.Split(s, "\n")
+	if len(e.text.carets) == 0 || len(lines) != len(e.text.carets)+1 {
+		return e.Insert(s)
+	}
+	e.eachCaret(func() {
+		// Count the carets before this one to find its line.
+		pos, _ := e.text.caret.bounds()
+		line := 0
+		for _, c := range e.text.carets {
+			if start, _ := c.bounds(); start < pos {
+				line++
+			}
+		}
+		insertedRunes += e.insertAt(lines[line])
+	})
+	return insertedRunes
+}
+
 // WriteTo implements io.WriterTo.
 func (e *Editor) WriteTo(w io.Writer) (int64, error) {
 	e.initBuffer()
@@ -9,6 +9,8 @@ import (
 	"io"
 	"math/rand"
 	"reflect"
+	"slices"
+	"strings"
 	"testing"
 	"testing/quick"
 	"time"
@@ -1283,3 +1285,434 @@ func textBaseline(e *Editor, lineNum int) float32 {
 	start := e.text.closestToLineCol(lineNum, 0)
 	return float32(start.y)
 }
+
+func TestChunkBuffer(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	b := new(chunkBuffer)
+	var want []rune
+	alphabet := []rune("ab\ncd é€😀")
+	for range 500 {
+		start := r.Intn(len(want) + 1)
+		count := r.Intn(min(len(want)-start, 300) + 1)
+		ins := make([]rune, r.Intn(600))
+		for i := range ins {
+			ins[i] = alphabet[r.Intn(len(alphabet))]
+		}
+		off := len(string(want[:start]))
+		b.ReplaceRunes(int64(off), int64(count), string(ins))
+		want = append(want[:start:start], append(ins, want[start+count:]...)...)
+		if got := b.Size(); got != int64(len(string(want))) {
+			t.Fatalf("got size %d, want %d", got, len(string(want)))
+		}
+	}
+	got := make([]byte, b.Size())
+	if _, err := b.ReadAt(got, 0); err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("buffer contents differ from the edited text")
+	}
+	for _, c := range b.chunks {
+		if len(c) > maxChunkSize || !utf8.Valid(c) {
+			t.Fatalf("invalid chunk of length %d", len(c))
+		}
+	}
+	if !b.Changed() || b.Changed() {
+		t.Errorf("buffer changes not reported exactly once")
+	}
+}
+
+// TestEditorLargeText ensures that editors laid out incrementally agree with
+// editors laid out in full.
+func TestEditorLargeText(t *testing.T) {
+	gtx := layout.Context{
+		Ops:         new(op.Ops),
+		Constraints: layout.Exact(image.Pt(150, 2000)),
+		Locale:      english,
+	}
+	cache := text.NewShaper(text.NoSystemFonts(), text.WithCollection(gofont.Collection()))
+	fontSize := unit.Sp(10)
+	font := font.Font{}
+	full, large := new(Editor), &Editor{LargeText: true}
+	layout := func() {
+		full.Layout(gtx, cache, font, fontSize, op.CallOp{}, op.CallOp{})
+		large.Layout(gtx, cache, font, fontSize, op.CallOp{}, op.CallOp{})
+	}
+	compare := func(step string) {
+		t.Helper()
+		if g, w := large.Len(), full.Len(); g != w {
+			t.Fatalf("%s: Len %d, want %d", step, g, w)
+		}
+		if g, w := large.Text(), full.Text(); g != w {
+			t.Fatalf("%s: Text %q, want %q", step, g, w)
+		}
+		for i := 0; i <= full.Len(); i++ {
+			full.SetCaret(i, i)
+			large.SetCaret(i, i)
+			gl, gc := large.CaretPos()
+			wl, wc := full.CaretPos()
+			if gl != wl || gc != wc || large.CaretCoords() != full.CaretCoords() {
+				t.Fatalf("%s: caret %d at (%d,%d) %v, want (%d,%d) %v", step, i, gl, gc, large.CaretCoords(), wl, wc, full.CaretCoords())
+			}
+			if g, w := large.text.ByteOffset(i), full.text.ByteOffset(i); g != w {
+				t.Fatalf("%s: rune %d at byte %d, want %d", step, i, g, w)
+			}
+		}
+		for i := 0; i <= full.Len(); i += 3 {
+			g := large.Regions(i, i+17, nil)
+			w := full.Regions(i, i+17, nil)
+			if !reflect.DeepEqual(g, w) {
+				t.Fatalf("%s: regions %v, want %v", step, g, w)
+			}
+		}
+		for y := 0; y < 200; y += 7 {
+			pt := image.Pt(y/2, y)
+			full.text.MoveCoord(pt)
+			large.text.MoveCoord(pt)
+			if g, _ := large.Selection(); g != func() int { s, _ := full.Selection(); return s }() {
+				t.Fatalf("%s: coordinate %v at rune %d", step, pt, g)
+			}
+		}
+		// The width of incremental layouts doesn't shrink when lines are
+		// shortened.
+		g, w := large.text.FullDimensions(), full.text.FullDimensions()
+		if g.Size.Y != w.Size.Y || g.Baseline != w.Baseline || g.Size.X < w.Size.X {
+			t.Fatalf("%s: dimensions %v, want %v", step, g, w)
+		}
+	}
+	const txt = "Lorem ipsum dolor sit amet,\nconsectetur adipiscing elit.\n\nSed do eiusmod tempor incididunt ut labore et dolore magna aliqua.\n"
+	for _, e := range []*Editor{full, large} {
+		e.SetText(txt)
+	}
+	layout()
+	compare("initial text")
+	r := rand.New(rand.NewSource(1))
+	edits := []string{"", "x", "\n", "two words", "é😀\n\nnew paragraph"}
+	for i := range 50 {
+		start, end := r.Intn(full.Len()+1), r.Intn(full.Len()+1)
+		s := edits[r.Intn(len(edits))]
+		for _, e := range []*Editor{full, large} {
+			e.SetCaret(start, end)
+			e.Insert(s)
+		}
+		layout()
+		compare(fmt.Sprintf("edit %d", i))
+	}
+}
+
+func TestEditorSource(t *testing.T) {
+	gtx := layout.Context{
+		Ops:         new(op.Ops),
+		Constraints: layout.Exact(image.Pt(100, 100)),
+		Locale:      english,
+	}
+	cache := text.NewShaper(text.NoSystemFonts(), text.WithCollection(gofont.Collection()))
+	e := new(Editor)
+	e.SetText("discarded")
+	src := new(editBuffer)
+	src.ReplaceRunes(0, 0, "hello")
+	e.SetSource(src)
+	if got := e.Text(); got != "hello" {
+		t.Fatalf("got text %q from source", got)
+	}
+	e.SetCaret(5, 5)
+	e.Insert(" world")
+	var buf [11]byte
+	if n, _ := src.ReadAt(buf[:], 0); string(buf[:n]) != "hello world" {
+		t.Errorf("source contains %q after insertion", buf[:n])
+	}
+	e.Update(gtx)
+	e.Layout(gtx, cache, font.Font{}, unit.Sp(10), op.CallOp{}, op.CallOp{})
+	// Change the source behind the editor's back.
+	src.ReplaceRunes(0, 0, "¡")
+	if ev, _ := e.Update(gtx); ev != (ChangeEvent{}) {
+		t.Errorf("got event %v for an external change, want ChangeEvent", ev)
+	}
+	if got, want := e.Len(), len([]rune("¡hello world")); got != want {
+		t.Errorf("got length %d after external change, want %d", got, want)
+	}
+	// The undo history doesn't apply to the changed text.
+	src.ReplaceRunes(0, 0, "¿")
+	if e.Undo() {
+		t.Error("undid a step after an external change")
+	}
+	if got, want := e.Text(), "¿¡hello world"; got != want {
+		t.Errorf("got text %q after undo, want %q", got, want)
+	}
+	e.SetSource(nil)
+	if got := e.Text(); got != "" {
+		t.Errorf("got text %q after resetting the source", got)
+	}
+}
+
+func TestEditorCarets(t *testing.T) {
+	e := new(Editor)
+	e.SetText("one two\none two\none two")
+	e.SetCaret(3, 3)
+	e.AddCaret(11, 11)
+	e.AddCaret(19, 19)
+	// Overlapping carets are merged.
+	e.AddCaret(11, 11)
+	assertCarets := func(want ...Caret) {
+		t.Helper()
+		if got := e.Carets(); !slices.Equal(got, want) {
+			t.Errorf("got carets %v, want %v", got, want)
+		}
+	}
+	assertCarets(Caret{11, 11}, Caret{3, 3}, Caret{19, 19})
+	e.Insert("!")
+	assertContents(t, e, "one! two\none! two\none! two", 13, 13)
+	assertCarets(Caret{13, 13}, Caret{4, 4}, Caret{22, 22})
+	e.Delete(-1)
+	assertContents(t, e, "one two\none two\none two", 11, 11)
+	// Edits at every caret are undone and redone together.
+	e.Insert("1")
+	e.undo()
+	assertContents(t, e, "one two\none two\none two", 11, 11)
+	if n := len(e.Carets()); n != 3 {
+		t.Errorf("got %d carets after undo, want 3", n)
+	}
+	e.redo()
+	if got, want := e.Text(), "one1 two\none1 two\none1 two"; got != want {
+		t.Errorf("got %q after redo, want %q", got, want)
+	}
+	if got, want := e.selectedTexts(), "1\n1\n1"; got != want {
+		t.Errorf("got selected texts %q, want %q", got, want)
+	}
+
+	e.SetText("one two\none two\none two")
+	e.SetCaret(1, 1)
+	// The first match selects the word around the caret.
+	if !e.AddNextMatch() {
+		t.Fatal("no word selected")
+	}
+	assertCarets(Caret{3, 0})
+	e.AddNextMatch()
+	e.AddNextMatch()
+	assertCarets(Caret{19, 16}, Caret{3, 0}, Caret{11, 8})
+	if e.AddNextMatch() {
+		t.Error("matched text that is already selected")
+	}
+	if got, want := e.selectedTexts(), "one\none\none"; got != want {
+		t.Errorf("got selected texts %q, want %q", got, want)
+	}
+	e.paste("1\n2\n3")
+	if got, want := e.Text(), "1 two\n2 two\n3 two"; got != want {
+		t.Errorf("got %q after paste, want %q", got, want)
+	}
+	e.ClearCarets()
+	assertCarets(Caret{13, 13})
+}
+
+func TestEditorColumnSelect(t *testing.T) {
+	e := new(Editor)
+	e.SetText("abcd\nabcd\nabcd")
+	r := new(input.Router)
+	gtx := layout.Context{
+		Ops:         new(op.Ops),
+		Locale:      english,
+		Constraints: layout.Exact(image.Pt(100, 100)),
+		Source:      r.Source(),
+	}
+	cache := text.NewShaper(text.NoSystemFonts(), text.WithCollection(gofont.Collection()))
+	font := font.Font{}
+	fontSize := unit.Sp(10)
+	layoutFrame := func() {
+		gtx.Ops.Reset()
+		e.Layout(gtx, cache, font, fontSize, op.CallOp{}, op.CallOp{})
+		r.Frame(gtx.Ops)
+	}
+	gtx.Execute(key.FocusCmd{Tag: e})
+	layoutFrame()
+
+	start := f32.Pt(textWidth(e, 0, 0, 1), textBaseline(e, 0))
+	end := f32.Pt(textWidth(e, 2, 0, 3), textBaseline(e, 2))
+	r.Queue(
+		pointer.Event{
+			Buttons:   pointer.ButtonPrimary,
+			Kind:      pointer.Press,
+			Source:    pointer.Mouse,
+			Modifiers: key.ModAlt,
+			Position:  start,
+		},
+		pointer.Event{
+			Buttons:   pointer.ButtonPrimary,
+			Kind:      pointer.Move,
+			Source:    pointer.Mouse,
+			Modifiers: key.ModAlt,
+			Position:  end,
+		},
+		pointer.Event{
+			Kind:      pointer.Release,
+			Source:    pointer.Mouse,
+			Modifiers: key.ModAlt,
+			Position:  end,
+		},
+	)
+	layoutFrame()
+	if got, want := e.selectedTexts(), "bc\nbc\nbc"; got != want {
+		t.Errorf("got column selection %q, want %q", got, want)
+	}
+	r.Queue(key.EditEvent{Range: key.Range{Start: 11, End: 13}, Text: "x"})
+	layoutFrame()
+	if got, want := e.Text(), "axd\naxd\naxd"; got != want {
+		t.Errorf("got %q after typing, want %q", got, want)
+	}
+	r.Queue(key.Event{Name: key.NameEscape, State: key.Press})
+	layoutFrame()
+	if n := len(e.Carets()); n != 1 {
+		t.Errorf("got %d carets after escape, want 1", n)
+	}
+}
+
+func TestEditorUndoGroups(t *testing.T) {
+	e := new(Editor)
+	if e.CanUndo() || e.CanRedo() {
+		t.Fatal("new editor can undo or redo")
+	}
+	e.Insert("a")
+	e.BeginUndoGroup()
+	e.Insert("b")
+	e.BeginUndoGroup()
+	e.Insert("c")
+	e.EndUndoGroup()
+	e.Delete(-1)
+	e.Insert("d")
+	e.EndUndoGroup()
+	if !e.Undo() {
+		t.Fatal("no undo step")
+	}
+	if got := e.Text(); got != "a" {
+		t.Errorf("got %q after undoing a group, want %q", got, "a")
+	}
+	if !e.CanRedo() {
+		t.Error("can't redo after undo")
+	}
+	e.Redo()
+	if got := e.Text(); got != "abd" {
+		t.Errorf("got %q after redoing a group, want %q", got, "abd")
+	}
+	if e.CanRedo() || e.Redo() {
+		t.Error("redo without undo")
+	}
+
+	e.MaxHistory = 2
+	e.Insert("e")
+	e.Insert("f")
+	for i := range 2 {
+		if !e.Undo() {
+			t.Errorf("undo %d failed", i)
+		}
+	}
+	if e.CanUndo() || e.Undo() {
+		t.Error("undo history exceeds MaxHistory")
+	}
+	if got := e.Text(); got != "abd" {
+		t.Errorf("got %q after undoing history, want %q", got, "abd")
+	}
+	e.Redo()
+	e.ClearHistory()
+	if e.CanUndo() || e.CanRedo() {
+		t.Error("can undo or redo after clearing the history")
+	}
+}
+
+func TestEditorTransform(t *testing.T) {
+	e := new(Editor)
+	// Accept digits only.
+	e.Transform = func(ed Edit) (Edit, bool) {
+		return ed, strings.Trim(ed.Text, "0123456789") == ""
+	}
+	e.Insert("12")
+	if n := e.Insert("a"); n != 0 {
+		t.Errorf("inserted %d runes of a rejected edit", n)
+	}
+	e.Insert("3")
+	assertContents(t, e, "123", 3, 3)
+	e.Undo()
+	assertContents(t, e, "12", 2, 2)
+	e.Transform = func(ed Edit) (Edit, bool) {
+		return ed, false
+	}
+	if n := e.Delete(-1); n != 0 {
+		t.Errorf("deleted %d runes of a rejected edit", n)
+	}
+	assertContents(t, e, "12", 2, 2)
+
+	// Format a phone number, replacing the whole text on every edit.
+	e = new(Editor)
+	e.Transform = func(ed Edit) (Edit, bool) {
+		text := []rune(e.Text())
+		text = slices.Concat(text[:ed.Start], []rune(ed.Text), text[ed.End:])
+		var digits []rune
+		for _, r := range text {
+			if '0' <= r && r <= '9' {
+				digits = append(digits, r)
+			}
+		}
+		var b strings.Builder
+		for i, d := range digits {
+			switch i {
+			case 0:
+				b.WriteRune('(')
+			case 3:
+				b.WriteString(") ")
+			case 6:
+				b.WriteRune('-')
+			}
+			b.WriteRune(d)
+		}
+		return Edit{Start: 0, End: e.Len(), Text: b.String()}, true
+	}
+	for _, r := range "5551234" {
+		e.Insert(string(r))
+	}
+	assertContents(t, e, "(555) 123-4", 11, 11)
+	// The counts are those of the transformed edit.
+	if n := e.Delete(-1); n != 11 {
+		t.Errorf("deleted %d runes, want 11", n)
+	}
+	assertContents(t, e, "(555) 123", 9, 9)
+	e.SetCaret(0, 0)
+	if n := e.Insert("1"); n != 11 {
+		t.Errorf("inserted %d runes, want 11", n)
+	}
+	assertContents(t, e, "(155) 512-3", 11, 11)
+
+	// The result of Transform is constrained like any other edit.
+	e = &Editor{
+		SingleLine: true,
+		MaxLen:     5,
+		Transform: func(ed Edit) (Edit, bool) {
+			ed.Text += "\n" + ed.Text
+			return ed, true
+		},
+	}
+	e.Insert("abc")
+	assertContents(t, e, "abc a", 5, 5)
+
+	// Edits from input methods are transformed as well.
+	e = &Editor{
+		Transform: func(ed Edit) (Edit, bool) {
+			ed.Text = strings.ToUpper(ed.Text)
+			return ed, true
+		},
+	}
+	r := new(input.Router)
+	gtx := layout.Context{
+		Ops:    new(op.Ops),
+		Locale: english,
+		Source: r.Source(),
+	}
+	cache := text.NewShaper(text.NoSystemFonts(), text.WithCollection(gofont.Collection()))
+	gtx.Execute(key.FocusCmd{Tag: e})
+	e.Layout(gtx, cache, font.Font{}, unit.Sp(10), op.CallOp{}, op.CallOp{})
+	r.Frame(gtx.Ops)
+	r.Queue(key.EditEvent{Range: key.Range{Start: 0, End: 0}, Text: "abc"})
+	if ev, _ := e.Update(gtx); ev != (ChangeEvent{}) {
+		t.Errorf("got event %v, want ChangeEvent", ev)
+	}
+	if got := e.Text(); got != "ABC" {
+		t.Errorf("got %q from input method, want %q", got, "ABC")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"gioui.org/f32"
 	"gioui.org/font"
 	"gioui.org/io/semantic"
+	"gioui.org/io/system"
 	"gioui.org/layout"
 	"gioui.org/op"
 	"gioui.org/op/clip"
@@ -36,6 +37,16 @@ type Label struct {
 	// LineHeightScale applies a scaling factor to the LineHeight. If zero, a
 	// sensible default will be used.
 	LineHeightScale float32
+	// LetterSpacing is extra space added after every glyph cluster.
+	LetterSpacing unit.Sp
+	// WordSpacing is extra space added to every word separator.
+	WordSpacing unit.Sp
+	// Decoration configures lines drawn alongside the text, such as underlines.
+	Decoration text.Decoration
+	// Vertical lays the text out in columns running top to bottom, progressing
+	// from right to left. The maximum height of the constraints limits the length
+	// of the columns.
+	Vertical bool
 }
 
 // Layout the label with the given shaper, font, size, text, and material.
@@ -56,6 +67,11 @@ func (l Label) LayoutDetailed(gtx layout.Context, lt *text.Shaper, font font.Fon
 	cs := gtx.Constraints
 	textSize := fixed.I(gtx.Sp(size))
 	lineHeight := fixed.I(gtx.Sp(l.LineHeight))
+	locale, maxWidth, minWidth := gtx.Locale, cs.Max.X, cs.Min.X
+	if l.Vertical {
+		locale.Direction = system.TTB
+		maxWidth, minWidth = cs.Max.Y, cs.Min.Y
+	}
 	lt.LayoutString(text.Parameters{
 		Font:            font,
 		PxPerEm:         textSize,
@@ -63,18 +79,21 @@ func (l Label) LayoutDetailed(gtx layout.Context, lt *text.Shaper, font font.Fon
 		Truncator:       l.Truncator,
 		Alignment:       l.Alignment,
 		WrapPolicy:      l.WrapPolicy,
-		MaxWidth:        cs.Max.X,
-		MinWidth:        cs.Min.X,
-		Locale:          gtx.Locale,
+		MaxWidth:        maxWidth,
+		MinWidth:        minWidth,
+		Locale:          locale,
 		LineHeight:      lineHeight,
 		LineHeightScale: l.LineHeightScale,
+		LetterSpacing:   fixed.I(gtx.Sp(l.LetterSpacing)),
+		WordSpacing:     fixed.I(gtx.Sp(l.WordSpacing)),
 	}, txt)
 	m := op.Record(gtx.Ops)
 	viewport := image.Rectangle{Max: cs.Max}
 	it := textIterator{
-		viewport: viewport,
-		maxLines: l.MaxLines,
-		material: textMaterial,
+		viewport:   viewport,
+		maxLines:   l.MaxLines,
+		material:   textMaterial,
+		decoration: l.Decoration,
 	}
 	semantic.LabelOp(txt).Add(gtx.Ops)
 	var glyphs [32]text.Glyph
@@ -92,7 +111,9 @@ func (l Label) LayoutDetailed(gtx layout.Context, lt *text.Shaper, font font.Fon
 	call.Add(gtx.Ops)
 	dims := layout.Dimensions{Size: it.bounds.Size()}
 	dims.Size = cs.Constrain(dims.Size)
-	dims.Baseline = dims.Size.Y - it.baseline
+	if !l.Vertical {
+		dims.Baseline = dims.Size.Y - it.baseline
+	}
 	clipStack.Pop()
 	return dims, TextInfo{Truncated: it.truncated}
 }
@@ -108,6 +129,8 @@ type textIterator struct {
 	// the color of the glyphs is undefined and may change unpredictably if the
 	// text contains color glyphs.
 	material op.CallOp
+	// decoration configures the lines painted alongside the glyphs.
+	decoration text.Decoration
 	// truncated tracks the count of truncated runes in the text.
 	truncated int
 	// linesSeen tracks the quantity of line endings this iterator has seen.
@@ -143,6 +166,9 @@ func (it *textIterator) processGlyph(g text.Glyph, ok bool) (visibleOrBefore boo
 			return false
 		}
 	}
+	if g.Flags&text.FlagVertical != 0 {
+		return it.processVerticalGlyph(g, ok)
+	}
 	// Compute the maximum extent to which glyphs overhang on the horizontal
 	// axis.
 	if d := g.Bounds.Min.X.Floor(); d < it.padding.Min.X {
@@ -190,6 +216,43 @@ func (it *textIterator) processGlyph(g text.Glyph, ok bool) (visibleOrBefore boo
 	return ok && !below
 }
 
+// processVerticalGlyph is processGlyph for glyphs of vertical text, whose advance
+// runs down the page and whose ascent and descent extend to the right and left
+// of their column's center line.
+func (it *textIterator) processVerticalGlyph(g text.Glyph, ok bool) (visibleOrBefore bool) {
+	if d := (g.Bounds.Min.X + g.Descent).Floor(); d < it.padding.Min.X {
+		it.padding.Min.X = d
+	}
+	if d := (g.Bounds.Max.X - g.Ascent).Ceil(); d > it.padding.Max.X {
+		it.padding.Max.X = d
+	}
+	if d := g.Bounds.Min.Y.Floor(); d < it.padding.Min.Y {
+		it.padding.Min.Y = d
+	}
+	if d := (g.Bounds.Max.Y - g.Advance).Ceil(); d > it.padding.Max.Y {
+		it.padding.Max.Y = d
+	}
+	logicalBounds := image.Rectangle{
+		Min: image.Pt((g.X - g.Descent).Floor(), int(g.Y)),
+		Max: image.Pt((g.X + g.Ascent).Ceil(), int(g.Y)+g.Advance.Ceil()),
+	}
+	if !it.first {
+		it.first = true
+		it.bounds = logicalBounds
+	}
+	// Columns progress leftwards, so text beyond the left edge of the viewport
+	// plays the role of text below it in horizontal layouts.
+	above := logicalBounds.Max.Y < it.viewport.Min.Y
+	below := logicalBounds.Min.Y > it.viewport.Max.Y
+	left := logicalBounds.Max.X < it.viewport.Min.X
+	right := logicalBounds.Min.X > it.viewport.Max.X
+	it.visible = !above && !below && !left && !right
+	if it.visible {
+		it.bounds = it.bounds.Union(logicalBounds)
+	}
+	return ok && !left
+}
+
 func fixedToFloat(i fixed.Int26_6) float32 {
 	return float32(i) / 64.0
 }
@@ -216,6 +279,12 @@ func (it *textIterator) paintGlyph(gtx layout.Context, shaper *text.Shaper, glyp
 		it.material.Add(gtx.Ops)
 		paint.PaintOp{}.Add(gtx.Ops)
 		outline.Pop()
+		if it.decoration != 0 && len(line) > 0 {
+			decor := clip.Outline{Path: shaper.Decorations(line, it.decoration)}.Op().Push(gtx.Ops)
+			it.material.Add(gtx.Ops)
+			paint.PaintOp{}.Add(gtx.Ops)
+			decor.Pop()
+		}
 		if call := shaper.Bitmaps(line); call != (op.CallOp{}) {
 			call.Add(gtx.Ops)
 		}
@@ -211,6 +211,42 @@ func TestGlyphIteratorPadding(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "vertical",
+			glyph: text.Glyph{
+				X:       fixed.I(50),
+				Y:       0,
+				Advance: fixed.I(40),
+				Ascent:  fixed.I(20),
+				Descent: fixed.I(20),
+				Flags:   text.FlagVertical,
+				Bounds: fixed.Rectangle26_6{
+					Min: fixed.Point26_6{
+						X: fixed.I(-25),
+						Y: fixed.I(-3),
+					},
+					Max: fixed.Point26_6{
+						X: fixed.I(27),
+						Y: fixed.I(44),
+					},
+				},
+			},
+			viewport: image.Rectangle{Max: image.Pt(math.MaxInt, math.MaxInt)},
+			expectedDims: image.Rectangle{
+				Min: image.Point{X: 30},
+				Max: image.Point{X: 70, Y: 40},
+			},
+			expectedPadding: image.Rectangle{
+				Min: image.Point{
+					X: -5,
+					Y: -3,
+				},
+				Max: image.Point{
+					X: 7,
+					Y: 4,
+				},
+			},
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			it := textIterator{viewport: tc.viewport}
@@ -0,0 +1,191 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package widget
+
+import (
+	"image"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/text"
+)
+
+// LineInfo describes a line of text displayed by an Editor.
+type LineInfo struct {
+	// Line is the index of the line among all the lines the text is
+	// broken into, as reported by Editor.CaretPos.
+	Line int
+	// Paragraph is the index of the paragraph containing the line, that
+	// is, the number of newlines before it.
+	Paragraph int
+	// Wrapped reports whether the line continues the paragraph of the
+	// line before it.
+	Wrapped bool
+	// Start is the rune offset of the start of the line.
+	Start int
+	// Baseline is the vertical position of the baseline of the line,
+	// relative to the top of the visible part of the editor. Ascent and
+	// Descent are the extents of the line above and below its baseline.
+	Baseline        int
+	Ascent, Descent int
+}
+
+// lineCount returns the number of lines of the text.
+func (e *textView) lineCount() int {
+	e.makeValid()
+	if e.incrementalLayout {
+		return e.paragraphs.total().lines
+	}
+	return max(len(e.index.lines), 1)
+}
+
+// visibleLines appends the lines that are at least partially visible
+// to lines.
+func (e *textView) visibleLines(lines []LineInfo) []LineInfo {
+	e.makeValid()
+	lines = lines[:0]
+	viewport := image.Rectangle{Max: e.viewSize}.Add(e.scrollOff)
+	if e.incrementalLayout {
+		p := &e.paragraphs
+		first, start := p.findY(viewport.Min.Y)
+		i := first
+		for ; i < len(p.paras) && p.top(start) <= viewport.Max.Y; i++ {
+			sp := e.shapeParagraph(i, start)
+			lines = e.appendLines(lines, &sp.index, viewport, func(j int) (int, bool) {
+				return i, j > 0
+			}, start.lines, start.runes, p.offset(i, start))
+			start = start.add(p.paras[i].paragraphMetrics)
+		}
+		e.discardParagraphs(first, i)
+		return lines
+	}
+	var para, glyph, next int
+	wrapped := false
+	paragraph := func(j int) (int, bool) {
+		// Count the paragraph breaks ending the lines before j.
+		for ; next < j; next++ {
+			glyph += e.index.lines[next].glyphs
+			wrapped = glyph > 0 && e.index.glyphs[glyph-1].Flags&text.FlagParagraphBreak == 0
+			if !wrapped {
+				para++
+			}
+		}
+		return para, wrapped
+	}
+	return e.appendLines(lines, &e.index, viewport, paragraph, 0, 0, 0)
+}
+
+// appendLines appends the lines of index that intersect viewport to lines.
+// The lines of the index are numbered from firstLine and the runes from
+// firstRune, and the index is offset vertically by yOff. paragraph returns
+// the paragraph of the line with the given index, and whether it is wrapped.
+// It must be called with increasing line indices.
+func (e *textView) appendLines(lines []LineInfo, index *glyphIndex, viewport image.Rectangle, paragraph func(j int) (int, bool), firstLine, firstRune, yOff int) []LineInfo {
+	for j, l := range index.lines {
+		baseline := l.yOff + yOff
+		ascent, descent := l.ascent.Ceil(), l.descent.Ceil()
+		if baseline+descent < viewport.Min.Y {
+			continue
+		}
+		if baseline-ascent > viewport.Max.Y {
+			break
+		}
+		para, wrapped := paragraph(j)
+		lines = append(lines, LineInfo{
+			Line:      firstLine + j,
+			Paragraph: para,
+			Wrapped:   wrapped,
+			Start:     firstRune + index.closestToLineCol(screenPos{line: j}).runes,
+			Baseline:  baseline - viewport.Min.Y,
+			Ascent:    ascent,
+			Descent:   descent,
+		})
+	}
+	return lines
+}
+
+// LineCount returns the number of lines the text is broken into. With
+// LargeText set, the count is an estimate until every paragraph has
+// been displayed.
+func (e *Editor) LineCount() int {
+	e.initBuffer()
+	return e.text.lineCount()
+}
+
+// LineStart returns the rune offset of the start of line, clamped to the
+// lines of the text.
+func (e *Editor) LineStart(line int) int {
+	e.initBuffer()
+	return e.text.closestToLineCol(line, 0).runes
+}
+
+// LineAt returns the line containing the rune at runeOffset.
+func (e *Editor) LineAt(runeOffset int) int {
+	e.initBuffer()
+	return e.text.closestToRune(runeOffset).lineCol.line
+}
+
+// ScrollToLine scrolls the editor vertically to display line at the top
+// of the visible area, as far as the text extends.
+func (e *Editor) ScrollToLine(line int) {
+	e.initBuffer()
+	pos := e.text.closestToLineCol(line, 0)
+	e.text.scrollAbs(e.text.scrollOff.X, pos.y-pos.ascent.Ceil())
+	e.scrollCaret = false
+	e.scroller.Stop()
+}
+
+// VisibleLines returns the lines that are at least partially visible,
+// reusing the storage of buf. The lines reflect the most recent layout
+// of the editor.
+func (e *Editor) VisibleLines(buf []LineInfo) []LineInfo {
+	e.initBuffer()
+	return e.text.visibleLines(buf)
+}
+
+// Gutter lays out content alongside the visible lines of an Editor, such
+// as line numbers, fold markers or error badges.
+type Gutter struct {
+	lines []LineInfo
+	calls []op.CallOp
+	dims  []layout.Dimensions
+}
+
+// Layout calls w for every visible line of e and displays the result
+// aligned with the line, such that their baselines match. The gutter is
+// as tall as the editor and as wide as the widest content. Lay out the
+// gutter after the editor to reflect its current scroll position. The
+// content of lines narrower than the gutter is aligned according to align.
+func (g *Gutter) Layout(gtx layout.Context, e *Editor, align text.Alignment, w func(gtx layout.Context, line LineInfo) layout.Dimensions) layout.Dimensions {
+	g.lines = e.VisibleLines(g.lines)
+	g.calls = g.calls[:0]
+	g.dims = g.dims[:0]
+	width := gtx.Constraints.Min.X
+	cgtx := gtx
+	cgtx.Constraints.Min = image.Point{}
+	for _, l := range g.lines {
+		m := op.Record(gtx.Ops)
+		dims := w(cgtx, l)
+		g.calls = append(g.calls, m.Stop())
+		g.dims = append(g.dims, dims)
+		width = max(width, dims.Size.X)
+	}
+	size := gtx.Constraints.Constrain(image.Pt(width, e.text.Dimensions().Size.Y))
+	defer clip.Rect{Max: size}.Push(gtx.Ops).Pop()
+	for i, l := range g.lines {
+		dims := g.dims[i]
+		var x int
+		switch align {
+		case text.Middle:
+			x = (size.X - dims.Size.X) / 2
+		case text.End:
+			x = size.X - dims.Size.X
+		}
+		y := l.Baseline - (dims.Size.Y - dims.Baseline)
+		trans := op.Offset(image.Pt(x, y)).Push(gtx.Ops)
+		g.calls[i].Add(gtx.Ops)
+		trans.Pop()
+	}
+	return layout.Dimensions{Size: size}
+}
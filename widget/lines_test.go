@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package widget
+
+import (
+	"image"
+	"slices"
+	"testing"
+
+	"gioui.org/font"
+	"gioui.org/font/gofont"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/text"
+	"gioui.org/unit"
+)
+
+func TestEditorLines(t *testing.T) {
+	for _, large := range []bool{false, true} {
+		gtx := layout.Context{
+			Ops:         new(op.Ops),
+			Constraints: layout.Exact(image.Pt(60, 50)),
+			Locale:      english,
+		}
+		cache := text.NewShaper(text.NoSystemFonts(), text.WithCollection(gofont.Collection()))
+		e := &Editor{LargeText: large}
+		e.SetText("one\ntwo three four five\nsix\nseven\neight\nnine\nten")
+		e.Layout(gtx, cache, font.Font{}, unit.Sp(10), op.CallOp{}, op.CallOp{})
+		lines := e.VisibleLines(nil)
+		if len(lines) < 3 {
+			t.Fatalf("large %v: got %d visible lines", large, len(lines))
+		}
+		if got := e.LineCount(); got <= 7 {
+			t.Errorf("large %v: got %d lines, want more than paragraphs", large, got)
+		}
+		var paras []int
+		for i, l := range lines {
+			if l.Line != i {
+				t.Errorf("large %v: line %d has index %d", large, i, l.Line)
+			}
+			if l.Wrapped != (i > 0 && l.Paragraph == lines[i-1].Paragraph) {
+				t.Errorf("large %v: line %d wrapped %v", large, i, l.Wrapped)
+			}
+			if !l.Wrapped {
+				paras = append(paras, l.Paragraph)
+			}
+			if got := e.LineStart(l.Line); got != l.Start {
+				t.Errorf("large %v: line %d starts at %d, want %d", large, i, got, l.Start)
+			}
+			if got := e.LineAt(l.Start); got != l.Line {
+				t.Errorf("large %v: rune %d is on line %d, want %d", large, l.Start, got, l.Line)
+			}
+			if i > 0 && l.Baseline <= lines[i-1].Baseline {
+				t.Errorf("large %v: line %d baseline %d is above the previous line", large, i, l.Baseline)
+			}
+		}
+		if want := []int{0, 1}; !slices.Equal(paras[:2], want) {
+			t.Errorf("large %v: got paragraphs %v", large, paras)
+		}
+		if lines[1].Start != len("one\n") {
+			t.Errorf("large %v: second line starts at %d", large, lines[1].Start)
+		}
+
+		last := e.LineCount() - 1
+		e.ScrollToLine(last)
+		lines = e.VisibleLines(lines)
+		if l := lines[len(lines)-1]; l.Line != last || l.Paragraph != 6 {
+			t.Errorf("large %v: got last visible line %+v after scrolling, want line %d", large, l, last)
+		}
+		if e.text.ScrollOff().Y == 0 {
+			t.Errorf("large %v: didn't scroll", large)
+		}
+
+		var g Gutter
+		var n int
+		dims := g.Layout(gtx, e, text.Start, func(gtx layout.Context, l LineInfo) layout.Dimensions {
+			n++
+			return layout.Dimensions{Size: image.Pt(l.Paragraph+1, l.Ascent+l.Descent), Baseline: l.Descent}
+		})
+		if n != len(lines) {
+			t.Errorf("large %v: gutter laid out %d lines, want %d", large, n, len(lines))
+		}
+		if want := image.Pt(60, 50); dims.Size != want {
+			t.Errorf("large %v: got gutter size %v, want %v", large, dims.Size, want)
+		}
+	}
+}
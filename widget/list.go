@@ -201,3 +201,17 @@ type List struct {
 	Scrollbar
 	layout.List
 }
+
+// scrollIntoView scrolls l such that element i is visible, given the size
+// of the list along its axis in the last layout. Elements after the end of
+// the list are aligned with its end.
+func scrollIntoView(l *layout.List, i, viewSize int) {
+	pos := &l.Position
+	switch {
+	case i < pos.First || i == pos.First && pos.Offset > 0:
+		l.ScrollTo(i)
+	case pos.Count > 0 && i >= pos.First+pos.Count-1:
+		l.ScrollTo(i + 1)
+		pos.Offset = -viewSize
+	}
+}
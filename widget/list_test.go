@@ -0,0 +1,44 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package widget
+
+import (
+	"image"
+	"testing"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+)
+
+func TestScrollIntoView(t *testing.T) {
+	l := layout.List{Axis: layout.Vertical}
+	gtx := layout.Context{
+		Ops:         new(op.Ops),
+		Constraints: layout.Exact(image.Pt(100, 30)),
+	}
+	frame := func() {
+		gtx.Ops.Reset()
+		l.Layout(gtx, 10, func(gtx layout.Context, i int) layout.Dimensions {
+			return layout.Dimensions{Size: image.Pt(100, 10)}
+		})
+	}
+	frame()
+	// Elements after the list are aligned with its end.
+	scrollIntoView(&l, 5, 30)
+	frame()
+	if pos := l.Position; pos.First != 3 || pos.Offset != 0 {
+		t.Errorf("got position %+v after scrolling down", pos)
+	}
+	// Visible elements don't scroll.
+	scrollIntoView(&l, 4, 30)
+	frame()
+	if pos := l.Position; pos.First != 3 || pos.Offset != 0 {
+		t.Errorf("got position %+v after revealing a visible element", pos)
+	}
+	// Elements before the list are aligned with its start.
+	scrollIntoView(&l, 1, 30)
+	frame()
+	if pos := l.Position; pos.First != 1 || pos.Offset != 0 {
+		t.Errorf("got position %+v after scrolling up", pos)
+	}
+}
@@ -0,0 +1,187 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package material
+
+import (
+	"image"
+	"image/color"
+
+	"gioui.org/f32"
+	"gioui.org/font"
+	"gioui.org/internal/f32color"
+	"gioui.org/io/semantic"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/text"
+	"gioui.org/unit"
+	"gioui.org/widget"
+)
+
+// DropdownStyle displays the selected option of a dropdown in an outlined
+// field with an arrow, and the options in a popup list below the field.
+type DropdownStyle struct {
+	Font     font.Font
+	TextSize unit.Sp
+	// Color is the color of the option labels.
+	Color color.NRGBA
+	// Hint is displayed in the field when no option is selected.
+	Hint string
+	// HintColor is the color of the hint.
+	HintColor color.NRGBA
+	// Description describes the dropdown to screen readers, such as the
+	// name of the value it chooses.
+	Description string
+	// BorderColor is the color of the field outline and the popup border.
+	BorderColor color.NRGBA
+	// FocusColor is the color of the field outline while the field has
+	// the keyboard focus.
+	FocusColor color.NRGBA
+	// ArrowColor is the color of the arrow of the field.
+	ArrowColor color.NRGBA
+	// Background is the color of the popup background.
+	Background color.NRGBA
+	// HighlightColor is the background color of the highlighted option.
+	HighlightColor color.NRGBA
+	// SelectionColor is the background color of the selected option.
+	SelectionColor color.NRGBA
+	CornerRadius   unit.Dp
+	// Inset is the padding around the content of the field and every
+	// option.
+	Inset layout.Inset
+	// MaxHeight is the maximum height of the popup list.
+	MaxHeight unit.Dp
+	// Editor is the style of the field of an editable dropdown.
+	Editor   EditorStyle
+	List     ListStyle
+	Dropdown *widget.Dropdown
+
+	shaper *text.Shaper
+}
+
+func Dropdown(th *Theme, dropdown *widget.Dropdown) DropdownStyle {
+	return DropdownStyle{
+		Font: font.Font{
+			Typeface: th.Face,
+		},
+		TextSize:       th.TextSize,
+		Color:          th.Palette.Fg,
+		HintColor:      f32color.MulAlpha(th.Palette.Fg, 0xbb),
+		BorderColor:    f32color.MulAlpha(th.Palette.Fg, 0x40),
+		FocusColor:     th.Palette.ContrastBg,
+		ArrowColor:     f32color.MulAlpha(th.Palette.Fg, 0xbb),
+		Background:     th.Palette.Bg,
+		HighlightColor: f32color.MulAlpha(th.Palette.ContrastBg, 0x30),
+		SelectionColor: f32color.MulAlpha(th.Palette.Fg, 0x20),
+		CornerRadius:   4,
+		Inset: layout.Inset{
+			Top: 8, Bottom: 8,
+			Left: 12, Right: 12,
+		},
+		MaxHeight: 240,
+		Editor:    Editor(th, &dropdown.Editor, ""),
+		List:      List(th, &dropdown.List),
+		Dropdown:  dropdown,
+		shaper:    th.Shaper,
+	}
+}
+
+func (d DropdownStyle) Layout(gtx layout.Context) layout.Dimensions {
+	return d.Dropdown.Layout(gtx, d.layoutField, d.layoutPopup)
+}
+
+func (d DropdownStyle) layoutField(gtx layout.Context) layout.Dimensions {
+	if desc := d.Description; desc != "" {
+		semantic.DescriptionOp(desc).Add(gtx.Ops)
+	}
+	m := op.Record(gtx.Ops)
+	dims := d.Inset.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+			layout.Flexed(1, d.layoutValue),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				size := gtx.Dp(20)
+				d.paintArrow(gtx, size, d.Dropdown.Active())
+				return layout.Dimensions{Size: image.Pt(size, size)}
+			}),
+		)
+	})
+	call := m.Stop()
+	c, bw := d.BorderColor, max(gtx.Dp(1), 1)
+	if d.Dropdown.Focused() {
+		c, bw = d.FocusColor, max(gtx.Dp(2), 1)
+	}
+	r := image.Rectangle{Max: dims.Size}.Inset(bw / 2)
+	paint.FillShape(gtx.Ops, c, clip.Stroke{Path: clip.UniformRRect(r, gtx.Dp(d.CornerRadius)).Path(gtx.Ops), Width: float32(bw)}.Op())
+	call.Add(gtx.Ops)
+	return dims
+}
+
+func (d DropdownStyle) layoutValue(gtx layout.Context) layout.Dimensions {
+	if d.Dropdown.Editable {
+		e := d.Editor
+		e.Hint = d.Hint
+		return e.Layout(gtx)
+	}
+	if i, ok := d.Dropdown.Selected(); ok {
+		return d.label(gtx, d.Dropdown.Options[i], d.Color)
+	}
+	return d.label(gtx, d.Hint, d.HintColor)
+}
+
+func (d DropdownStyle) layoutPopup(gtx layout.Context) layout.Dimensions {
+	gtx.Constraints.Max.Y = min(gtx.Constraints.Max.Y, gtx.Dp(d.MaxHeight))
+	m := op.Record(gtx.Ops)
+	dims := d.Dropdown.LayoutOptions(gtx, d.List.Layout, d.layoutOption)
+	call := m.Stop()
+	rr := gtx.Dp(d.CornerRadius)
+	bw := max(gtx.Dp(1), 1)
+	paint.FillShape(gtx.Ops, d.BorderColor, clip.UniformRRect(image.Rectangle{Min: image.Pt(-bw, -bw), Max: dims.Size.Add(image.Pt(bw, bw))}, rr).Op(gtx.Ops))
+	defer clip.UniformRRect(image.Rectangle{Max: dims.Size}, rr).Push(gtx.Ops).Pop()
+	paint.Fill(gtx.Ops, d.Background)
+	call.Add(gtx.Ops)
+	return dims
+}
+
+func (d DropdownStyle) layoutOption(gtx layout.Context, opt widget.DropdownOption) layout.Dimensions {
+	gtx.Constraints.Min.X = gtx.Constraints.Max.X
+	m := op.Record(gtx.Ops)
+	dims := d.Inset.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		dims := d.label(gtx, d.Dropdown.Options[opt.Index], d.Color)
+		dims.Size.X = gtx.Constraints.Max.X
+		return dims
+	})
+	call := m.Stop()
+	if opt.Selected {
+		paint.FillShape(gtx.Ops, d.SelectionColor, clip.Rect{Max: dims.Size}.Op())
+	}
+	if opt.Highlighted {
+		paint.FillShape(gtx.Ops, d.HighlightColor, clip.Rect{Max: dims.Size}.Op())
+	}
+	call.Add(gtx.Ops)
+	return dims
+}
+
+func (d DropdownStyle) label(gtx layout.Context, txt string, c color.NRGBA) layout.Dimensions {
+	gtx.Constraints.Min = image.Point{}
+	lbl := widget.Label{MaxLines: 1}
+	return lbl.Layout(gtx, d.shaper, d.Font, d.TextSize, txt, colorMaterial(gtx.Ops, c))
+}
+
+// paintArrow draws a triangle pointing down, or up while the popup is
+// displayed.
+func (d DropdownStyle) paintArrow(gtx layout.Context, size int, active bool) {
+	s := float32(size)
+	c := f32.Pt(s/2, s/2)
+	r := s / 5
+	if active {
+		r = -r
+	}
+	var p clip.Path
+	p.Begin(gtx.Ops)
+	p.MoveTo(c.Add(f32.Pt(-r, -r/2)))
+	p.LineTo(c.Add(f32.Pt(r, -r/2)))
+	p.LineTo(c.Add(f32.Pt(0, r/2)))
+	p.Close()
+	paint.FillShape(gtx.Ops, d.ArrowColor, clip.Outline{Path: p.End()}.Op())
+}
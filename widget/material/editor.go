@@ -32,7 +32,14 @@ type EditorStyle struct {
 	HintColor color.NRGBA
 	// SelectionColor is the color of the background for selected text.
 	SelectionColor color.NRGBA
-	Editor         *widget.Editor
+	// MatchColor is the color of the background for matches of the
+	// editor's search query.
+	MatchColor color.NRGBA
+	// MisspellingColor is the color of the lines under misspelled words.
+	MisspellingColor color.NRGBA
+	// Menu is the style of the context menu of the editor.
+	Menu   MenuStyle
+	Editor *widget.Editor
 
 	shaper *text.Shaper
 }
@@ -43,12 +50,15 @@ func Editor(th *Theme, editor *widget.Editor, hint string) EditorStyle {
 		Font: font.Font{
 			Typeface: th.Face,
 		},
-		TextSize:       th.TextSize,
-		Color:          th.Palette.Fg,
-		shaper:         th.Shaper,
-		Hint:           hint,
-		HintColor:      f32color.MulAlpha(th.Palette.Fg, 0xbb),
-		SelectionColor: f32color.MulAlpha(th.Palette.ContrastBg, 0x60),
+		TextSize:         th.TextSize,
+		Color:            th.Palette.Fg,
+		shaper:           th.Shaper,
+		Hint:             hint,
+		HintColor:        f32color.MulAlpha(th.Palette.Fg, 0xbb),
+		SelectionColor:   f32color.MulAlpha(th.Palette.ContrastBg, 0x60),
+		MatchColor:       f32color.MulAlpha(th.Palette.ContrastBg, 0x30),
+		MisspellingColor: color.NRGBA{R: 0xd0, G: 0x20, B: 0x20, A: 0xff},
+		Menu:             Menu(th, nil),
 	}
 }
 
@@ -63,6 +73,12 @@ func (e EditorStyle) Layout(gtx layout.Context) layout.Dimensions {
 	selectionColorMacro := op.Record(gtx.Ops)
 	paint.ColorOp{Color: blendDisabledColor(!gtx.Enabled(), e.SelectionColor)}.Add(gtx.Ops)
 	selectionColor := selectionColorMacro.Stop()
+	matchColorMacro := op.Record(gtx.Ops)
+	paint.ColorOp{Color: blendDisabledColor(!gtx.Enabled(), e.MatchColor)}.Add(gtx.Ops)
+	matchColor := matchColorMacro.Stop()
+	misspellingColorMacro := op.Record(gtx.Ops)
+	paint.ColorOp{Color: blendDisabledColor(!gtx.Enabled(), e.MisspellingColor)}.Add(gtx.Ops)
+	misspellingColor := misspellingColorMacro.Stop()
 
 	var maxlines int
 	if e.Editor.SingleLine {
@@ -75,6 +91,8 @@ func (e EditorStyle) Layout(gtx layout.Context) layout.Dimensions {
 		MaxLines:        maxlines,
 		LineHeight:      e.LineHeight,
 		LineHeightScale: e.LineHeightScale,
+		LetterSpacing:   e.Editor.LetterSpacing,
+		WordSpacing:     e.Editor.WordSpacing,
 	}
 	dims := tl.Layout(gtx, e.shaper, e.Font, e.TextSize, e.Hint, hintColor)
 	call := macro.Stop()
@@ -87,10 +105,14 @@ func (e EditorStyle) Layout(gtx layout.Context) layout.Dimensions {
 	}
 	e.Editor.LineHeight = e.LineHeight
 	e.Editor.LineHeightScale = e.LineHeightScale
-	dims = e.Editor.Layout(gtx, e.shaper, e.Font, e.TextSize, textColor, selectionColor)
+	dims = e.Editor.LayoutMatches(gtx, e.shaper, e.Font, e.TextSize, textColor, selectionColor, matchColor)
+	e.Editor.PaintMisspellings(gtx, misspellingColor)
 	if e.Editor.Len() == 0 {
 		call.Add(gtx.Ops)
 	}
+	menu := e.Editor.ContextMenu()
+	e.Menu.Menu = &menu.Menu
+	menu.LayoutMenu(gtx, e.Menu.Layout)
 	return dims
 }
 
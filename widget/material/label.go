@@ -44,6 +44,15 @@ type LabelStyle struct {
 	// LineHeightScale applies a scaling factor to the LineHeight. If zero, a
 	// sensible default will be used.
 	LineHeightScale float32
+	// LetterSpacing is extra space added after every glyph cluster.
+	LetterSpacing unit.Sp
+	// WordSpacing is extra space added to every word separator.
+	WordSpacing unit.Sp
+	// Decoration configures lines drawn alongside the text, such as underlines.
+	Decoration text.Decoration
+	// Vertical lays the text out in columns running top to bottom. It is ignored
+	// if State is set.
+	Vertical bool
 
 	// Shaper is the text shaper used to display this labe. This field is automatically
 	// set using by all constructor functions. If constructing a LabelStyle literal, you
@@ -52,6 +61,9 @@ type LabelStyle struct {
 	// State provides text selection state for the label. If not set, the label cannot
 	// be selected or copied interactively.
 	State *widget.Selectable
+	// Menu is the style of the context menu of State. The menu is not
+	// displayed if Menu is not initialized by a constructor function.
+	Menu MenuStyle
 }
 
 func H1(th *Theme, txt string) LabelStyle {
@@ -117,6 +129,7 @@ func Label(th *Theme, size unit.Sp, txt string) LabelStyle {
 		SelectionColor: f32color.MulAlpha(th.Palette.ContrastBg, 0x60),
 		TextSize:       size,
 		Shaper:         th.Shaper,
+		Menu:           Menu(th, nil),
 	}
 	l.Font.Typeface = th.Face
 	return l
@@ -140,7 +153,16 @@ func (l LabelStyle) Layout(gtx layout.Context) layout.Dimensions {
 		l.State.WrapPolicy = l.WrapPolicy
 		l.State.LineHeight = l.LineHeight
 		l.State.LineHeightScale = l.LineHeightScale
-		return l.State.Layout(gtx, l.Shaper, l.Font, l.TextSize, textColor, selectColor)
+		l.State.LetterSpacing = l.LetterSpacing
+		l.State.WordSpacing = l.WordSpacing
+		l.State.Decoration = l.Decoration
+		dims := l.State.Layout(gtx, l.Shaper, l.Font, l.TextSize, textColor, selectColor)
+		if l.Menu.shaper != nil {
+			menu := l.State.ContextMenu()
+			l.Menu.Menu = &menu.Menu
+			menu.LayoutMenu(gtx, l.Menu.Layout)
+		}
+		return dims
 	}
 	tl := widget.Label{
 		Alignment:       l.Alignment,
@@ -149,6 +171,10 @@ func (l LabelStyle) Layout(gtx layout.Context) layout.Dimensions {
 		WrapPolicy:      l.WrapPolicy,
 		LineHeight:      l.LineHeight,
 		LineHeightScale: l.LineHeightScale,
+		LetterSpacing:   l.LetterSpacing,
+		WordSpacing:     l.WordSpacing,
+		Decoration:      l.Decoration,
+		Vertical:        l.Vertical,
 	}
 	return tl.Layout(gtx, l.Shaper, l.Font, l.TextSize, l.Text, textColor)
 }
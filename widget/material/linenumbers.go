@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package material
+
+import (
+	"image/color"
+	"strconv"
+
+	"gioui.org/font"
+	"gioui.org/internal/f32color"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/paint"
+	"gioui.org/text"
+	"gioui.org/unit"
+	"gioui.org/widget"
+)
+
+// LineNumbersStyle displays the line numbers of an editor in a gutter.
+// Lines continuing a wrapped paragraph are not numbered.
+type LineNumbersStyle struct {
+	Font     font.Font
+	TextSize unit.Sp
+	// Color is the color of the line numbers.
+	Color color.NRGBA
+	// Alignment is the alignment of line numbers narrower than the gutter.
+	Alignment text.Alignment
+	Gutter    *widget.Gutter
+	Editor    *widget.Editor
+
+	shaper *text.Shaper
+}
+
+func LineNumbers(th *Theme, gutter *widget.Gutter, editor *widget.Editor) LineNumbersStyle {
+	return LineNumbersStyle{
+		Font: font.Font{
+			Typeface: th.Face,
+		},
+		TextSize:  th.TextSize,
+		Color:     f32color.MulAlpha(th.Palette.Fg, 0xbb),
+		Alignment: text.End,
+		Gutter:    gutter,
+		Editor:    editor,
+		shaper:    th.Shaper,
+	}
+}
+
+func (l LineNumbersStyle) Layout(gtx layout.Context) layout.Dimensions {
+	colMacro := op.Record(gtx.Ops)
+	paint.ColorOp{Color: l.Color}.Add(gtx.Ops)
+	textColor := colMacro.Stop()
+	return l.Gutter.Layout(gtx, l.Editor, l.Alignment, func(gtx layout.Context, line widget.LineInfo) layout.Dimensions {
+		if line.Wrapped {
+			return layout.Dimensions{}
+		}
+		lbl := widget.Label{MaxLines: 1}
+		return lbl.Layout(gtx, l.shaper, l.Font, l.TextSize, strconv.Itoa(line.Paragraph+1), textColor)
+	})
+}
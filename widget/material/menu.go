@@ -0,0 +1,258 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package material
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gioui.org/f32"
+	"gioui.org/font"
+	"gioui.org/internal/f32color"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/text"
+	"gioui.org/unit"
+	"gioui.org/widget"
+)
+
+// MenuStyle displays the items of a menu in a column, with their labels
+// and shortcuts aligned.
+type MenuStyle struct {
+	Font     font.Font
+	TextSize unit.Sp
+	// Color is the color of the item labels.
+	Color color.NRGBA
+	// ShortcutColor is the color of the item shortcuts.
+	ShortcutColor color.NRGBA
+	// Background is the color of the menu background.
+	Background color.NRGBA
+	// HoverColor is the background color of the item under the pointer.
+	HoverColor color.NRGBA
+	// BorderColor is the color of the menu border and the separators.
+	BorderColor  color.NRGBA
+	CornerRadius unit.Dp
+	// Inset is the padding around the content of every item.
+	Inset layout.Inset
+	Menu  *widget.Menu
+
+	shaper *text.Shaper
+}
+
+func Menu(th *Theme, menu *widget.Menu) MenuStyle {
+	return MenuStyle{
+		Font: font.Font{
+			Typeface: th.Face,
+		},
+		TextSize:      th.TextSize * 14.0 / 16.0,
+		Color:         th.Palette.Fg,
+		ShortcutColor: f32color.MulAlpha(th.Palette.Fg, 0xbb),
+		Background:    th.Palette.Bg,
+		HoverColor:    f32color.MulAlpha(th.Palette.ContrastBg, 0x30),
+		BorderColor:   f32color.MulAlpha(th.Palette.Fg, 0x40),
+		CornerRadius:  4,
+		Inset: layout.Inset{
+			Top: 6, Bottom: 6,
+			Left: 16, Right: 16,
+		},
+		Menu:   menu,
+		shaper: th.Shaper,
+	}
+}
+
+func (m MenuStyle) Layout(gtx layout.Context) layout.Dimensions {
+	gtx.Constraints.Min = image.Point{}
+	items := m.Menu.Items
+	// Measure the widest label and shortcut, so that the items line up.
+	var labelWidth, shortcutWidth int
+	var checks, submenus bool
+	for _, it := range items {
+		macro := op.Record(gtx.Ops)
+		labelWidth = max(labelWidth, m.label(gtx, it.Label, op.CallOp{}).Size.X)
+		if sc := it.ShortcutLabel(); sc != "" {
+			shortcutWidth = max(shortcutWidth, m.label(gtx, sc, op.CallOp{}).Size.X)
+		}
+		macro.Stop()
+		checks = checks || it.Kind != widget.ActionMenuItem
+		submenus = submenus || it.Submenu != nil
+	}
+	// Reserve columns for the check marks and the submenu arrows.
+	var cols menuColumns
+	if checks {
+		cols.check = gtx.Dp(24)
+	}
+	if submenus {
+		cols.arrow = gtx.Dp(24)
+	}
+	cols.shortcut = shortcutWidth
+	left, right := gtx.Dp(m.Inset.Left), gtx.Dp(m.Inset.Right)
+	width := left + cols.check + labelWidth + cols.arrow + right
+	if shortcutWidth > 0 {
+		width += gtx.Dp(24) + shortcutWidth
+	}
+	open, opened := m.Menu.Opened()
+	macro := op.Record(gtx.Ops)
+	var height, openY int
+	for i, it := range items {
+		if i == open {
+			openY = height
+		}
+		trans := op.Offset(image.Pt(0, height)).Push(gtx.Ops)
+		var dims layout.Dimensions
+		if it.Label == "" {
+			dims = m.layoutSeparator(gtx, width)
+		} else {
+			dims = m.layoutItem(gtx, i, width, cols)
+		}
+		trans.Pop()
+		height += dims.Size.Y
+	}
+	call := macro.Stop()
+
+	size := image.Pt(width, height)
+	rr := gtx.Dp(m.CornerRadius)
+	bw := max(gtx.Dp(1), 1)
+	paint.FillShape(gtx.Ops, m.BorderColor, clip.UniformRRect(image.Rectangle{Min: image.Pt(-bw, -bw), Max: size.Add(image.Pt(bw, bw))}, rr).Op(gtx.Ops))
+	area := clip.UniformRRect(image.Rectangle{Max: size}, rr).Push(gtx.Ops)
+	paint.Fill(gtx.Ops, m.Background)
+	call.Add(gtx.Ops)
+	area.Pop()
+	if opened {
+		// Display the submenu next to its item.
+		sub := m
+		sub.Menu = items[open].Submenu
+		trans := op.Offset(image.Pt(width, openY)).Push(gtx.Ops)
+		sub.Layout(gtx)
+		trans.Pop()
+	}
+	return layout.Dimensions{Size: size}
+}
+
+// menuColumns are the widths of the optional columns of menu items.
+type menuColumns struct {
+	check, shortcut, arrow int
+}
+
+func (m MenuStyle) layoutItem(gtx layout.Context, i, width int, cols menuColumns) layout.Dimensions {
+	it := m.Menu.Items[i]
+	click := m.Menu.Clickable(i)
+	gtx.Constraints = layout.Constraints{
+		Min: image.Pt(width, 0),
+		Max: image.Pt(width, gtx.Constraints.Max.Y),
+	}
+	if it.Disabled {
+		gtx = gtx.Disabled()
+	}
+	return click.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		macro := op.Record(gtx.Ops)
+		dims := m.Inset.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			fg := blendDisabledColor(it.Disabled, m.Color)
+			trans := op.Offset(image.Pt(cols.check, 0)).Push(gtx.Ops)
+			dims := layoutMnemonic(gtx, m.shaper, m.Font, m.TextSize, it.Label, it.Mnemonic, fg)
+			trans.Pop()
+			end := gtx.Constraints.Max.X
+			if cols.check > 0 && it.Checked {
+				drawCheckMark(gtx.Ops, it.Kind, image.Rect(0, 0, cols.check, dims.Size.Y), fg)
+			}
+			if it.Submenu != nil {
+				drawMenuArrow(gtx.Ops, image.Rect(end-cols.arrow, 0, end, dims.Size.Y), gtx.Dp(4), fg)
+			}
+			if sc := it.ShortcutLabel(); sc != "" {
+				trans := op.Offset(image.Pt(end-cols.arrow-cols.shortcut, 0)).Push(gtx.Ops)
+				m.label(gtx, sc, colorMaterial(gtx.Ops, blendDisabledColor(it.Disabled, m.ShortcutColor)))
+				trans.Pop()
+			}
+			dims.Size.X = end
+			return dims
+		})
+		call := macro.Stop()
+		if h, ok := m.Menu.Highlighted(); ok && h == i && !it.Disabled {
+			paint.FillShape(gtx.Ops, m.HoverColor, clip.Rect{Max: dims.Size}.Op())
+		}
+		call.Add(gtx.Ops)
+		return dims
+	})
+}
+
+// drawCheckMark draws the mark of a checked item of kind k, centered in r.
+func drawCheckMark(ops *op.Ops, k widget.MenuItemKind, r image.Rectangle, c color.NRGBA) {
+	s := float32(min(r.Dx(), r.Dy()))
+	ctr := layout.FPt(r.Min.Add(r.Max)).Mul(.5)
+	if k == widget.RadioMenuItem {
+		d := s * .3
+		dot := image.Rectangle{Min: ctr.Sub(f32.Pt(d/2, d/2)).Round(), Max: ctr.Add(f32.Pt(d/2, d/2)).Round()}
+		paint.FillShape(ops, c, clip.Ellipse(dot).Op(ops))
+		return
+	}
+	var p clip.Path
+	p.Begin(ops)
+	p.MoveTo(ctr.Add(f32.Pt(-.3*s, 0)))
+	p.LineTo(ctr.Add(f32.Pt(-.1*s, .2*s)))
+	p.LineTo(ctr.Add(f32.Pt(.3*s, -.2*s)))
+	paint.FillShape(ops, c, clip.Stroke{Path: p.End(), Width: max(s*.08, 1)}.Op())
+}
+
+// drawMenuArrow draws the arrow of an item with a submenu, of half height
+// a, centered in r.
+func drawMenuArrow(ops *op.Ops, r image.Rectangle, a int, c color.NRGBA) {
+	ctr := layout.FPt(r.Min.Add(r.Max)).Mul(.5)
+	h := float32(a)
+	var p clip.Path
+	p.Begin(ops)
+	p.MoveTo(ctr.Add(f32.Pt(-h/2, -h)))
+	p.LineTo(ctr.Add(f32.Pt(h/2, 0)))
+	p.LineTo(ctr.Add(f32.Pt(-h/2, h)))
+	p.Close()
+	paint.FillShape(ops, c, clip.Outline{Path: p.End()}.Op())
+}
+
+func (m MenuStyle) layoutSeparator(gtx layout.Context, width int) layout.Dimensions {
+	height := gtx.Dp(9)
+	y := height / 2
+	paint.FillShape(gtx.Ops, m.BorderColor, clip.Rect{Min: image.Pt(0, y), Max: image.Pt(width, y+max(gtx.Dp(1), 1))}.Op())
+	return layout.Dimensions{Size: image.Pt(width, height)}
+}
+
+func (m MenuStyle) label(gtx layout.Context, txt string, material op.CallOp) layout.Dimensions {
+	gtx.Constraints.Min = image.Point{}
+	lbl := widget.Label{MaxLines: 1}
+	return lbl.Layout(gtx, m.shaper, m.Font, m.TextSize, txt, material)
+}
+
+// layoutMnemonic lays out the single line label txt, underlining the first
+// occurrence of the mnemonic r, ignoring case.
+func layoutMnemonic(gtx layout.Context, shaper *text.Shaper, fnt font.Font, size unit.Sp, txt string, r rune, c color.NRGBA) layout.Dimensions {
+	gtx.Constraints.Min = image.Point{}
+	lbl := widget.Label{MaxLines: 1}
+	dims := lbl.Layout(gtx, shaper, fnt, size, txt, colorMaterial(gtx.Ops, c))
+	i := -1
+	if r != 0 {
+		i = strings.IndexFunc(txt, func(c rune) bool {
+			return unicode.ToLower(c) == unicode.ToLower(r)
+		})
+	}
+	if i == -1 {
+		return dims
+	}
+	_, n := utf8.DecodeRuneInString(txt[i:])
+	macro := op.Record(gtx.Ops)
+	x0 := lbl.Layout(gtx, shaper, fnt, size, txt[:i], op.CallOp{}).Size.X
+	x1 := lbl.Layout(gtx, shaper, fnt, size, txt[:i+n], op.CallOp{}).Size.X
+	macro.Stop()
+	t := max(gtx.Dp(1), 1)
+	y := dims.Size.Y - dims.Baseline + t
+	paint.FillShape(gtx.Ops, c, clip.Rect{Min: image.Pt(x0, y), Max: image.Pt(x1, y+t)}.Op())
+	return dims
+}
+
+// colorMaterial records a paint material of color c.
+func colorMaterial(ops *op.Ops, c color.NRGBA) op.CallOp {
+	m := op.Record(ops)
+	paint.ColorOp{Color: c}.Add(ops)
+	return m.Stop()
+}
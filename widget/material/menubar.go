@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package material
+
+import (
+	"image"
+	"image/color"
+
+	"gioui.org/font"
+	"gioui.org/internal/f32color"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/text"
+	"gioui.org/unit"
+	"gioui.org/widget"
+)
+
+// MenuBarStyle displays the items of a menu bar in a row spanning the
+// available width, and the displayed menu below its bar item.
+type MenuBarStyle struct {
+	Font     font.Font
+	TextSize unit.Sp
+	// Color is the color of the bar item labels.
+	Color color.NRGBA
+	// Background is the color of the bar background.
+	Background color.NRGBA
+	// HoverColor is the background color of the bar item under the
+	// pointer or whose menu is displayed.
+	HoverColor color.NRGBA
+	// Inset is the padding around the labels of the bar items.
+	Inset layout.Inset
+	// Menu is the style of the menus. Its Menu field is ignored.
+	Menu    MenuStyle
+	MenuBar *widget.MenuBar
+
+	shaper *text.Shaper
+}
+
+func MenuBar(th *Theme, bar *widget.MenuBar) MenuBarStyle {
+	return MenuBarStyle{
+		Font: font.Font{
+			Typeface: th.Face,
+		},
+		TextSize:   th.TextSize * 14.0 / 16.0,
+		Color:      th.Palette.Fg,
+		Background: th.Palette.Bg,
+		HoverColor: f32color.MulAlpha(th.Palette.ContrastBg, 0x30),
+		Inset: layout.Inset{
+			Top: 6, Bottom: 6,
+			Left: 10, Right: 10,
+		},
+		Menu:    Menu(th, nil),
+		MenuBar: bar,
+		shaper:  th.Shaper,
+	}
+}
+
+func (b MenuBarStyle) Layout(gtx layout.Context) layout.Dimensions {
+	gtx.Constraints.Min = image.Pt(gtx.Constraints.Max.X, 0)
+	macro := op.Record(gtx.Ops)
+	dims := b.MenuBar.Layout(gtx, b.layoutItem, func(gtx layout.Context, m *widget.Menu) layout.Dimensions {
+		s := b.Menu
+		s.Menu = m
+		return s.Layout(gtx)
+	})
+	call := macro.Stop()
+	paint.FillShape(gtx.Ops, b.Background, clip.Rect{Max: dims.Size}.Op())
+	call.Add(gtx.Ops)
+	return dims
+}
+
+func (b MenuBarStyle) layoutItem(gtx layout.Context, i int) layout.Dimensions {
+	it := b.MenuBar.Menu.Items[i]
+	macro := op.Record(gtx.Ops)
+	dims := b.Inset.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layoutMnemonic(gtx, b.shaper, b.Font, b.TextSize, it.Label, it.Mnemonic, blendDisabledColor(it.Disabled, b.Color))
+	})
+	call := macro.Stop()
+	open, opened := b.MenuBar.Opened()
+	if (opened && open == i) || b.MenuBar.Menu.Clickable(i).Hovered() {
+		paint.FillShape(gtx.Ops, b.HoverColor, clip.Rect{Max: dims.Size}.Op())
+	}
+	call.Add(gtx.Ops)
+	return dims
+}
@@ -0,0 +1,37 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package material
+
+import (
+	"image/color"
+
+	"gioui.org/layout"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/widget"
+)
+
+// LayersStyle displays the layers of an overlay, dimming the content
+// below modal layers.
+type LayersStyle struct {
+	Overlay *widget.Overlay
+	// ScrimColor is the color drawn over the content below modal layers.
+	ScrimColor color.NRGBA
+}
+
+// Layers returns the style of overlay. It isn't named Overlay, which is
+// taken by the scroll bar anchor strategy.
+func Layers(th *Theme, overlay *widget.Overlay) LayersStyle {
+	return LayersStyle{
+		Overlay:    overlay,
+		ScrimColor: color.NRGBA{A: 0x66},
+	}
+}
+
+// Layout the content with w and the layers of the overlay above it.
+func (o LayersStyle) Layout(gtx layout.Context, w layout.Widget) layout.Dimensions {
+	return o.Overlay.Layout(gtx, w, func(gtx layout.Context) layout.Dimensions {
+		paint.FillShape(gtx.Ops, o.ScrimColor, clip.Rect{Max: gtx.Constraints.Min}.Op())
+		return layout.Dimensions{Size: gtx.Constraints.Min}
+	})
+}
@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package material
+
+import (
+	"image"
+	"image/color"
+
+	"gioui.org/internal/f32color"
+	"gioui.org/layout"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget"
+)
+
+// SplitStyle displays the divider of a split as a line centered in the
+// area that can be dragged.
+type SplitStyle struct {
+	// Color is the color of the line.
+	Color color.NRGBA
+	// DragColor is the color of the line while it's being dragged.
+	DragColor color.NRGBA
+	// Width is the width of the line.
+	Width unit.Dp
+	// HandleWidth is the width of the area that can be dragged.
+	HandleWidth unit.Dp
+	Split       *widget.Split
+}
+
+func Split(th *Theme, split *widget.Split) SplitStyle {
+	return SplitStyle{
+		Color:       f32color.MulAlpha(th.Palette.Fg, 0x40),
+		DragColor:   th.Palette.ContrastBg,
+		Width:       1,
+		HandleWidth: 8,
+		Split:       split,
+	}
+}
+
+func (s SplitStyle) Layout(gtx layout.Context, first, second layout.Widget) layout.Dimensions {
+	return s.Split.Layout(gtx, first, second, s.layoutDivider)
+}
+
+func (s SplitStyle) layoutDivider(gtx layout.Context) layout.Dimensions {
+	axis := s.Split.Axis
+	handle := gtx.Dp(s.HandleWidth)
+	width := max(gtx.Dp(s.Width), 1)
+	cross := axis.Convert(gtx.Constraints.Min).Y
+	col := s.Color
+	if s.Split.Dragging() {
+		col = s.DragColor
+	}
+	x := (handle - width) / 2
+	line := image.Rectangle{
+		Min: axis.Convert(image.Pt(x, 0)),
+		Max: axis.Convert(image.Pt(x+width, cross)),
+	}
+	paint.FillShape(gtx.Ops, col, clip.Rect(line).Op())
+	return layout.Dimensions{Size: axis.Convert(image.Pt(handle, cross))}
+}
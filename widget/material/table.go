@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package material
+
+import (
+	"image"
+	"image/color"
+
+	"gioui.org/f32"
+	"gioui.org/internal/f32color"
+	"gioui.org/layout"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/widget"
+)
+
+// TableStyle displays the cells of a table separated by grid lines, with
+// shaded fixed cells, highlighted selected cells and scroll bars.
+type TableStyle struct {
+	Table *widget.Table
+	// HeaderBackground is the background color of the fixed cells.
+	HeaderBackground color.NRGBA
+	// GridColor is the color of the lines between the cells.
+	GridColor color.NRGBA
+	// SelectionColor is the background color of the selected cells.
+	SelectionColor color.NRGBA
+	// CaretColor is the color of the outline of the caret cell while the
+	// table has the keyboard focus.
+	CaretColor color.NRGBA
+	// SortColor is the color of the sort indicator in the header.
+	SortColor              color.NRGBA
+	HScrollbar, VScrollbar ScrollbarStyle
+}
+
+func Table(th *Theme, table *widget.Table) TableStyle {
+	return TableStyle{
+		Table:            table,
+		HeaderBackground: f32color.MulAlpha(th.Palette.Fg, 0x10),
+		GridColor:        f32color.MulAlpha(th.Palette.Fg, 0x30),
+		SelectionColor:   f32color.MulAlpha(th.Palette.ContrastBg, 0x40),
+		CaretColor:       th.Palette.ContrastBg,
+		SortColor:        f32color.MulAlpha(th.Palette.Fg, 0xbb),
+		HScrollbar:       Scrollbar(th, &table.HScrollbar),
+		VScrollbar:       Scrollbar(th, &table.VScrollbar),
+	}
+}
+
+// Layout the visible cells of a table of rows rows with w, and the scroll
+// bars over the table.
+func (t TableStyle) Layout(gtx layout.Context, rows int, w widget.TableElement) layout.Dimensions {
+	dims := t.Table.Layout(gtx, rows, func(gtx layout.Context, cell widget.TableCell) layout.Dimensions {
+		return t.layoutCell(gtx, cell, w)
+	})
+	gtx.Constraints = layout.Exact(dims.Size)
+	start, end := t.Table.Viewport(layout.Vertical)
+	layout.E.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return t.VScrollbar.Layout(gtx, layout.Vertical, start, end)
+	})
+	if d := t.Table.VScrollbar.ScrollDistance(); d != 0 {
+		t.Table.ScrollBy(layout.Vertical, d)
+	}
+	start, end = t.Table.Viewport(layout.Horizontal)
+	layout.S.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return t.HScrollbar.Layout(gtx, layout.Horizontal, start, end)
+	})
+	if d := t.Table.HScrollbar.ScrollDistance(); d != 0 {
+		t.Table.ScrollBy(layout.Horizontal, d)
+	}
+	return dims
+}
+
+func (t TableStyle) layoutCell(gtx layout.Context, cell widget.TableCell, w widget.TableElement) layout.Dimensions {
+	size := gtx.Constraints.Min
+	header := cell.Row < t.Table.FixedRows || cell.Column < t.Table.FixedColumns
+	switch {
+	case t.Table.Selected(cell):
+		paint.FillShape(gtx.Ops, t.SelectionColor, clip.Rect{Max: size}.Op())
+	case header:
+		paint.FillShape(gtx.Ops, t.HeaderBackground, clip.Rect{Max: size}.Op())
+	}
+	w(gtx, cell)
+	lw := max(gtx.Dp(1), 1)
+	paint.FillShape(gtx.Ops, t.GridColor, clip.Rect{Min: image.Pt(size.X-lw, 0), Max: size}.Op())
+	paint.FillShape(gtx.Ops, t.GridColor, clip.Rect{Min: image.Pt(0, size.Y-lw), Max: size}.Op())
+	if col, desc, ok := t.Table.Sort(); ok && cell.Row == 0 && t.Table.FixedRows > 0 && cell.Column == col {
+		t.paintSortIndicator(gtx, size, desc)
+	}
+	if c, ok := t.Table.Caret(); ok && c == cell && t.Table.Focused() {
+		cw := max(gtx.Dp(2), 1)
+		r := image.Rectangle{Max: size}
+		paint.FillShape(gtx.Ops, t.CaretColor, clip.Stroke{Path: clip.Rect(r.Inset(cw / 2)).Path(), Width: float32(cw)}.Op())
+	}
+	return layout.Dimensions{Size: size}
+}
+
+// paintSortIndicator draws a triangle at the end of a header cell, pointing
+// up for ascending order and down for descending order.
+func (t TableStyle) paintSortIndicator(gtx layout.Context, size image.Point, descending bool) {
+	r := float32(gtx.Dp(4))
+	c := f32.Pt(float32(size.X)-2*r, float32(size.Y)/2)
+	if descending {
+		r = -r
+	}
+	var p clip.Path
+	p.Begin(gtx.Ops)
+	p.MoveTo(c.Add(f32.Pt(-r, r/2)))
+	p.LineTo(c.Add(f32.Pt(r, r/2)))
+	p.LineTo(c.Add(f32.Pt(0, -r/2)))
+	p.Close()
+	paint.FillShape(gtx.Ops, t.SortColor, clip.Outline{Path: p.End()}.Op())
+}
@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package material
+
+import (
+	"image"
+	"image/color"
+
+	"gioui.org/f32"
+	"gioui.org/internal/f32color"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget"
+)
+
+// TabsStyle displays a tab bar with a line under the selected tab, and
+// a close button in every tab of closable tabs.
+type TabsStyle struct {
+	Tabs *widget.Tabs
+	// Inset is the padding around the content of every tab.
+	Inset layout.Inset
+	// IndicatorColor is the color of the line under the selected tab.
+	IndicatorColor color.NRGBA
+	// IndicatorHeight is the thickness of the line under the selected
+	// tab.
+	IndicatorHeight unit.Dp
+	// HoverColor is the background color of the tab under the pointer.
+	HoverColor color.NRGBA
+	// BorderColor is the color of the line under the bar.
+	BorderColor color.NRGBA
+	// CloseColor is the color of the close buttons.
+	CloseColor color.NRGBA
+	// CloseSize is the size of the close buttons.
+	CloseSize unit.Dp
+}
+
+func Tabs(th *Theme, tabs *widget.Tabs) TabsStyle {
+	return TabsStyle{
+		Tabs: tabs,
+		Inset: layout.Inset{
+			Top: 10, Bottom: 10,
+			Left: 16, Right: 16,
+		},
+		IndicatorColor:  th.Palette.ContrastBg,
+		IndicatorHeight: 2,
+		HoverColor:      f32color.MulAlpha(th.Palette.Fg, 0x18),
+		BorderColor:     f32color.MulAlpha(th.Palette.Fg, 0x30),
+		CloseColor:      f32color.MulAlpha(th.Palette.Fg, 0xbb),
+		CloseSize:       16,
+	}
+}
+
+// Layout the tab bar, with w laying out the content of each tab.
+func (t TabsStyle) Layout(gtx layout.Context, w widget.TabElement) layout.Dimensions {
+	dims := t.Tabs.Layout(gtx, func(gtx layout.Context, tab widget.Tab) layout.Dimensions {
+		return t.layoutTab(gtx, tab, w)
+	})
+	lw := max(gtx.Dp(1), 1)
+	size := dims.Size
+	paint.FillShape(gtx.Ops, t.BorderColor, clip.Rect{Min: image.Pt(0, size.Y-lw), Max: size}.Op())
+	if x0, x1, ok := t.Tabs.Indicator(); ok {
+		h := gtx.Dp(t.IndicatorHeight)
+		paint.FillShape(gtx.Ops, t.IndicatorColor, clip.Rect{Min: image.Pt(x0, size.Y-h), Max: image.Pt(x1, size.Y)}.Op())
+	}
+	return dims
+}
+
+func (t TabsStyle) layoutTab(gtx layout.Context, tab widget.Tab, w widget.TabElement) layout.Dimensions {
+	m := op.Record(gtx.Ops)
+	dims := t.Inset.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return w(gtx, tab)
+			}),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				if !t.Tabs.Closable {
+					return layout.Dimensions{}
+				}
+				return layout.Inset{Left: 8}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					return t.Tabs.LayoutClose(gtx, tab.Key, t.layoutClose)
+				})
+			}),
+		)
+	})
+	call := m.Stop()
+	if tab.Hovered || tab.Dragging {
+		paint.FillShape(gtx.Ops, t.HoverColor, clip.Rect{Max: dims.Size}.Op())
+	}
+	call.Add(gtx.Ops)
+	return dims
+}
+
+// layoutClose draws a cross.
+func (t TabsStyle) layoutClose(gtx layout.Context) layout.Dimensions {
+	size := gtx.Dp(t.CloseSize)
+	s := float32(size)
+	r := s / 4
+	c := f32.Pt(s/2, s/2)
+	var p clip.Path
+	p.Begin(gtx.Ops)
+	p.MoveTo(c.Add(f32.Pt(-r, -r)))
+	p.LineTo(c.Add(f32.Pt(r, r)))
+	p.MoveTo(c.Add(f32.Pt(r, -r)))
+	p.LineTo(c.Add(f32.Pt(-r, r)))
+	width := float32(max(gtx.Dp(1.5), 1))
+	paint.FillShape(gtx.Ops, t.CloseColor, clip.Stroke{Path: p.End(), Width: width}.Op())
+	return layout.Dimensions{Size: image.Pt(size, size)}
+}
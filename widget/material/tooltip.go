@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package material
+
+import (
+	"image"
+	"image/color"
+
+	"gioui.org/font"
+	"gioui.org/internal/f32color"
+	"gioui.org/io/semantic"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/text"
+	"gioui.org/unit"
+	"gioui.org/widget"
+)
+
+// TooltipStyle displays the text of a tooltip in a rounded box.
+type TooltipStyle struct {
+	// Text is the tip. It is also the description of the widget for
+	// screen readers.
+	Text     string
+	Font     font.Font
+	TextSize unit.Sp
+	// Color is the color of the text.
+	Color color.NRGBA
+	// Background is the color of the box.
+	Background   color.NRGBA
+	CornerRadius unit.Dp
+	// Inset is the padding around the text.
+	Inset layout.Inset
+	// Margin is the space between the widget and the box.
+	Margin unit.Dp
+	// MaxWidth is the maximum width of the box.
+	MaxWidth unit.Dp
+	Tooltip  *widget.Tooltip
+
+	shaper *text.Shaper
+}
+
+func Tooltip(th *Theme, tooltip *widget.Tooltip, txt string) TooltipStyle {
+	return TooltipStyle{
+		Text: txt,
+		Font: font.Font{
+			Typeface: th.Face,
+		},
+		TextSize:     th.TextSize * 12.0 / 16.0,
+		Color:        th.Palette.Bg,
+		Background:   f32color.MulAlpha(th.Palette.Fg, 0xe6),
+		CornerRadius: 4,
+		Inset: layout.Inset{
+			Top: 4, Bottom: 4,
+			Left: 8, Right: 8,
+		},
+		Margin:   4,
+		MaxWidth: 240,
+		Tooltip:  tooltip,
+		shaper:   th.Shaper,
+	}
+}
+
+// Layout w, and the tip in a layer of o while the tooltip is visible.
+func (t TooltipStyle) Layout(gtx layout.Context, o *widget.Overlay, w layout.Widget) layout.Dimensions {
+	return t.Tooltip.Layout(gtx, o, func(gtx layout.Context) layout.Dimensions {
+		semantic.DescriptionOp(t.Text).Add(gtx.Ops)
+		return w(gtx)
+	}, t.layoutTip)
+}
+
+func (t TooltipStyle) layoutTip(gtx layout.Context) layout.Dimensions {
+	gtx.Constraints.Min = image.Point{}
+	gtx.Constraints.Max.X = min(gtx.Constraints.Max.X, gtx.Dp(t.MaxWidth))
+	return layout.UniformInset(t.Margin).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		m := op.Record(gtx.Ops)
+		dims := t.Inset.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			lbl := widget.Label{}
+			return lbl.Layout(gtx, t.shaper, t.Font, t.TextSize, t.Text, colorMaterial(gtx.Ops, t.Color))
+		})
+		call := m.Stop()
+		paint.FillShape(gtx.Ops, t.Background, clip.UniformRRect(image.Rectangle{Max: dims.Size}, gtx.Dp(t.CornerRadius)).Op(gtx.Ops))
+		call.Add(gtx.Ops)
+		return dims
+	})
+}
@@ -0,0 +1,108 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package material
+
+import (
+	"image"
+	"image/color"
+
+	"gioui.org/f32"
+	"gioui.org/internal/f32color"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget"
+)
+
+// TreeStyle displays the rows of a tree indented by depth, with a button
+// for expanding and collapsing the nodes with children.
+type TreeStyle struct {
+	Tree *widget.Tree
+	List ListStyle
+	// Indent is the indentation of each level of the tree.
+	Indent unit.Dp
+	// ExpanderSize is the size of the expand and collapse button.
+	ExpanderSize unit.Dp
+	// ExpanderColor is the color of the expand and collapse button.
+	ExpanderColor color.NRGBA
+	// SelectionColor is the background color of the selected row.
+	SelectionColor color.NRGBA
+	// FocusedSelectionColor is the background color of the selected row
+	// while the tree has the keyboard focus.
+	FocusedSelectionColor color.NRGBA
+}
+
+func Tree(th *Theme, tree *widget.Tree) TreeStyle {
+	return TreeStyle{
+		Tree:                  tree,
+		List:                  List(th, &tree.List),
+		Indent:                16,
+		ExpanderSize:          20,
+		ExpanderColor:         f32color.MulAlpha(th.Palette.Fg, 0xbb),
+		SelectionColor:        f32color.MulAlpha(th.Palette.Fg, 0x20),
+		FocusedSelectionColor: f32color.MulAlpha(th.Palette.ContrastBg, 0x60),
+	}
+}
+
+// Layout the visible rows of the tree, with w laying out the content of
+// each row.
+func (t TreeStyle) Layout(gtx layout.Context, w widget.TreeElement) layout.Dimensions {
+	return t.Tree.LayoutList(gtx, t.List.Layout, func(gtx layout.Context, row widget.TreeRow) layout.Dimensions {
+		return t.layoutRow(gtx, row, w)
+	})
+}
+
+func (t TreeStyle) layoutRow(gtx layout.Context, row widget.TreeRow, w widget.TreeElement) layout.Dimensions {
+	gtx.Constraints.Min.X = gtx.Constraints.Max.X
+	m := op.Record(gtx.Ops)
+	dims := layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Dimensions{Size: image.Pt(gtx.Dp(t.Indent)*row.Depth, 0)}
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			size := gtx.Dp(t.ExpanderSize)
+			if !row.Expandable {
+				return layout.Dimensions{Size: image.Pt(size, size)}
+			}
+			return t.Tree.LayoutExpander(gtx, row, func(gtx layout.Context) layout.Dimensions {
+				t.paintExpander(gtx, size, row.Expanded)
+				return layout.Dimensions{Size: image.Pt(size, size)}
+			})
+		}),
+		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+			return w(gtx, row)
+		}),
+	)
+	call := m.Stop()
+	if row.Node == t.Tree.Selected() {
+		c := t.SelectionColor
+		if t.Tree.Focused() {
+			c = t.FocusedSelectionColor
+		}
+		paint.FillShape(gtx.Ops, c, clip.Rect{Max: dims.Size}.Op())
+	}
+	call.Add(gtx.Ops)
+	return dims
+}
+
+// paintExpander draws a triangle pointing right, or down if expanded.
+func (t TreeStyle) paintExpander(gtx layout.Context, size int, expanded bool) {
+	s := float32(size)
+	c := f32.Pt(s/2, s/2)
+	r := s / 5
+	var p clip.Path
+	p.Begin(gtx.Ops)
+	if expanded {
+		p.MoveTo(c.Add(f32.Pt(-r, -r/2)))
+		p.LineTo(c.Add(f32.Pt(r, -r/2)))
+		p.LineTo(c.Add(f32.Pt(0, r)))
+	} else {
+		p.MoveTo(c.Add(f32.Pt(-r/2, -r)))
+		p.LineTo(c.Add(f32.Pt(r, 0)))
+		p.LineTo(c.Add(f32.Pt(-r/2, r)))
+	}
+	p.Close()
+	paint.FillShape(gtx.Ops, t.ExpanderColor, clip.Outline{Path: p.End()}.Op())
+}
@@ -0,0 +1,719 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package widget
+
+import (
+	"image"
+	"strings"
+
+	"gioui.org/io/event"
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+)
+
+// MenuItem is an entry of a Menu.
+type MenuItem struct {
+	// Label is the text of the item. An item with an empty Label is
+	// displayed as a separator.
+	Label string
+	// Shortcut describes the key shortcut of the item, such as "Ctrl-C".
+	// It is displayed alongside the label.
+	Shortcut string
+	// Key, if its Name is set, is the key shortcut of the item. The key
+	// shortcuts of the items of a MenuBar activate them even while their
+	// menus are hidden. If Shortcut is empty, it is derived from Key.
+	Key key.Filter
+	// Mnemonic is the letter of the Label that activates the item from
+	// the keyboard while its menu is displayed, or zero. The mnemonics of
+	// the items of a MenuBar open their menu together with the alt key.
+	Mnemonic rune
+	Kind     MenuItemKind
+	// Checked is the state of check and radio items.
+	Checked bool
+	// Submenu, if not nil, is displayed next to the item when the item is
+	// activated or hovered.
+	Submenu *Menu
+	// Disabled items are displayed, but can't be activated.
+	Disabled bool
+	// Action is called when the item is activated, after updating Checked.
+	Action func(gtx layout.Context)
+}
+
+// MenuItemKind is the kind of a MenuItem.
+type MenuItemKind uint8
+
+const (
+	// ActionMenuItem calls its Action when activated.
+	ActionMenuItem MenuItemKind = iota
+	// CheckMenuItem toggles its Checked state when activated.
+	CheckMenuItem
+	// RadioMenuItem is checked when activated, and unchecks the radio
+	// items adjacent to it.
+	RadioMenuItem
+)
+
+// Menu is the state of a list of menu items.
+type Menu struct {
+	Items []MenuItem
+
+	clicks []Clickable
+	// highlighted is the item highlighted by the keyboard or the
+	// pointer, or -1.
+	highlighted int
+	// hovered is the item under the pointer, or -1.
+	hovered int
+	// open is the item whose submenu is displayed, or -1.
+	open int
+}
+
+// MenuBar is the state of a row of menus, such as the menu bar of a
+// window. The Submenu of every bar item is displayed below it when the
+// item is clicked, or from the keyboard with the F10 key or with the alt
+// key and the Mnemonic of the item. While a menu is displayed, the arrow
+// keys navigate the items across menus, the return key activates the
+// highlighted item and the escape key closes the innermost menu.
+//
+// The keys are processed without the keyboard focus, so the bar must be
+// updated before the widgets that handle the same keys.
+type MenuBar struct {
+	// Menu holds the bar items.
+	Menu Menu
+
+	active bool
+}
+
+// ContextMenu displays a menu at the position of secondary clicks on a
+// widget. While the menu is displayed, it covers the window such that a
+// press outside the menu dismisses it, as does the escape key or the
+// activation of an item.
+type ContextMenu struct {
+	// Menu holds the items of the menu. Set them when Update reports that
+	// the menu opened.
+	Menu Menu
+
+	active bool
+	pos    image.Point
+}
+
+// Update calls the Action of the item activated since the last call, if
+// any, and returns its index. Hovering or clicking an item with a Submenu
+// displays the submenu instead.
+func (m *Menu) Update(gtx layout.Context) (int, bool) {
+	hovered := m.hoveredItem()
+	if hovered != m.hovered && hovered != -1 && m.enabled(hovered) {
+		m.highlight(hovered)
+	}
+	m.hovered = hovered
+	for i, it := range m.Items {
+		if i >= len(m.clicks) {
+			break
+		}
+		if !m.clicks[i].Clicked(gtx) || !m.enabled(i) {
+			continue
+		}
+		if it.Submenu != nil {
+			if m.open != i {
+				m.openSubmenu(i)
+			}
+			continue
+		}
+		m.activate(gtx, i)
+		return i, true
+	}
+	return 0, false
+}
+
+// activate item i.
+func (m *Menu) activate(gtx layout.Context, i int) {
+	it := &m.Items[i]
+	switch it.Kind {
+	case CheckMenuItem:
+		it.Checked = !it.Checked
+	case RadioMenuItem:
+		// Uncheck the group of adjacent radio items.
+		start, end := i, i+1
+		for start > 0 && m.Items[start-1].Kind == RadioMenuItem {
+			start--
+		}
+		for end < len(m.Items) && m.Items[end].Kind == RadioMenuItem {
+			end++
+		}
+		for j := start; j < end; j++ {
+			m.Items[j].Checked = false
+		}
+		it.Checked = true
+	}
+	if it.Action != nil {
+		it.Action(gtx)
+	}
+}
+
+// enabled reports whether item i can be highlighted and activated.
+func (m *Menu) enabled(i int) bool {
+	it := m.Items[i]
+	return !it.Disabled && it.Label != ""
+}
+
+// hoveredItem returns the item under the pointer, or -1.
+func (m *Menu) hoveredItem() int {
+	for i := range min(len(m.clicks), len(m.Items)) {
+		if m.clicks[i].Hovered() {
+			return i
+		}
+	}
+	return -1
+}
+
+// reset the state of a menu about to be displayed.
+func (m *Menu) reset() {
+	m.highlighted, m.open = -1, -1
+	m.hovered = m.hoveredItem()
+}
+
+// highlight item i, and display its submenu, if any.
+func (m *Menu) highlight(i int) {
+	m.highlighted = i
+	switch {
+	case m.Items[i].Submenu == nil:
+		m.open = -1
+	case m.open != i:
+		m.openSubmenu(i)
+	}
+}
+
+// move the highlight to the next enabled item in direction dir, wrapping
+// around the ends of the menu.
+func (m *Menu) move(dir int) {
+	n := len(m.Items)
+	i := m.highlighted
+	if i < 0 || i >= n {
+		// Start before the first item, or after the last.
+		i = -1
+		if dir < 0 {
+			i = n
+		}
+	}
+	for range n {
+		i = (i + dir + n) % n
+		if m.enabled(i) {
+			m.highlighted = i
+			return
+		}
+	}
+}
+
+// openSubmenu displays the submenu of item i.
+func (m *Menu) openSubmenu(i int) {
+	m.open = i
+	m.Items[i].Submenu.reset()
+}
+
+// submenu returns the displayed submenu, or nil.
+func (m *Menu) submenu() *Menu {
+	if m.open < 0 || m.open >= len(m.Items) {
+		return nil
+	}
+	return m.Items[m.open].Submenu
+}
+
+// mnemonic returns the enabled item with the mnemonic of the key name.
+func (m *Menu) mnemonic(name key.Name) (int, bool) {
+	for i, it := range m.Items {
+		if it.Mnemonic != 0 && mnemonicKey(it.Mnemonic) == name && m.enabled(i) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Highlighted returns the index of the item highlighted by the keyboard
+// or the pointer, if any.
+func (m *Menu) Highlighted() (int, bool) {
+	i := m.highlighted
+	return i, i >= 0 && i < len(m.Items)
+}
+
+// Opened returns the index of the item whose submenu is displayed, if
+// any.
+func (m *Menu) Opened() (int, bool) {
+	return m.open, m.submenu() != nil
+}
+
+// Clickable returns the click state of item i, for use by the widget
+// displaying the menu.
+func (m *Menu) Clickable(i int) *Clickable {
+	if n := len(m.Items); len(m.clicks) < n {
+		m.clicks = append(m.clicks, make([]Clickable, n-len(m.clicks))...)
+	}
+	return &m.clicks[i]
+}
+
+// Update processes input and reports whether the menu was opened by a
+// secondary click. Activating an item of the menu or of its submenus calls
+// its Action and dismisses the menu.
+func (c *ContextMenu) Update(gtx layout.Context) bool {
+	for m := &c.Menu; m != nil; m = m.submenu() {
+		for {
+			if _, ok := m.Update(gtx); !ok {
+				break
+			}
+			c.active = false
+		}
+	}
+	opened := false
+	for {
+		ev, ok := gtx.Event(
+			pointer.Filter{Target: c, Kinds: pointer.Press},
+			condFilter(c.active, key.Filter{Name: key.NameEscape}),
+		)
+		if !ok {
+			break
+		}
+		switch ev := ev.(type) {
+		case pointer.Event:
+			switch {
+			case c.active:
+				c.active = false
+				opened = false
+			case ev.Buttons == pointer.ButtonSecondary:
+				c.Open(ev.Position.Round())
+				opened = true
+			}
+		case key.Event:
+			if ev.State == key.Press {
+				c.active = false
+			}
+		}
+	}
+	return opened
+}
+
+// Open displays the menu at pos, relative to the widget, for example in
+// response to the menu key or a long press.
+func (c *ContextMenu) Open(pos image.Point) {
+	c.active = true
+	c.pos = pos
+	c.Menu.reset()
+}
+
+// Dismiss hides the menu.
+func (c *ContextMenu) Dismiss() {
+	c.active = false
+}
+
+// Active reports whether the menu is displayed.
+func (c *ContextMenu) Active() bool {
+	return c.active
+}
+
+// Position returns the position of the menu relative to the widget.
+func (c *ContextMenu) Position() image.Point {
+	return c.pos
+}
+
+// Add the handler for secondary clicks to ops, covering the current clip
+// area. Widgets with their own context menu call Add instead of Layout.
+func (c *ContextMenu) Add(ops *op.Ops) {
+	event.Op(ops, c)
+}
+
+// Layout lays out w, handles secondary clicks over it and displays the
+// menu laid out by menu while it is active.
+func (c *ContextMenu) Layout(gtx layout.Context, w, menu layout.Widget) layout.Dimensions {
+	c.Update(gtx)
+	m := op.Record(gtx.Ops)
+	dims := w(gtx)
+	call := m.Stop()
+	area := clip.Rect(image.Rectangle{Max: dims.Size}).Push(gtx.Ops)
+	c.Add(gtx.Ops)
+	call.Add(gtx.Ops)
+	area.Pop()
+	c.LayoutMenu(gtx, menu)
+	return dims
+}
+
+// LayoutMenu displays the menu laid out by menu above all other content
+// while the menu is active. It must be called in the coordinate space of
+// the widget the menu belongs to.
+func (c *ContextMenu) LayoutMenu(gtx layout.Context, menu layout.Widget) {
+	if !c.active {
+		return
+	}
+	const inf = 1e6
+	m := op.Record(gtx.Ops)
+	// Catch presses outside the menu.
+	scrim := clip.Rect{Min: image.Pt(-inf, -inf), Max: image.Pt(inf, inf)}.Push(gtx.Ops)
+	c.Add(gtx.Ops)
+	scrim.Pop()
+	op.Offset(c.pos).Add(gtx.Ops)
+	gtx.Constraints = layout.Constraints{Max: image.Pt(inf, inf)}
+	menu(gtx)
+	op.Defer(gtx.Ops, m.Stop())
+}
+
+// Update processes input, and returns the next item activated by the
+// pointer, the keyboard or its key shortcut, if any. The Action of the
+// item is called before Update returns.
+func (b *MenuBar) Update(gtx layout.Context) (*MenuItem, bool) {
+	if !gtx.Enabled() {
+		b.close()
+	}
+	bar := &b.Menu
+	hovered := bar.hoveredItem()
+	// Moving the pointer across the bar switches between menus.
+	if hovered != bar.hovered && hovered != -1 && b.active && bar.enabled(hovered) && bar.Items[hovered].Submenu != nil {
+		b.open(hovered)
+	}
+	bar.hovered = hovered
+	for i := range min(len(bar.clicks), len(bar.Items)) {
+		if !bar.clicks[i].Clicked(gtx) || !bar.enabled(i) {
+			continue
+		}
+		switch {
+		case bar.Items[i].Submenu == nil:
+			b.close()
+			bar.activate(gtx, i)
+			return &bar.Items[i], true
+		case b.active && bar.open == i:
+			b.close()
+		default:
+			b.open(i)
+		}
+	}
+	if b.active {
+		for m := bar.submenu(); m != nil; m = m.submenu() {
+			if i, ok := m.Update(gtx); ok {
+				b.close()
+				return &m.Items[i], true
+			}
+		}
+	}
+	for {
+		ev, ok := gtx.Event(b.filters()...)
+		if !ok {
+			break
+		}
+		switch e := ev.(type) {
+		case pointer.Event:
+			// A press outside the bar and its menus.
+			b.close()
+		case key.Event:
+			if e.State != key.Press {
+				break
+			}
+			if it, ok := b.key(gtx, e); ok {
+				return it, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func (b *MenuBar) filters() []event.Filter {
+	filters := []event.Filter{
+		pointer.Filter{Target: b, Kinds: pointer.Press},
+		key.Filter{Name: key.NameF10},
+	}
+	for _, it := range b.Menu.Items {
+		if it.Mnemonic != 0 {
+			filters = append(filters, key.Filter{Name: mnemonicKey(it.Mnemonic), Required: key.ModAlt})
+		}
+	}
+	if b.active {
+		for _, n := range []key.Name{
+			key.NameUpArrow, key.NameDownArrow, key.NameLeftArrow, key.NameRightArrow,
+			key.NameReturn, key.NameEnter, key.NameSpace, key.NameEscape,
+		} {
+			filters = append(filters, key.Filter{Name: n})
+		}
+		for _, it := range b.innermost().Items {
+			if it.Mnemonic != 0 {
+				filters = append(filters, key.Filter{Name: mnemonicKey(it.Mnemonic)})
+			}
+		}
+	}
+	return b.Menu.shortcutFilters(filters)
+}
+
+// key processes the key press e.
+func (b *MenuBar) key(gtx layout.Context, e key.Event) (*MenuItem, bool) {
+	bar := &b.Menu
+	if b.active && e.Modifiers == 0 {
+		parent, m := b.parent(), b.innermost()
+		switch e.Name {
+		case key.NameUpArrow:
+			m.move(-1)
+			return nil, false
+		case key.NameDownArrow:
+			m.move(+1)
+			return nil, false
+		case key.NameLeftArrow:
+			if parent != bar {
+				parent.open = -1
+			} else {
+				b.step(-1)
+			}
+			return nil, false
+		case key.NameRightArrow:
+			if i, ok := m.Highlighted(); ok && m.Items[i].Submenu != nil {
+				m.openSubmenu(i)
+				m.Items[i].Submenu.move(+1)
+			} else {
+				b.step(+1)
+			}
+			return nil, false
+		case key.NameReturn, key.NameEnter, key.NameSpace:
+			if i, ok := m.Highlighted(); ok {
+				return b.choose(gtx, m, i)
+			}
+			return nil, false
+		case key.NameEscape:
+			if parent != bar {
+				parent.open = -1
+			} else {
+				b.close()
+			}
+			return nil, false
+		}
+		if i, ok := m.mnemonic(e.Name); ok {
+			return b.choose(gtx, m, i)
+		}
+	}
+	if e.Name == key.NameF10 && e.Modifiers == 0 {
+		if b.active {
+			b.close()
+		} else {
+			b.step(+1)
+		}
+		return nil, false
+	}
+	if e.Modifiers == key.ModAlt {
+		if i, ok := bar.mnemonic(e.Name); ok {
+			return b.choose(gtx, bar, i)
+		}
+	}
+	if m, i, ok := bar.shortcut(e); ok {
+		b.close()
+		m.activate(gtx, i)
+		return &m.Items[i], true
+	}
+	return nil, false
+}
+
+// choose displays the submenu of item i of m, highlighting its first item,
+// or activates the item if it has no submenu.
+func (b *MenuBar) choose(gtx layout.Context, m *Menu, i int) (*MenuItem, bool) {
+	if sub := m.Items[i].Submenu; sub != nil {
+		if m == &b.Menu {
+			b.open(i)
+		} else {
+			m.highlighted = i
+			m.openSubmenu(i)
+		}
+		sub.move(+1)
+		return nil, false
+	}
+	b.close()
+	m.activate(gtx, i)
+	return &m.Items[i], true
+}
+
+// step displays the menu of the next bar item with a submenu in direction
+// dir, highlighting its first item.
+func (b *MenuBar) step(dir int) {
+	bar := &b.Menu
+	n := len(bar.Items)
+	i := bar.open
+	if !b.active {
+		i = -1
+		if dir < 0 {
+			i = n
+		}
+	}
+	for range n {
+		i = (i + dir + n) % n
+		if bar.enabled(i) && bar.Items[i].Submenu != nil {
+			b.open(i)
+			bar.Items[i].Submenu.move(+1)
+			return
+		}
+	}
+}
+
+// open displays the submenu of bar item i.
+func (b *MenuBar) open(i int) {
+	b.active = true
+	b.Menu.highlighted = i
+	b.Menu.openSubmenu(i)
+}
+
+// close hides the menus.
+func (b *MenuBar) close() {
+	b.active = false
+	b.Menu.highlighted = -1
+	b.Menu.open = -1
+}
+
+// innermost returns the innermost displayed menu.
+func (b *MenuBar) innermost() *Menu {
+	m := &b.Menu
+	for sub := m.submenu(); sub != nil; sub = sub.submenu() {
+		m = sub
+	}
+	return m
+}
+
+// parent returns the menu of the item whose submenu is the innermost
+// displayed menu.
+func (b *MenuBar) parent() *Menu {
+	m := &b.Menu
+	for sub := m.submenu(); sub != nil && sub.submenu() != nil; sub = sub.submenu() {
+		m = sub
+	}
+	return m
+}
+
+// Close hides the menus.
+func (b *MenuBar) Close() {
+	b.close()
+}
+
+// Opened returns the index of the bar item whose menu is displayed, if
+// any.
+func (b *MenuBar) Opened() (int, bool) {
+	if !b.active {
+		return 0, false
+	}
+	return b.Menu.Opened()
+}
+
+// Layout lays out the bar items in a row with item, and the displayed
+// menu with menu, below its bar item and above all other content. While
+// a menu is displayed, presses outside the bar and the menus close it.
+func (b *MenuBar) Layout(gtx layout.Context, item func(gtx layout.Context, i int) layout.Dimensions, menu func(gtx layout.Context, m *Menu) layout.Dimensions) layout.Dimensions {
+	for {
+		if _, ok := b.Update(gtx); !ok {
+			break
+		}
+	}
+	open, opened := b.Opened()
+	var x, height, openX int
+	macro := op.Record(gtx.Ops)
+	for i, it := range b.Menu.Items {
+		igtx := gtx
+		igtx.Constraints = layout.Constraints{Max: image.Pt(max(gtx.Constraints.Max.X-x, 0), gtx.Constraints.Max.Y)}
+		if it.Disabled {
+			igtx = igtx.Disabled()
+		}
+		trans := op.Offset(image.Pt(x, 0)).Push(gtx.Ops)
+		dims := b.Menu.Clickable(i).Layout(igtx, func(gtx layout.Context) layout.Dimensions {
+			return item(gtx, i)
+		})
+		trans.Pop()
+		if i == open {
+			openX = x
+		}
+		x += dims.Size.X
+		height = max(height, dims.Size.Y)
+	}
+	call := macro.Stop()
+	size := image.Pt(max(x, gtx.Constraints.Min.X), max(height, gtx.Constraints.Min.Y))
+	call.Add(gtx.Ops)
+	if !opened {
+		return layout.Dimensions{Size: size}
+	}
+	const inf = 1e6
+	m := op.Record(gtx.Ops)
+	// Catch presses outside the bar and the menus.
+	for _, r := range []image.Rectangle{
+		{Min: image.Pt(-inf, -inf), Max: image.Pt(inf, 0)},
+		{Min: image.Pt(-inf, size.Y), Max: image.Pt(inf, inf)},
+		{Min: image.Pt(-inf, 0), Max: image.Pt(0, size.Y)},
+		{Min: image.Pt(size.X, 0), Max: image.Pt(inf, size.Y)},
+	} {
+		area := clip.Rect(r).Push(gtx.Ops)
+		event.Op(gtx.Ops, b)
+		area.Pop()
+	}
+	op.Offset(image.Pt(openX, size.Y)).Add(gtx.Ops)
+	gtx.Constraints = layout.Constraints{Max: image.Pt(inf, inf)}
+	menu(gtx, b.Menu.Items[open].Submenu)
+	op.Defer(gtx.Ops, m.Stop())
+	return layout.Dimensions{Size: size}
+}
+
+// shortcutFilters appends the filters of the key shortcuts of the enabled
+// items of m and its submenus to filters.
+func (m *Menu) shortcutFilters(filters []event.Filter) []event.Filter {
+	for i, it := range m.Items {
+		if !m.enabled(i) {
+			continue
+		}
+		if it.Key.Name != "" {
+			filters = append(filters, it.Key)
+		}
+		if it.Submenu != nil {
+			filters = it.Submenu.shortcutFilters(filters)
+		}
+	}
+	return filters
+}
+
+// shortcut returns the enabled item of m or its submenus whose key
+// shortcut matches e.
+func (m *Menu) shortcut(e key.Event) (*Menu, int, bool) {
+	for i, it := range m.Items {
+		if !m.enabled(i) {
+			continue
+		}
+		f := it.Key
+		if f.Name != "" && f.Name == e.Name && e.Modifiers&f.Required == f.Required && e.Modifiers&^(f.Required|f.Optional) == 0 {
+			return m, i, true
+		}
+		if it.Submenu != nil {
+			if m, i, ok := it.Submenu.shortcut(e); ok {
+				return m, i, true
+			}
+		}
+	}
+	return nil, 0, false
+}
+
+// ShortcutLabel returns Shortcut, or the description of Key if Shortcut is
+// empty.
+func (it MenuItem) ShortcutLabel() string {
+	if it.Shortcut != "" || it.Key.Name == "" {
+		return it.Shortcut
+	}
+	return shortcutLabel(it.Key.Required, it.Key.Name)
+}
+
+// shortcutLabel returns the description of the key shortcut name with the
+// modifiers mods.
+func shortcutLabel(mods key.Modifiers, name key.Name) string {
+	if mods == 0 {
+		return string(name)
+	}
+	return mods.String() + "-" + string(name)
+}
+
+// mnemonicKey returns the name of the key of the mnemonic r.
+func mnemonicKey(r rune) key.Name {
+	return key.Name(strings.ToUpper(string(r)))
+}
+
+func (k MenuItemKind) String() string {
+	switch k {
+	case ActionMenuItem:
+		return "ActionMenuItem"
+	case CheckMenuItem:
+		return "CheckMenuItem"
+	case RadioMenuItem:
+		return "RadioMenuItem"
+	default:
+		panic("invalid MenuItemKind")
+	}
+}
@@ -0,0 +1,412 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package widget
+
+import (
+	"image"
+	"slices"
+	"testing"
+
+	"gioui.org/f32"
+	"gioui.org/font"
+	"gioui.org/font/gofont"
+	"gioui.org/io/input"
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/text"
+	"gioui.org/unit"
+)
+
+func TestContextMenu(t *testing.T) {
+	r := new(input.Router)
+	gtx := layout.Context{
+		Ops:         new(op.Ops),
+		Constraints: layout.Exact(image.Pt(50, 50)),
+		Source:      r.Source(),
+	}
+	var c ContextMenu
+	var activated bool
+	c.Menu.Items = []MenuItem{{Label: "Item", Action: func(gtx layout.Context) {
+		activated = true
+	}}}
+	frame := func() {
+		gtx.Ops.Reset()
+		c.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return layout.Dimensions{Size: gtx.Constraints.Min}
+		}, func(gtx layout.Context) layout.Dimensions {
+			return c.Menu.Clickable(0).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return layout.Dimensions{Size: image.Pt(20, 10)}
+			})
+		})
+		r.Frame(gtx.Ops)
+	}
+	frame()
+	// Primary presses don't open the menu.
+	r.Queue(pointer.Event{Kind: pointer.Press, Buttons: pointer.ButtonPrimary, Position: f32.Pt(10, 10)})
+	if c.Update(gtx) {
+		t.Error("primary press opened the menu")
+	}
+	r.Queue(
+		pointer.Event{Kind: pointer.Release, Position: f32.Pt(10, 10)},
+		pointer.Event{Kind: pointer.Press, Buttons: pointer.ButtonSecondary, Position: f32.Pt(10, 20)},
+		pointer.Event{Kind: pointer.Release, Position: f32.Pt(10, 20)},
+	)
+	if !c.Update(gtx) || !c.Active() {
+		t.Fatal("secondary press didn't open the menu")
+	}
+	if got, want := c.Position(), image.Pt(10, 20); got != want {
+		t.Errorf("got menu position %v, want %v", got, want)
+	}
+	frame()
+	// A press outside the widget and the menu dismisses it.
+	r.Queue(
+		pointer.Event{Kind: pointer.Press, Buttons: pointer.ButtonPrimary, Position: f32.Pt(100, 100)},
+		pointer.Event{Kind: pointer.Release, Position: f32.Pt(100, 100)},
+	)
+	c.Update(gtx)
+	if c.Active() {
+		t.Error("press outside didn't dismiss the menu")
+	}
+
+	c.Open(image.Pt(10, 20))
+	frame()
+	r.Queue(key.Event{Name: key.NameEscape, State: key.Press})
+	c.Update(gtx)
+	if c.Active() {
+		t.Error("escape didn't dismiss the menu")
+	}
+
+	c.Open(image.Pt(10, 20))
+	frame()
+	r.Queue(
+		pointer.Event{Kind: pointer.Press, Buttons: pointer.ButtonPrimary, Position: f32.Pt(15, 25)},
+		pointer.Event{Kind: pointer.Release, Position: f32.Pt(15, 25)},
+	)
+	c.Update(gtx)
+	if !activated || c.Active() {
+		t.Errorf("clicking the item activated it: %v, menu active: %v", activated, c.Active())
+	}
+}
+
+func TestContextSubmenu(t *testing.T) {
+	r := new(input.Router)
+	gtx := layout.Context{
+		Ops:         new(op.Ops),
+		Constraints: layout.Exact(image.Pt(50, 50)),
+		Source:      r.Source(),
+	}
+	var c ContextMenu
+	var activated bool
+	sub := &Menu{Items: []MenuItem{{Label: "Sub", Action: func(gtx layout.Context) {
+		activated = true
+	}}}}
+	c.Menu.Items = []MenuItem{{Label: "More", Submenu: sub}}
+	item := func(gtx layout.Context, m *Menu) layout.Dimensions {
+		return m.Clickable(0).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return layout.Dimensions{Size: image.Pt(20, 10)}
+		})
+	}
+	frame := func() {
+		gtx.Ops.Reset()
+		c.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return layout.Dimensions{Size: gtx.Constraints.Min}
+		}, func(gtx layout.Context) layout.Dimensions {
+			dims := item(gtx, &c.Menu)
+			if m := c.Menu.submenu(); m != nil {
+				defer op.Offset(image.Pt(20, 0)).Push(gtx.Ops).Pop()
+				item(gtx, m)
+			}
+			return dims
+		})
+		r.Frame(gtx.Ops)
+	}
+	click := func(pos f32.Point) {
+		r.Queue(
+			pointer.Event{Kind: pointer.Press, Buttons: pointer.ButtonPrimary, Position: pos},
+			pointer.Event{Kind: pointer.Release, Position: pos},
+		)
+		c.Update(gtx)
+		frame()
+	}
+	c.Open(image.Pt(0, 0))
+	frame()
+	click(f32.Pt(5, 5))
+	if i, ok := c.Menu.Opened(); !ok || i != 0 {
+		t.Fatal("clicking the item didn't open its submenu")
+	}
+	click(f32.Pt(25, 5))
+	if !activated || c.Active() {
+		t.Errorf("clicking the submenu item activated it: %v, menu active: %v", activated, c.Active())
+	}
+}
+
+func TestEditorContextMenu(t *testing.T) {
+	r := new(input.Router)
+	gtx := layout.Context{
+		Ops:         new(op.Ops),
+		Constraints: layout.Exact(image.Pt(200, 50)),
+		Locale:      english,
+		Source:      r.Source(),
+	}
+	cache := text.NewShaper(text.NoSystemFonts(), text.WithCollection(gofont.Collection()))
+	e := new(Editor)
+	e.SetText("hello world")
+	e.MenuItems = func(items []MenuItem) []MenuItem {
+		return append(items, MenuItem{Label: "Custom"})
+	}
+	frame := func() {
+		gtx.Ops.Reset()
+		e.Layout(gtx, cache, font.Font{}, unit.Sp(10), op.CallOp{}, op.CallOp{})
+		r.Frame(gtx.Ops)
+	}
+	secondaryClick := func(pos f32.Point) {
+		r.Queue(
+			pointer.Event{Kind: pointer.Press, Buttons: pointer.ButtonSecondary, Position: pos},
+			pointer.Event{Kind: pointer.Release, Position: pos},
+		)
+	}
+	item := func(label string) int {
+		t.Helper()
+		for i, it := range e.ContextMenu().Menu.Items {
+			if it.Label == label {
+				return i
+			}
+		}
+		t.Fatalf("no %q item", label)
+		return -1
+	}
+	frame()
+	secondaryClick(f32.Pt(150, 5))
+	frame()
+	menu := e.ContextMenu()
+	if !menu.Active() {
+		t.Fatal("secondary click didn't open the menu")
+	}
+	if !gtx.Focused(e) {
+		t.Error("secondary click didn't focus the editor")
+	}
+	// The click moved the caret to the end of the text.
+	assertCaret(t, e, 0, len("hello world"), len("hello world"))
+	if items := menu.Menu.Items; !items[item("Cut")].Disabled || !items[item("Copy")].Disabled {
+		t.Error("cut and copy enabled without a selection")
+	}
+	item("Custom")
+
+	menu.Menu.Clickable(item("Select All")).Click()
+	frame()
+	if menu.Active() {
+		t.Error("activating an item didn't dismiss the menu")
+	}
+	if start, end := e.Selection(); start != 0 || end != e.Len() {
+		t.Errorf("got selection [%d, %d) after select all", start, end)
+	}
+
+	// Clicking within the selection keeps it.
+	secondaryClick(f32.Pt(5, 5))
+	frame()
+	if start, end := e.Selection(); start != 0 || end != e.Len() {
+		t.Errorf("got selection [%d, %d) after secondary click", start, end)
+	}
+	if menu.Menu.Items[item("Cut")].Disabled {
+		t.Error("cut disabled with a selection")
+	}
+	menu.Menu.Clickable(item("Cut")).Click()
+	gtx.Ops.Reset()
+	ev, ok := e.Update(gtx)
+	if _, change := ev.(ChangeEvent); !ok || !change {
+		t.Errorf("got event %v after cut, want ChangeEvent", ev)
+	}
+	if got := e.Text(); got != "" {
+		t.Errorf("got text %q after cut", got)
+	}
+	r.Frame(gtx.Ops)
+	if _, data, ok := r.WriteClipboard(); !ok || string(data) != "hello world" {
+		t.Errorf("got clipboard %q after cut", data)
+	}
+	secondaryClick(f32.Pt(5, 5))
+	frame()
+	menu.Menu.Clickable(item("Undo")).Click()
+	frame()
+	if got := e.Text(); got != "hello world" {
+		t.Errorf("got text %q after undo", got)
+	}
+}
+
+func TestMenuBar(t *testing.T) {
+	var (
+		bar              MenuBar
+		file, view, docs Menu
+		news             int
+	)
+	docs.Items = []MenuItem{{Label: "a.txt"}, {Label: "b.txt"}}
+	file.Items = []MenuItem{
+		{Label: "New", Key: key.Filter{Name: "N", Required: key.ModShortcut}, Action: func(gtx layout.Context) {
+			news++
+		}},
+		{Label: "Recent", Mnemonic: 'r', Submenu: &docs},
+		{},
+		{Label: "Quit", Disabled: true},
+	}
+	view.Items = []MenuItem{
+		{Label: "Wrap", Mnemonic: 'w', Kind: CheckMenuItem},
+		{},
+		{Label: "Small", Kind: RadioMenuItem, Checked: true},
+		{Label: "Large", Kind: RadioMenuItem},
+	}
+	bar.Menu.Items = []MenuItem{
+		{Label: "File", Mnemonic: 'f', Submenu: &file},
+		{Label: "View", Mnemonic: 'v', Submenu: &view},
+	}
+	r := new(input.Router)
+	gtx := layout.Context{
+		Ops:         new(op.Ops),
+		Constraints: layout.Constraints{Max: image.Pt(200, 200)},
+		Source:      r.Source(),
+	}
+	box := func(size image.Point) layout.Widget {
+		return func(gtx layout.Context) layout.Dimensions {
+			return layout.Dimensions{Size: size}
+		}
+	}
+	// Bar items are 40x20, and menu items 60x10.
+	var layoutMenu func(gtx layout.Context, m *Menu) layout.Dimensions
+	layoutMenu = func(gtx layout.Context, m *Menu) layout.Dimensions {
+		for i := range m.Items {
+			trans := op.Offset(image.Pt(0, i*10)).Push(gtx.Ops)
+			m.Clickable(i).Layout(gtx, box(image.Pt(60, 10)))
+			trans.Pop()
+		}
+		if i, ok := m.Opened(); ok {
+			trans := op.Offset(image.Pt(60, i*10)).Push(gtx.Ops)
+			layoutMenu(gtx, m.Items[i].Submenu)
+			trans.Pop()
+		}
+		return layout.Dimensions{Size: image.Pt(60, len(m.Items)*10)}
+	}
+	frame := func() {
+		gtx.Ops.Reset()
+		bar.Layout(gtx, func(gtx layout.Context, i int) layout.Dimensions {
+			return box(image.Pt(40, 20))(gtx)
+		}, layoutMenu)
+		r.Frame(gtx.Ops)
+	}
+	var activated []string
+	update := func() {
+		activated = activated[:0]
+		for {
+			it, ok := bar.Update(gtx)
+			if !ok {
+				break
+			}
+			activated = append(activated, it.Label)
+		}
+		frame()
+	}
+	click := func(x, y float32) {
+		r.Queue(
+			pointer.Event{Kind: pointer.Press, Buttons: pointer.ButtonPrimary, Position: f32.Pt(x, y)},
+			pointer.Event{Kind: pointer.Release, Position: f32.Pt(x, y)},
+		)
+		update()
+	}
+	press := func(name key.Name, mods key.Modifiers) {
+		r.Queue(key.Event{Name: name, Modifiers: mods, State: key.Press})
+		update()
+	}
+	checkActivated := func(want ...string) {
+		t.Helper()
+		if !slices.Equal(activated, want) {
+			t.Errorf("activated %q, want %q", activated, want)
+		}
+	}
+	frame()
+	frame()
+
+	click(20, 10)
+	if i, ok := bar.Opened(); !ok || i != 0 {
+		t.Fatal("click didn't open the file menu")
+	}
+	// Hovering an item opens its submenu.
+	r.Queue(pointer.Event{Kind: pointer.Move, Position: f32.Pt(20, 35)})
+	update()
+	frame()
+	if i, ok := file.Opened(); !ok || i != 1 {
+		t.Fatal("hovering didn't open the submenu")
+	}
+	click(70, 35)
+	checkActivated("a.txt")
+	if _, ok := bar.Opened(); ok {
+		t.Error("activating an item didn't close the menus")
+	}
+	// Move the pointer out of the way of the keyboard.
+	r.Queue(pointer.Event{Kind: pointer.Move, Position: f32.Pt(150, 150)})
+	update()
+
+	press("F", key.ModAlt)
+	if i, ok := bar.Opened(); !ok || i != 0 {
+		t.Fatal("alt and mnemonic didn't open the file menu")
+	}
+	if i, ok := file.Highlighted(); !ok || i != 0 {
+		t.Errorf("highlighted item %d of opened menu, want 0", i)
+	}
+	// Disabled items and separators are skipped.
+	press(key.NameUpArrow, 0)
+	if i, _ := file.Highlighted(); i != 1 {
+		t.Errorf("highlighted item %d after up, want 1", i)
+	}
+	press(key.NameRightArrow, 0)
+	press(key.NameDownArrow, 0)
+	if i, ok := docs.Highlighted(); !ok || i != 1 {
+		t.Errorf("highlighted submenu item %d, want 1", i)
+	}
+	// The escape key closes the innermost menu.
+	press(key.NameEscape, 0)
+	if _, ok := file.Opened(); ok {
+		t.Error("escape didn't close the submenu")
+	}
+	if _, ok := bar.Opened(); !ok {
+		t.Error("escape closed the file menu")
+	}
+	press(key.NameRightArrow, 0)
+	press(key.NameReturn, 0)
+	checkActivated("a.txt")
+
+	// Moving across menus.
+	press(key.NameF10, 0)
+	press(key.NameRightArrow, 0)
+	if i, ok := bar.Opened(); !ok || i != 1 {
+		t.Fatal("right arrow didn't move to the view menu")
+	}
+	press("W", 0)
+	checkActivated("Wrap")
+	if !view.Items[0].Checked {
+		t.Error("activating a check item didn't check it")
+	}
+	press(key.NameF10, 0)
+	press(key.NameLeftArrow, 0)
+	press(key.NameDownArrow, 0)
+	press(key.NameDownArrow, 0)
+	press(key.NameReturn, 0)
+	checkActivated("Large")
+	if view.Items[2].Checked || !view.Items[3].Checked {
+		t.Error("activating a radio item didn't check it alone")
+	}
+
+	// Key shortcuts work while the menus are hidden.
+	if got, want := file.Items[0].ShortcutLabel(), shortcutLabel(key.ModShortcut, "N"); got != want {
+		t.Errorf("got shortcut label %q, want %q", got, want)
+	}
+	press("N", key.ModShortcut)
+	checkActivated("New")
+	if news != 1 {
+		t.Errorf("action called %d times", news)
+	}
+
+	click(20, 10)
+	click(150, 150)
+	if _, ok := bar.Opened(); ok || len(activated) > 0 {
+		t.Error("press outside didn't close the menus")
+	}
+}
@@ -0,0 +1,336 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package widget
+
+import (
+	"image"
+	"slices"
+	"time"
+
+	"gioui.org/f32"
+	"gioui.org/io/event"
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+)
+
+// Overlay displays layers above the content of a window, such as modal
+// dialogs, popovers and toasts. Layers are displayed in the order they're
+// pushed, except for toasts which are displayed above all other layers.
+//
+// While a modal layer is displayed, the content and the layers below it
+// are laid out with a disabled context: they receive no input and can't
+// be focused, so the keyboard focus stays within the modal layer and the
+// layers above it.
+type Overlay struct {
+	layers []*Layer
+	closed []*Layer
+	// pointer is the last position of the pointer over the overlay.
+	pointer f32.Point
+}
+
+// Layer is a layer of an Overlay.
+type Layer struct {
+	Kind LayerKind
+	// Widget lays out the content of the layer.
+	Widget layout.Widget
+	// Position is the position of a popover relative to the overlay.
+	// The popover is moved as necessary to fit inside the overlay. Modal
+	// layers are centered, and toasts are stacked at the bottom.
+	Position image.Point
+	// Anchor, if not empty, is the area relative to the overlay that a
+	// popover belongs to. The popover is centered below the anchor, or
+	// above it if there is no room below, and Position is ignored.
+	Anchor image.Rectangle
+	// Duration is the time a toast is displayed. If zero, the toast is
+	// displayed for 4 seconds.
+	Duration time.Duration
+	// Persistent layers don't close on escape or on presses outside
+	// them. Toasts close only when their Duration has passed.
+	Persistent bool
+
+	expires time.Time
+	// focused tracks whether a modal layer has taken the focus.
+	focused bool
+	tag     struct{}
+}
+
+// LayerKind is the kind of a Layer.
+type LayerKind uint8
+
+const (
+	// ModalLayer blocks the input to everything below it.
+	ModalLayer LayerKind = iota
+	// PopoverLayer is displayed at a position, such as next to the
+	// widget it belongs to.
+	PopoverLayer
+	// ToastLayer displays a short notice that closes after a while.
+	ToastLayer
+)
+
+// defaultToastDuration is the display time of toasts without a Duration.
+const defaultToastDuration = 4 * time.Second
+
+// Update the overlay state by processing events, and return the next layer
+// closed by the user or by expiring, if any.
+func (o *Overlay) Update(gtx layout.Context) (*Layer, bool) {
+	o.update(gtx)
+	if len(o.closed) == 0 {
+		return nil, false
+	}
+	l := o.closed[0]
+	o.closed = slices.Delete(o.closed, 0, 1)
+	return l, true
+}
+
+func (o *Overlay) update(gtx layout.Context) {
+	for {
+		ev, ok := gtx.Event(pointer.Filter{
+			Target: o,
+			Kinds:  pointer.Enter | pointer.Move | pointer.Press | pointer.Drag,
+		})
+		if !ok {
+			break
+		}
+		if ev, ok := ev.(pointer.Event); ok {
+			o.pointer = ev.Position
+		}
+	}
+	top := o.topModal()
+	for i, l := range slices.Clone(o.layers) {
+		if l.Kind == ToastLayer {
+			if l.expires.IsZero() {
+				d := l.Duration
+				if d == 0 {
+					d = defaultToastDuration
+				}
+				l.expires = gtx.Now.Add(d)
+			}
+			if !gtx.Now.Before(l.expires) {
+				o.dismiss(l)
+			} else {
+				gtx.Execute(op.InvalidateCmd{At: l.expires})
+			}
+			continue
+		}
+		if i < top {
+			// The layer is disabled by the modal layer.
+			continue
+		}
+		filters := []event.Filter{pointer.Filter{Target: l, Kinds: pointer.Press}}
+		if l.Kind == ModalLayer {
+			// Make the modal layer focusable.
+			filters = append(filters, key.FocusFilter{Target: &l.tag})
+		}
+		for {
+			ev, ok := gtx.Event(filters...)
+			if !ok {
+				break
+			}
+			if _, ok := ev.(pointer.Event); ok && !l.Persistent {
+				o.dismiss(l)
+			}
+		}
+	}
+	for {
+		top := o.escapeLayer()
+		ev, ok := gtx.Event(condFilter(top != nil, key.Filter{Name: key.NameEscape}))
+		if !ok {
+			break
+		}
+		if ev, ok := ev.(key.Event); ok && ev.State == key.Press {
+			o.dismiss(top)
+		}
+	}
+}
+
+// escapeLayer returns the topmost layer closed by the escape key, or nil.
+func (o *Overlay) escapeLayer() *Layer {
+	for i := len(o.layers) - 1; i >= 0; i-- {
+		l := o.layers[i]
+		if l.Kind == ToastLayer {
+			continue
+		}
+		if l.Persistent {
+			if l.Kind == ModalLayer {
+				// The escape key doesn't reach the layers below.
+				return nil
+			}
+			continue
+		}
+		return l
+	}
+	return nil
+}
+
+// dismiss closes l on behalf of the user.
+func (o *Overlay) dismiss(l *Layer) {
+	if o.Active(l) {
+		o.Close(l)
+		o.closed = append(o.closed, l)
+	}
+}
+
+// Push displays l above the other layers. Pushing a modal layer moves the
+// keyboard focus to it.
+func (o *Overlay) Push(l *Layer) {
+	o.Close(l)
+	l.expires = time.Time{}
+	l.focused = false
+	o.layers = append(o.layers, l)
+}
+
+// Close removes l from the overlay.
+func (o *Overlay) Close(l *Layer) {
+	if i := slices.Index(o.layers, l); i != -1 {
+		o.layers = slices.Delete(o.layers, i, i+1)
+	}
+}
+
+// Active reports whether l is displayed.
+func (o *Overlay) Active(l *Layer) bool {
+	return slices.Contains(o.layers, l)
+}
+
+// Modal reports whether a modal layer is displayed.
+func (o *Overlay) Modal() bool {
+	return o.topModal() != -1
+}
+
+// topModal returns the index of the topmost modal layer, or -1.
+func (o *Overlay) topModal() int {
+	for i := len(o.layers) - 1; i >= 0; i-- {
+		if o.layers[i].Kind == ModalLayer {
+			return i
+		}
+	}
+	return -1
+}
+
+// Layout the content with w and the layers above it. The layers are laid
+// out within the maximum constraints, and scrim lays out the backdrop of
+// modal layers, covering the minimum constraints. A nil scrim draws no
+// backdrop.
+func (o *Overlay) Layout(gtx layout.Context, w, scrim layout.Widget) layout.Dimensions {
+	o.update(gtx)
+	size := gtx.Constraints.Max
+	// Track the pointer over the content and the layers.
+	defer clip.Rect{Max: size}.Push(gtx.Ops).Pop()
+	event.Op(gtx.Ops, o)
+	top := o.topModal()
+	cgtx := gtx
+	if top != -1 {
+		cgtx = gtx.Disabled()
+	}
+	dims := w(cgtx)
+	var toasts []*Layer
+	for i, l := range o.layers {
+		lgtx := gtx
+		if i < top {
+			lgtx = gtx.Disabled()
+		}
+		switch l.Kind {
+		case ModalLayer:
+			o.layoutModal(lgtx, l, size, scrim)
+		case PopoverLayer:
+			o.layoutPopover(lgtx, l, size)
+		case ToastLayer:
+			toasts = append(toasts, l)
+		}
+	}
+	o.layoutToasts(gtx, toasts, size)
+	return dims
+}
+
+func (o *Overlay) layoutModal(gtx layout.Context, l *Layer, size image.Point, scrim layout.Widget) {
+	area := clip.Rect{Max: size}.Push(gtx.Ops)
+	event.Op(gtx.Ops, l)
+	if scrim != nil {
+		sgtx := gtx
+		sgtx.Constraints = layout.Exact(size)
+		scrim(sgtx)
+	}
+	area.Pop()
+	if !l.focused && gtx.Enabled() {
+		l.focused = true
+		gtx.Execute(key.FocusCmd{Tag: &l.tag})
+	}
+	m := op.Record(gtx.Ops)
+	gtx.Constraints = layout.Constraints{Max: size}
+	dims := l.Widget(gtx)
+	call := m.Stop()
+	pos := size.Sub(dims.Size).Div(2)
+	o.layoutContent(gtx, l, pos, dims.Size, call)
+}
+
+func (o *Overlay) layoutPopover(gtx layout.Context, l *Layer, size image.Point) {
+	if !l.Persistent {
+		// Catch presses outside the popover.
+		area := clip.Rect{Max: size}.Push(gtx.Ops)
+		event.Op(gtx.Ops, l)
+		area.Pop()
+	}
+	m := op.Record(gtx.Ops)
+	gtx.Constraints = layout.Constraints{Max: size}
+	dims := l.Widget(gtx)
+	call := m.Stop()
+	o.layoutContent(gtx, l, l.place(size, dims.Size), dims.Size, call)
+}
+
+// place returns the position of the popover l of the given size inside an
+// overlay of overlaySize.
+func (l *Layer) place(overlaySize, size image.Point) image.Point {
+	pos := l.Position
+	if a := l.Anchor; !a.Empty() {
+		pos = image.Pt(a.Min.X+(a.Dx()-size.X)/2, a.Max.Y)
+		if pos.Y+size.Y > overlaySize.Y && a.Min.Y-size.Y >= 0 {
+			pos.Y = a.Min.Y - size.Y
+		}
+	}
+	pos.X = max(min(pos.X, overlaySize.X-size.X), 0)
+	pos.Y = max(min(pos.Y, overlaySize.Y-size.Y), 0)
+	return pos
+}
+
+// layoutToasts stacks the toasts at the bottom center, with the latest
+// toast at the bottom.
+func (o *Overlay) layoutToasts(gtx layout.Context, toasts []*Layer, size image.Point) {
+	margin := gtx.Dp(16)
+	y := size.Y - margin
+	for i := len(toasts) - 1; i >= 0 && y > 0; i-- {
+		l := toasts[i]
+		m := op.Record(gtx.Ops)
+		gtx := gtx
+		gtx.Constraints = layout.Constraints{Max: image.Pt(max(size.X-2*margin, 0), y)}
+		dims := l.Widget(gtx)
+		call := m.Stop()
+		y -= dims.Size.Y
+		pos := image.Pt((size.X-dims.Size.X)/2, y)
+		o.layoutContent(gtx, l, pos, dims.Size, call)
+		y -= gtx.Dp(8)
+	}
+}
+
+// layoutContent adds the content of l at pos. The content area blocks
+// the pointer input to everything below it.
+func (o *Overlay) layoutContent(gtx layout.Context, l *Layer, pos, size image.Point, content op.CallOp) {
+	defer op.Offset(pos).Push(gtx.Ops).Pop()
+	defer clip.Rect{Max: size}.Push(gtx.Ops).Pop()
+	event.Op(gtx.Ops, &l.tag)
+	content.Add(gtx.Ops)
+}
+
+func (k LayerKind) String() string {
+	switch k {
+	case ModalLayer:
+		return "ModalLayer"
+	case PopoverLayer:
+		return "PopoverLayer"
+	case ToastLayer:
+		return "ToastLayer"
+	default:
+		panic("invalid LayerKind")
+	}
+}
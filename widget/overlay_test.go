@@ -0,0 +1,153 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package widget
+
+import (
+	"image"
+	"slices"
+	"testing"
+	"time"
+
+	"gioui.org/f32"
+	"gioui.org/io/input"
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+)
+
+func TestOverlay(t *testing.T) {
+	var (
+		o                   Overlay
+		btn, inner          Clickable
+		btnClicks, inClicks int
+	)
+	r := new(input.Router)
+	gtx := layout.Context{
+		Ops:         new(op.Ops),
+		Constraints: layout.Exact(image.Pt(100, 100)),
+		Source:      r.Source(),
+		Now:         time.Unix(1, 0),
+	}
+	box := func(size image.Point) layout.Widget {
+		return func(gtx layout.Context) layout.Dimensions {
+			return layout.Dimensions{Size: size}
+		}
+	}
+	frame := func() {
+		gtx.Ops.Reset()
+		o.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			if btn.Clicked(gtx) {
+				btnClicks++
+			}
+			return btn.Layout(gtx, box(gtx.Constraints.Max))
+		}, nil)
+		r.Frame(gtx.Ops)
+	}
+	var closed []*Layer
+	update := func() {
+		closed = closed[:0]
+		// Focus changes may defer events to the next frame.
+		for range 2 {
+			for {
+				l, ok := o.Update(gtx)
+				if !ok {
+					break
+				}
+				closed = append(closed, l)
+			}
+			frame()
+		}
+	}
+	click := func(pos f32.Point) {
+		r.Queue(
+			pointer.Event{Kind: pointer.Press, Buttons: pointer.ButtonPrimary, Position: pos},
+			pointer.Event{Kind: pointer.Release, Position: pos},
+		)
+		update()
+	}
+	escape := func() {
+		r.Queue(key.Event{Name: key.NameEscape, State: key.Press})
+		update()
+	}
+	frame()
+	frame()
+	click(f32.Pt(5, 5))
+	if btnClicks != 1 {
+		t.Fatalf("got %d content clicks without layers", btnClicks)
+	}
+
+	modal := &Layer{
+		Kind:       ModalLayer,
+		Persistent: true,
+		Widget: func(gtx layout.Context) layout.Dimensions {
+			if inner.Clicked(gtx) {
+				inClicks++
+			}
+			return inner.Layout(gtx, box(image.Pt(40, 40)))
+		},
+	}
+	o.Push(modal)
+	update()
+	if !gtx.Focused(&modal.tag) {
+		t.Error("modal layer didn't take the focus")
+	}
+	click(f32.Pt(5, 5))
+	click(f32.Pt(50, 50))
+	if btnClicks != 1 || inClicks != 1 {
+		t.Errorf("got %d content clicks and %d modal clicks, want 1 and 1", btnClicks, inClicks)
+	}
+	escape()
+	if len(closed) > 0 || !o.Active(modal) {
+		t.Error("closed persistent modal layer")
+	}
+	// The focus stays within the modal layer.
+	for range 4 {
+		r.MoveFocus(key.FocusForward)
+		frame()
+		if gtx.Focused(&btn) {
+			t.Fatal("focus moved below the modal layer")
+		}
+	}
+	modal.Persistent = false
+	frame()
+	escape()
+	if !slices.Equal(closed, []*Layer{modal}) || o.Modal() {
+		t.Errorf("escape closed %v", closed)
+	}
+
+	popover := &Layer{
+		Kind:     PopoverLayer,
+		Position: image.Pt(90, 90),
+		Widget:   box(image.Pt(20, 20)),
+	}
+	o.Push(popover)
+	update()
+	// The popover is moved inside the overlay.
+	click(f32.Pt(85, 85))
+	if len(closed) > 0 {
+		t.Error("click inside closed the popover")
+	}
+	click(f32.Pt(5, 5))
+	if !slices.Equal(closed, []*Layer{popover}) || btnClicks != 1 {
+		t.Errorf("click outside closed %v with %d content clicks", closed, btnClicks)
+	}
+
+	toast := &Layer{
+		Kind:     ToastLayer,
+		Duration: time.Second,
+		Widget:   box(image.Pt(20, 20)),
+	}
+	o.Push(toast)
+	update()
+	escape()
+	click(f32.Pt(5, 5))
+	if !o.Active(toast) || btnClicks != 2 {
+		t.Error("toast closed early or blocked the content")
+	}
+	gtx.Now = gtx.Now.Add(time.Second)
+	update()
+	if !slices.Equal(closed, []*Layer{toast}) {
+		t.Errorf("got closed layers %v after toast duration", closed)
+	}
+}
@@ -0,0 +1,545 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package widget
+
+import (
+	"bufio"
+	"image"
+	"io"
+	"math"
+	"math/bits"
+	"slices"
+	"unicode/utf8"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/text"
+	"golang.org/x/image/math/fixed"
+)
+
+// paragraphIndex tracks the paragraphs of a text for incremental layout.
+// Rather than shaping the entire text up front like glyphIndex, paragraphs
+// are shaped one at a time when they are displayed or navigated through.
+// Paragraphs that are not shaped yet are assumed to occupy a single line.
+type paragraphIndex struct {
+	paras []paragraph
+	// sums is a Fenwick tree over the metrics of paras, for locating
+	// paragraphs by rune, line or vertical position in logarithmic time.
+	// sums[0] is unused.
+	sums []paragraphMetrics
+	// scanned tracks whether paras reflects the contents of the text.
+	scanned bool
+	// measured tracks whether the line metrics below are up to date.
+	measured bool
+	// lineHeight is the distance between the baselines of two lines, and
+	// ascent and descent are the extents of a line. They're used for
+	// paragraphs that are not shaped.
+	lineHeight      int
+	ascent, descent int
+	// descentRound is the descent of a line, rounded to the nearest pixel.
+	descentRound int
+	// minX and maxX track the horizontal extents of the shaped paragraphs,
+	// if bounded is set.
+	minX, maxX int
+	bounded    bool
+	// shaped counts the paragraphs with glyph data.
+	shaped int
+	// reader is used to find the grapheme clusters of paragraphs.
+	reader graphemeReader
+	// regions is scratch space for locating regions within paragraphs.
+	regions []Region
+	buf     []byte
+}
+
+// paragraph describes a paragraph of text, including its terminating newline,
+// if any.
+type paragraph struct {
+	paragraphMetrics
+	// ascent is the distance between the top of the paragraph and the baseline
+	// of its first line, and descent the distance between the baseline of its
+	// last line and its bottom.
+	ascent, descent int
+	// shaped is the layout of the paragraph, or nil if it is not shaped. The
+	// metrics of a paragraph are kept when its layout is discarded.
+	shaped *shapedParagraph
+}
+
+type paragraphMetrics struct {
+	runes, bytes int
+	lines        int
+	// span is the distance between the baselines of the first line of the
+	// paragraph and the first line of the following paragraph.
+	span int
+}
+
+// shapedParagraph is the layout of a paragraph, with positions relative to its
+// top left corner and rune indices relative to its first rune.
+type shapedParagraph struct {
+	index     glyphIndex
+	graphemes []int
+}
+
+// maxShapedParagraphs is the number of shaped paragraphs above which the
+// layouts of paragraphs outside the viewport are discarded.
+const maxShapedParagraphs = 2048
+
+func (m paragraphMetrics) add(o paragraphMetrics) paragraphMetrics {
+	return paragraphMetrics{
+		runes: m.runes + o.runes,
+		bytes: m.bytes + o.bytes,
+		lines: m.lines + o.lines,
+		span:  m.span + o.span,
+	}
+}
+
+func (m paragraphMetrics) sub(o paragraphMetrics) paragraphMetrics {
+	return paragraphMetrics{
+		runes: m.runes - o.runes,
+		bytes: m.bytes - o.bytes,
+		lines: m.lines - o.lines,
+		span:  m.span - o.span,
+	}
+}
+
+// reset forgets the paragraphs of the text.
+func (p *paragraphIndex) reset() {
+	p.paras = p.paras[:0]
+	p.scanned = false
+	p.shaped = 0
+}
+
+// unshape discards the layout and metrics of every paragraph, for when the
+// text parameters change.
+func (p *paragraphIndex) unshape() {
+	p.measured = false
+}
+
+// newParagraph returns an unshaped paragraph with the given length.
+func (p *paragraphIndex) newParagraph(runes, bytes int) paragraph {
+	return paragraph{
+		paragraphMetrics: paragraphMetrics{runes: runes, bytes: bytes, lines: 1, span: p.lineHeight},
+		ascent:           p.ascent,
+		descent:          p.descent,
+	}
+}
+
+// rebuild recomputes the Fenwick tree of paragraph metrics.
+func (p *paragraphIndex) rebuild() {
+	n := len(p.paras)
+	p.sums = slices.Grow(p.sums[:0], n+1)[:n+1]
+	p.sums[0] = paragraphMetrics{}
+	for i := range p.paras {
+		p.sums[i+1] = p.paras[i].paragraphMetrics
+	}
+	for i := 1; i <= n; i++ {
+		if j := i + i&-i; j <= n {
+			p.sums[j] = p.sums[j].add(p.sums[i])
+		}
+	}
+}
+
+// setMetrics updates the metrics of paragraph i.
+func (p *paragraphIndex) setMetrics(i int, m paragraphMetrics) {
+	delta := m.sub(p.paras[i].paragraphMetrics)
+	p.paras[i].paragraphMetrics = m
+	for j := i + 1; j < len(p.sums); j += j & -j {
+		p.sums[j] = p.sums[j].add(delta)
+	}
+}
+
+// start returns the sum of the metrics of the paragraphs before paragraph i.
+func (p *paragraphIndex) start(i int) paragraphMetrics {
+	var m paragraphMetrics
+	for ; i > 0; i -= i & -i {
+		m = m.add(p.sums[i])
+	}
+	return m
+}
+
+// total returns the sum of the metrics of all paragraphs.
+func (p *paragraphIndex) total() paragraphMetrics {
+	return p.start(len(p.paras))
+}
+
+// find returns the last paragraph whose start, as measured by the field
+// selected by metric, is at most target. It also returns the start of the
+// paragraph.
+func (p *paragraphIndex) find(metric func(m paragraphMetrics) int, target int) (int, paragraphMetrics) {
+	n := len(p.paras)
+	var (
+		i     int
+		start paragraphMetrics
+	)
+	for step := 1 << (bits.Len(uint(n)) - 1); step > 0; step >>= 1 {
+		if j := i + step; j <= n {
+			if next := start.add(p.sums[j]); metric(next) <= target {
+				i, start = j, next
+			}
+		}
+	}
+	if i == n {
+		i--
+		start = start.sub(p.paras[i].paragraphMetrics)
+	}
+	return i, start
+}
+
+func byRunes(m paragraphMetrics) int { return m.runes }
+func byLines(m paragraphMetrics) int { return m.lines }
+func bySpan(m paragraphMetrics) int  { return m.span }
+
+// findY returns the paragraph containing the vertical position y.
+func (p *paragraphIndex) findY(y int) (int, paragraphMetrics) {
+	// A paragraph starts below the descent of the last line of the
+	// paragraph before it.
+	return p.find(bySpan, y-p.baseline()+p.lineHeight-p.descentRound-1)
+}
+
+// baseline returns the baseline of the first line of the text.
+func (p *paragraphIndex) baseline() int {
+	return p.paras[0].ascent
+}
+
+// offset returns the vertical offset of paragraph i with the given start.
+func (p *paragraphIndex) offset(i int, start paragraphMetrics) int {
+	return p.baseline() + start.span - p.paras[i].ascent
+}
+
+// top returns the vertical position of the top of paragraph i.
+func (p *paragraphIndex) top(start paragraphMetrics) int {
+	return p.baseline() + start.span - p.ascent
+}
+
+// globalize converts a position within paragraph i to a position within the
+// text.
+func (p *paragraphIndex) globalize(pos combinedPos, i int, start paragraphMetrics) combinedPos {
+	pos.runes += start.runes
+	pos.lineCol.line += start.lines
+	pos.y += p.offset(i, start)
+	return pos
+}
+
+// scan appends the paragraphs of the bytes in [start, end) of src to paras.
+// If final is set, the range extends to the end of the text and always ends
+// with a (possibly empty) paragraph.
+func (p *paragraphIndex) scan(src io.ReaderAt, start, end int64, final bool, paras []paragraph) []paragraph {
+	const bufSize = 32 * 1024
+	if len(p.buf) < bufSize {
+		p.buf = make([]byte, bufSize)
+	}
+	var runes, bytes, carry int
+	for off := start; off < end; {
+		n, _ := src.ReadAt(p.buf[carry:min(len(p.buf), carry+int(end-off))], off)
+		if n == 0 {
+			break
+		}
+		off += int64(n)
+		b := p.buf[:carry+n]
+		for len(b) > 0 {
+			c := b[0]
+			size := 1
+			if c >= utf8.RuneSelf {
+				if !utf8.FullRune(b) && off < end {
+					// Complete the rune with the next read.
+					break
+				}
+				_, size = utf8.DecodeRune(b)
+			}
+			b = b[size:]
+			runes++
+			bytes += size
+			if c == '\n' {
+				paras = append(paras, p.newParagraph(runes, bytes))
+				runes, bytes = 0, 0
+			}
+		}
+		carry = copy(p.buf, b)
+	}
+	if bytes > 0 || final {
+		paras = append(paras, p.newParagraph(runes, bytes))
+	}
+	return paras
+}
+
+// incremental reports whether the text is laid out one paragraph at a time.
+func (e *textView) incremental() bool {
+	return e.Incremental && !e.SingleLine && e.MaxLines == 0 && e.Mask == 0
+}
+
+// layoutParagraphs prepares the text for incremental layout.
+func (e *textView) layoutParagraphs() {
+	p := &e.paragraphs
+	if !p.measured {
+		p.lineHeight, p.ascent, p.descent, p.descentRound = 0, 0, 0, 0
+		if e.shaper != nil {
+			m := e.shaper.MeasureString(e.params, "\n\n")
+			first, second := m.Lines[0], m.Lines[len(m.Lines)-1]
+			p.lineHeight = int(second.Baseline - first.Baseline)
+			p.ascent = first.Ascent.Ceil()
+			p.descent = first.Descent.Ceil()
+			p.descentRound = first.Descent.Round()
+		}
+		p.minX, p.maxX, p.bounded = 0, 0, false
+		p.shaped = 0
+		for i := range p.paras {
+			p.paras[i] = p.newParagraph(p.paras[i].runes, p.paras[i].bytes)
+		}
+		p.measured = true
+	}
+	if !p.scanned {
+		p.paras = p.scan(e.rr, 0, e.rr.Size(), true, p.paras[:0])
+		p.scanned = true
+		p.shaped = 0
+	}
+	p.rebuild()
+	e.updateParagraphDims()
+}
+
+// updateParagraphDims updates the text dimensions from the paragraph metrics.
+func (e *textView) updateParagraphDims() {
+	p := &e.paragraphs
+	last := p.paras[len(p.paras)-1]
+	baseline := p.baseline()
+	height := baseline + p.total().span - p.lineHeight + last.descent
+	e.dims = layout.Dimensions{
+		Size:     image.Pt(p.maxX-p.minX, height),
+		Baseline: height - baseline,
+	}
+}
+
+// shapeParagraph returns the layout of paragraph i, shaping it if necessary.
+func (e *textView) shapeParagraph(i int, start paragraphMetrics) *shapedParagraph {
+	p := &e.paragraphs
+	para := &p.paras[i]
+	if para.shaped != nil {
+		return para.shaped
+	}
+	sp := new(shapedParagraph)
+	params := e.params
+	if params.MaxWidth != math.MaxInt {
+		// Align every paragraph to the same width.
+		params.MinWidth = max(params.MinWidth, params.MaxWidth)
+	}
+	src := io.NewSectionReader(e.rr, int64(start.bytes), int64(para.bytes))
+	it := textIterator{viewport: image.Rectangle{Max: image.Point{X: math.MaxInt, Y: math.MaxInt}}}
+	if e.shaper != nil {
+		e.shaper.Layout(params, src)
+		broke := false
+		for {
+			g, ok := e.shaper.NextGlyph()
+			if !it.processGlyph(g, ok) {
+				break
+			}
+			sp.index.Glyph(g)
+			// Skip the empty line the shaper adds after a trailing newline; it
+			// belongs to the next paragraph.
+			broke = broke || g.Flags&text.FlagParagraphBreak != 0
+			if broke && g.Flags&text.FlagLineBreak != 0 {
+				break
+			}
+		}
+	} else {
+		// Make a fake glyph for every rune in the paragraph.
+		b := bufio.NewReader(src)
+		for r, _, err := b.ReadRune(); err != io.EOF; r, _, err = b.ReadRune() {
+			g := text.Glyph{Runes: 1, Flags: text.FlagClusterBreak}
+			if r == '\n' {
+				g.Flags |= text.FlagParagraphBreak
+			}
+			_ = it.processGlyph(g, true)
+			sp.index.Glyph(g)
+		}
+	}
+	p.reader.SetSource(src)
+	sp.graphemes = append(sp.graphemes, p.reader.Graphemes()...)
+	if len(sp.graphemes) == 0 {
+		sp.graphemes = append(sp.graphemes, 0)
+	}
+	if lines := sp.index.lines; len(lines) > 0 {
+		first, last := lines[0], lines[len(lines)-1]
+		para.ascent = first.yOff
+		para.descent = last.descent.Ceil()
+		m := para.paragraphMetrics
+		m.lines = len(lines)
+		m.span = last.yOff - first.yOff + p.lineHeight
+		p.setMetrics(i, m)
+	}
+	if !p.bounded {
+		p.minX, p.maxX = it.bounds.Min.X, it.bounds.Max.X
+		p.bounded = true
+	} else {
+		p.minX = min(p.minX, it.bounds.Min.X)
+		p.maxX = max(p.maxX, it.bounds.Max.X)
+	}
+	para.shaped = sp
+	p.shaped++
+	e.updateParagraphDims()
+	return sp
+}
+
+// discardParagraphs discards the layouts of the paragraphs outside of
+// [first, last] if too many paragraphs are shaped.
+func (e *textView) discardParagraphs(first, last int) {
+	p := &e.paragraphs
+	if p.shaped <= maxShapedParagraphs {
+		return
+	}
+	for i := range p.paras {
+		if (i < first || i > last) && p.paras[i].shaped != nil {
+			p.paras[i].shaped = nil
+			p.shaped--
+		}
+	}
+}
+
+func (e *textView) paragraphClosestToRune(runeIdx int) combinedPos {
+	i, start := e.paragraphs.find(byRunes, runeIdx)
+	sp := e.shapeParagraph(i, start)
+	pos, _ := sp.index.closestToRune(runeIdx - start.runes)
+	return e.paragraphs.globalize(pos, i, start)
+}
+
+func (e *textView) paragraphClosestToLineCol(line, col int) combinedPos {
+	p := &e.paragraphs
+	i, start := p.find(byLines, line)
+	// Shaping a paragraph updates its line count, which may move the line to
+	// an earlier paragraph.
+	for p.paras[i].shaped == nil {
+		e.shapeParagraph(i, start)
+		i, start = p.find(byLines, line)
+	}
+	pos := p.paras[i].shaped.index.closestToLineCol(screenPos{line: line - start.lines, col: col})
+	return p.globalize(pos, i, start)
+}
+
+func (e *textView) paragraphClosestToXY(x fixed.Int26_6, y int) combinedPos {
+	p := &e.paragraphs
+	i, start := p.findY(y)
+	for p.paras[i].shaped == nil {
+		e.shapeParagraph(i, start)
+		i, start = p.findY(y)
+	}
+	for {
+		sp := e.shapeParagraph(i, start)
+		pos := p.globalize(sp.index.closestToXY(x, y-p.offset(i, start)), i, start)
+		if pos.y+pos.descent.Round() >= y || i+1 == len(p.paras) {
+			return pos
+		}
+		// The position is between this paragraph and the next.
+		start = start.add(p.paras[i].paragraphMetrics)
+		i++
+	}
+}
+
+// paragraphLocate is like glyphIndex.locate for incrementally laid out text.
+func (e *textView) paragraphLocate(viewport image.Rectangle, startRune, endRune int, rects []Region) []Region {
+	p := &e.paragraphs
+	if startRune > endRune {
+		startRune, endRune = endRune, startRune
+	}
+	rects = rects[:0]
+	i, start := p.find(byRunes, startRune)
+	// Skip paragraphs above the viewport.
+	if j, s := p.findY(viewport.Min.Y); j > i {
+		i, start = j, s
+	}
+	for ; i < len(p.paras) && start.runes <= endRune; i++ {
+		if p.top(start) > viewport.Max.Y {
+			break
+		}
+		sp := e.shapeParagraph(i, start)
+		para := p.paras[i]
+		local := viewport.Sub(image.Pt(0, p.offset(i, start)))
+		p.regions = sp.index.locate(local, startRune-start.runes, min(endRune-start.runes, para.runes), p.regions)
+		rects = append(rects, p.regions...)
+		start = start.add(para.paragraphMetrics)
+	}
+	return rects
+}
+
+// paintParagraphs paints the visible paragraphs of incrementally laid out
+// text, and returns the padding required to avoid clipping glyphs.
+func (e *textView) paintParagraphs(gtx layout.Context, viewport image.Rectangle, material op.CallOp) image.Rectangle {
+	p := &e.paragraphs
+	var padding image.Rectangle
+	first, start := p.findY(viewport.Min.Y)
+	i := first
+	for ; i < len(p.paras) && p.top(start) <= viewport.Max.Y; i++ {
+		sp := e.shapeParagraph(i, start)
+		it := textIterator{
+			viewport:   viewport.Sub(image.Pt(0, p.offset(i, start))),
+			material:   material,
+			decoration: e.Decoration,
+		}
+		e.paintIndex(gtx, &it, &sp.index)
+		padding = padding.Union(it.padding)
+		start = start.add(p.paras[i].paragraphMetrics)
+	}
+	e.discardParagraphs(first, i)
+	return padding
+}
+
+func (e *textView) paragraphMoveByGraphemes(startRuneIdx, graphemes int) int {
+	p := &e.paragraphs
+	i, start := p.find(byRunes, startRuneIdx)
+	g := e.shapeParagraph(i, start).graphemes
+	idx, _ := slices.BinarySearch(g, startRuneIdx-start.runes)
+	idx += graphemes
+	// The last boundary of a paragraph is the first boundary of the next.
+	for idx < 0 && i > 0 {
+		i--
+		start = start.sub(p.paras[i].paragraphMetrics)
+		g = e.shapeParagraph(i, start).graphemes
+		idx += len(g) - 1
+	}
+	for idx >= len(g) && i+1 < len(p.paras) {
+		idx -= len(g) - 1
+		start = start.add(p.paras[i].paragraphMetrics)
+		i++
+		g = e.shapeParagraph(i, start).graphemes
+	}
+	idx = min(max(idx, 0), len(g)-1)
+	return e.closestToRune(start.runes + g[idx]).runes
+}
+
+// paragraphRuneOffset is runeOffset for incrementally laid out text.
+func (e *textView) paragraphRuneOffset(r int) int {
+	p := &e.paragraphs
+	i, start := p.find(byRunes, r)
+	off := start.bytes
+	n := min(r-start.runes, p.paras[i].runes)
+	end := off + p.paras[i].bytes
+	for n > 0 && off < end {
+		_, s, _ := e.ReadRuneAt(int64(off))
+		off += s
+		n--
+	}
+	return off
+}
+
+// replaceParagraphs updates the paragraphs after the runes in [startRune,
+// endRune) have been replaced, changing the size of the text by delta bytes.
+func (e *textView) replaceParagraphs(startRune, endRune int, delta int64) {
+	p := &e.paragraphs
+	i, start := p.find(byRunes, startRune)
+	j, end := p.find(byRunes, endRune)
+	for k := i; k <= j; k++ {
+		if p.paras[k].shaped != nil {
+			p.shaped--
+		}
+	}
+	endOff := int64(end.bytes+p.paras[j].bytes) + delta
+	final := j == len(p.paras)-1
+	paras := p.scan(e.rr, int64(start.bytes), endOff, final, nil)
+	if len(paras) == j-i+1 {
+		for k, para := range paras {
+			p.paras[i+k].shaped = nil
+			p.paras[i+k].ascent, p.paras[i+k].descent = para.ascent, para.descent
+			p.setMetrics(i+k, para.paragraphMetrics)
+		}
+	} else {
+		p.paras = slices.Replace(p.paras, i, j+1, paras...)
+		p.rebuild()
+	}
+	e.updateParagraphDims()
+}
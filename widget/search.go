@@ -0,0 +1,243 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package widget
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"unicode/utf8"
+
+	"gioui.org/font"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/text"
+	"gioui.org/unit"
+)
+
+// SearchOptions configures how Editor.Search matches its query.
+type SearchOptions struct {
+	// Regexp interprets the query as a regular expression in the syntax
+	// accepted by package regexp. Replacement strings may then refer to
+	// submatches as described by regexp.Regexp.Expand.
+	Regexp bool
+	// IgnoreCase matches letters regardless of their case.
+	IgnoreCase bool
+}
+
+// Match is the rune range of a search match.
+type Match struct {
+	Start, End int
+}
+
+// editorSearch is the state of an Editor search.
+type editorSearch struct {
+	re *regexp.Regexp
+	// regexp tracks whether re was compiled from a regular expression
+	// query, as opposed to a literal query.
+	regexp bool
+	// revision is the text revision the matches were found in. It is -1 if
+	// the matches are not valid.
+	revision int
+	// text is the text the matches were found in.
+	text    []byte
+	matches []Match
+	// submatches holds the byte offsets of the submatches of every match,
+	// in the format of regexp.Regexp.FindAllSubmatchIndex.
+	submatches [][]int
+}
+
+// Search sets the query highlighted by LayoutMatches and stepped through by
+// FindNext and FindPrevious. An empty query ends the search. Search returns
+// an error if opts.Regexp is set and query is not a valid regular expression.
+func (e *Editor) Search(query string, opts SearchOptions) error {
+	e.search = editorSearch{}
+	if query == "" {
+		return nil
+	}
+	expr := query
+	if !opts.Regexp {
+		expr = regexp.QuoteMeta(query)
+	}
+	if opts.IgnoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return err
+	}
+	e.search = editorSearch{re: re, regexp: opts.Regexp, revision: -1}
+	return nil
+}
+
+// Matches returns the non-empty matches of the search query in text order.
+func (e *Editor) Matches() []Match {
+	e.initBuffer()
+	return slices.Clone(e.findMatches())
+}
+
+// findMatches updates the matches of the search for the current text.
+func (e *Editor) findMatches() []Match {
+	s := &e.search
+	if s.re == nil {
+		return nil
+	}
+	if s.revision == e.text.revision {
+		return s.matches
+	}
+	s.revision = e.text.revision
+	s.text = e.text.Text(s.text)
+	s.matches = s.matches[:0]
+	s.submatches = s.submatches[:0]
+	// Convert byte offsets to rune offsets incrementally, relying on
+	// matches being ordered.
+	var off, runes int
+	for _, sub := range s.re.FindAllSubmatchIndex(s.text, -1) {
+		start, end := sub[0], sub[1]
+		if start == end {
+			continue
+		}
+		runes += utf8.RuneCount(s.text[off:start])
+		m := Match{Start: runes}
+		runes += utf8.RuneCount(s.text[start:end])
+		m.End = runes
+		off = end
+		s.matches = append(s.matches, m)
+		s.submatches = append(s.submatches, sub)
+	}
+	return s.matches
+}
+
+// FindNext selects the first match after the selection, wrapping around to
+// the start of the text, and scrolls it into view. It reports whether
+// there was a match.
+func (e *Editor) FindNext() bool {
+	e.initBuffer()
+	matches := e.findMatches()
+	if len(matches) == 0 {
+		return false
+	}
+	start, end := e.text.Selection()
+	pos := max(start, end)
+	i := sort.Search(len(matches), func(i int) bool {
+		return matches[i].Start >= pos
+	})
+	if i == len(matches) {
+		i = 0
+	}
+	e.selectMatch(matches[i])
+	return true
+}
+
+// FindPrevious selects the last match before the selection, wrapping
+// around to the end of the text, and scrolls it into view. It reports
+// whether there was a match.
+func (e *Editor) FindPrevious() bool {
+	e.initBuffer()
+	matches := e.findMatches()
+	if len(matches) == 0 {
+		return false
+	}
+	start, end := e.text.Selection()
+	pos := min(start, end)
+	i := sort.Search(len(matches), func(i int) bool {
+		return matches[i].Start >= pos
+	}) - 1
+	if i < 0 {
+		i = len(matches) - 1
+	}
+	e.selectMatch(matches[i])
+	return true
+}
+
+func (e *Editor) selectMatch(m Match) {
+	e.text.clearCarets()
+	e.SetCaret(m.End, m.Start)
+}
+
+// Replace replaces the selection with repl if the selection is a match,
+// and then selects the next match. It reports whether the selection was
+// replaced.
+func (e *Editor) Replace(repl string) bool {
+	e.initBuffer()
+	matches := e.findMatches()
+	start, end := e.text.Selection()
+	start, end = min(start, end), max(start, end)
+	i := sort.Search(len(matches), func(i int) bool {
+		return matches[i].Start >= start
+	})
+	replaced := i < len(matches) && matches[i] == Match{Start: start, End: end}
+	if replaced {
+		_, _, caret := e.replace(start, end, e.expand(repl, i), true)
+		e.text.clearCarets()
+		e.SetCaret(caret, caret)
+	}
+	e.FindNext()
+	return replaced
+}
+
+// ReplaceAll replaces every match with repl as a single undoable
+// modification. It returns the number of matches replaced.
+func (e *Editor) ReplaceAll(repl string) int {
+	e.initBuffer()
+	matches := e.findMatches()
+	if len(matches) == 0 {
+		return 0
+	}
+	// Expand the replacements before the matches are invalidated.
+	repls := make([]string, len(matches))
+	for i := range matches {
+		repls[i] = e.expand(repl, i)
+	}
+	matches = append([]Match(nil), matches...)
+	e.BeginUndoGroup()
+	defer e.EndUndoGroup()
+	// Replace from the end, so the positions of the remaining matches stay
+	// valid.
+	for i := len(matches) - 1; i >= 0; i-- {
+		m := matches[i]
+		e.replace(m.Start, m.End, repls[i], true)
+	}
+	return len(matches)
+}
+
+// expand returns the replacement for match i.
+func (e *Editor) expand(repl string, i int) string {
+	s := &e.search
+	if !s.regexp {
+		return repl
+	}
+	return string(s.re.Expand(nil, []byte(repl), s.text, s.submatches[i]))
+}
+
+// LayoutMatches is like Layout, and in addition highlights the matches of
+// the search query set by Search with matchMaterial.
+func (e *Editor) LayoutMatches(gtx layout.Context, lt *text.Shaper, font font.Font, size unit.Sp, textMaterial, selectMaterial, matchMaterial op.CallOp) layout.Dimensions {
+	for {
+		_, ok := e.Update(gtx)
+		if !ok {
+			break
+		}
+	}
+
+	e.text.Layout(gtx, lt, font, size)
+	return e.layout(gtx, textMaterial, selectMaterial, matchMaterial)
+}
+
+// paintMatches paints the visible matches of the search query.
+func (e *Editor) paintMatches(gtx layout.Context, material op.CallOp) {
+	matches := e.findMatches()
+	if len(matches) == 0 {
+		return
+	}
+	start, end := e.text.visibleRunes()
+	i := sort.Search(len(matches), func(i int) bool {
+		return matches[i].End > start
+	})
+	for _, m := range matches[i:] {
+		if m.Start >= end {
+			break
+		}
+		e.text.paintRegions(gtx, material, m.Start, m.End)
+	}
+}
@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package widget
+
+import (
+	"image"
+	"image/color"
+	"slices"
+	"testing"
+
+	"gioui.org/font"
+	"gioui.org/font/gofont"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/paint"
+	"gioui.org/text"
+	"gioui.org/unit"
+)
+
+func TestEditorSearch(t *testing.T) {
+	e := new(Editor)
+	e.SetText("Foo bar foo\nfoo")
+	assertMatches := func(want ...Match) {
+		t.Helper()
+		if got := e.Matches(); !slices.Equal(got, want) {
+			t.Errorf("got matches %v, want %v", got, want)
+		}
+	}
+	assertMatches()
+	if err := e.Search("foo", SearchOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	assertMatches(Match{8, 11}, Match{12, 15})
+	e.Search("foo", SearchOptions{IgnoreCase: true})
+	assertMatches(Match{0, 3}, Match{8, 11}, Match{12, 15})
+
+	e.FindNext()
+	assertContents(t, e, "Foo bar foo\nfoo", 3, 0)
+	e.FindNext()
+	assertContents(t, e, "Foo bar foo\nfoo", 11, 8)
+	e.FindPrevious()
+	assertContents(t, e, "Foo bar foo\nfoo", 3, 0)
+	e.FindPrevious()
+	assertContents(t, e, "Foo bar foo\nfoo", 15, 12)
+
+	e.SetCaret(0, 0)
+	if e.Replace("baz") {
+		t.Error("replaced a selection that is not a match")
+	}
+	if !e.Replace("baz") {
+		t.Error("didn't replace a match")
+	}
+	assertContents(t, e, "baz bar foo\nfoo", 11, 8)
+	// Matches follow changes to the text.
+	assertMatches(Match{8, 11}, Match{12, 15})
+	if n := e.ReplaceAll("qux"); n != 2 {
+		t.Errorf("replaced %d matches, want 2", n)
+	}
+	assertContents(t, e, "baz bar qux\nqux", 11, 8)
+	assertMatches()
+	// Replacing all matches is undone in a single step.
+	e.undo()
+	if got, want := e.Text(), "baz bar foo\nfoo"; got != want {
+		t.Errorf("got %q after undo, want %q", got, want)
+	}
+
+	e.SetText("a=1\nb=2")
+	if err := e.Search(`(\w)=(\d)`, SearchOptions{Regexp: true}); err != nil {
+		t.Fatal(err)
+	}
+	e.ReplaceAll("$2=$1")
+	if got, want := e.Text(), "1=a\n2=b"; got != want {
+		t.Errorf("got %q after replacing submatches, want %q", got, want)
+	}
+	if err := e.Search("(", SearchOptions{Regexp: true}); err == nil {
+		t.Error("invalid regular expression accepted")
+	}
+	assertMatches()
+}
+
+func TestEditorLayoutMatches(t *testing.T) {
+	gtx := layout.Context{
+		Ops:         new(op.Ops),
+		Constraints: layout.Exact(image.Pt(100, 30)),
+		Locale:      english,
+	}
+	cache := text.NewShaper(text.NoSystemFonts(), text.WithCollection(gofont.Collection()))
+	e := new(Editor)
+	e.SetText("foo\nbar\nbaz\nfoo\nbar\nbaz\nfoo")
+	e.Search("foo", SearchOptions{})
+	m := op.Record(gtx.Ops)
+	paint.ColorOp{Color: color.NRGBA{A: 0xff}}.Add(gtx.Ops)
+	material := m.Stop()
+	e.LayoutMatches(gtx, cache, font.Font{}, unit.Sp(10), op.CallOp{}, op.CallOp{}, material)
+	start, end := e.text.visibleRunes()
+	if start != 0 || end >= e.Len() {
+		t.Errorf("got visible runes [%d, %d) of %d", start, end, e.Len())
+	}
+	// Scroll to the last match.
+	e.FindPrevious()
+	e.LayoutMatches(gtx, cache, font.Font{}, unit.Sp(10), op.CallOp{}, op.CallOp{}, material)
+	start, end = e.text.visibleRunes()
+	if start == 0 || end != e.Len() {
+		t.Errorf("got visible runes [%d, %d) of %d after scrolling", start, end, e.Len())
+	}
+}
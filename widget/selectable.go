@@ -2,13 +2,11 @@ package widget
 
 import (
 	"image"
-	"io"
 	"math"
 	"strings"
 
 	"gioui.org/font"
 	"gioui.org/gesture"
-	"gioui.org/io/clipboard"
 	"gioui.org/io/event"
 	"gioui.org/io/key"
 	"gioui.org/io/pointer"
@@ -20,13 +18,13 @@ import (
 	"gioui.org/unit"
 )
 
-// stringSource is an immutable textSource with a fixed string
+// stringSource is an immutable TextSource with a fixed string
 // value.
 type stringSource struct {
 	reader *strings.Reader
 }
 
-var _ textSource = stringSource{}
+var _ TextSource = stringSource{}
 
 func newStringSource(str string) stringSource {
 	return stringSource{
@@ -67,8 +65,17 @@ type Selectable struct {
 	// LineHeightScale applies a scaling factor to the LineHeight. If zero, a
 	// sensible default will be used.
 	LineHeightScale float32
-	initialized     bool
-	source          stringSource
+	// LetterSpacing is extra space added after every glyph cluster.
+	LetterSpacing unit.Sp
+	// WordSpacing is extra space added to every word separator.
+	WordSpacing unit.Sp
+	// Decoration configures lines drawn alongside the text, such as underlines.
+	Decoration text.Decoration
+	// MenuItems, if set, is called with the default items of the context
+	// menu when it opens, and returns the items to display.
+	MenuItems   func(items []MenuItem) []MenuItem
+	initialized bool
+	source      TextSource
 	// scratch is a buffer reused to efficiently read text out of the
 	// textView.
 	scratch   []byte
@@ -79,6 +86,7 @@ type Selectable struct {
 	dragger   gesture.Drag
 
 	clicker gesture.Click
+	menu    ContextMenu
 }
 
 // initialize must be called at the beginning of any exported method that
@@ -158,13 +166,28 @@ func (l *Selectable) Text() string {
 // text will clear the selection unless the selectable already contains s.
 func (l *Selectable) SetText(s string) {
 	l.initialize()
-	if l.lastValue != s {
+	if _, ok := l.source.(stringSource); !ok || l.lastValue != s {
 		l.source = newStringSource(s)
 		l.lastValue = s
 		l.text.SetSource(l.source)
 	}
 }
 
+// SetSource makes the selectable display the text of src, which avoids copying
+// texts that are already stored elsewhere. Changes to src must be reported by
+// src.Changed, and are displayed the next time the selectable is laid out.
+// Selectable never modifies src. Setting the text with SetText or a nil src
+// replaces src.
+func (l *Selectable) SetSource(src TextSource) {
+	l.initialize()
+	if src == nil {
+		src = newStringSource("")
+		l.lastValue = ""
+	}
+	l.source = src
+	l.text.SetSource(src)
+}
+
 // Truncated returns whether the text has been truncated by the text shaper to
 // fit within available constraints.
 func (l *Selectable) Truncated() bool {
@@ -189,6 +212,9 @@ func (l *Selectable) Layout(gtx layout.Context, lt *text.Shaper, font font.Font,
 	l.text.MaxLines = l.MaxLines
 	l.text.Truncator = l.Truncator
 	l.text.WrapPolicy = l.WrapPolicy
+	l.text.LetterSpacing = l.LetterSpacing
+	l.text.WordSpacing = l.WordSpacing
+	l.text.Decoration = l.Decoration
 	l.text.Layout(gtx, lt, font, size)
 	dims := l.text.Dimensions()
 	defer clip.Rect(image.Rectangle{Max: dims.Size}).Push(gtx.Ops).Pop()
@@ -197,6 +223,7 @@ func (l *Selectable) Layout(gtx layout.Context, lt *text.Shaper, font font.Font,
 
 	l.clicker.Add(gtx.Ops)
 	l.dragger.Add(gtx.Ops)
+	l.menu.Add(gtx.Ops)
 
 	l.paintSelection(gtx, selectionMaterial)
 	l.paintText(gtx, textMaterial)
@@ -210,11 +237,39 @@ func (l *Selectable) handleEvents(gtx layout.Context) (selectionChanged bool) {
 			selectionChanged = true
 		}
 	}()
+	if l.menu.Update(gtx) {
+		l.openMenu(gtx)
+	}
 	l.processPointer(gtx)
 	l.processKey(gtx)
 	return selectionChanged
 }
 
+// openMenu prepares the context menu opened by a secondary click.
+func (l *Selectable) openMenu(gtx layout.Context) {
+	gtx.Execute(key.FocusCmd{Tag: l})
+	items := []MenuItem{
+		{Label: "Copy", Shortcut: shortcutLabel(key.ModShortcut, "C"), Disabled: l.text.SelectionLen() == 0, Action: func(gtx layout.Context) {
+			l.scratch = l.text.SelectedText(l.scratch)
+			writeClipboard(gtx, string(l.scratch))
+		}},
+		{Label: "Select All", Shortcut: shortcutLabel(key.ModShortcut, "A"), Action: func(gtx layout.Context) {
+			l.text.SetCaret(0, l.text.Len())
+		}},
+	}
+	if l.MenuItems != nil {
+		items = l.MenuItems(items)
+	}
+	l.menu.Menu.Items = items
+}
+
+// ContextMenu returns the context menu opened by secondary clicks on the
+// label. Display it by calling its LayoutMenu method after laying out the
+// label. Items can be customized with MenuItems.
+func (l *Selectable) ContextMenu() *ContextMenu {
+	return &l.menu
+}
+
 func (e *Selectable) processPointer(gtx layout.Context) {
 	for _, evt := range e.clickDragEvents(gtx) {
 		switch evt := evt.(type) {
@@ -342,7 +397,7 @@ func (e *Selectable) command(gtx layout.Context, k key.Event) {
 		case "C", "X":
 			e.scratch = e.text.SelectedText(e.scratch)
 			if text := string(e.scratch); text != "" {
-				gtx.Execute(clipboard.WriteCmd{Type: "application/text", Data: io.NopCloser(strings.NewReader(text))})
+				writeClipboard(gtx, text)
 			}
 		// Select all
 		case "A":
@@ -32,6 +32,34 @@ func TestSelectableZeroValue(t *testing.T) {
 	}
 }
 
+func TestSelectableSource(t *testing.T) {
+	gtx := layout.Context{
+		Ops:         new(op.Ops),
+		Constraints: layout.Exact(image.Pt(100, 100)),
+		Locale:      english,
+	}
+	cache := text.NewShaper(text.NoSystemFonts(), text.WithCollection(gofont.Collection()))
+	src := new(editBuffer)
+	src.ReplaceRunes(0, 0, "hello")
+	var s Selectable
+	s.SetSource(src)
+	s.Layout(gtx, cache, font.Font{}, unit.Sp(10), op.CallOp{}, op.CallOp{})
+	s.SetCaret(0, 5)
+	if got := s.SelectedText(); got != "hello" {
+		t.Errorf("got selection %q", got)
+	}
+	src.ReplaceRunes(5, 0, " world")
+	s.Layout(gtx, cache, font.Font{}, unit.Sp(10), op.CallOp{}, op.CallOp{})
+	s.SetCaret(0, 100)
+	if got := s.SelectedText(); got != "hello world" {
+		t.Errorf("got selection %q after changing the source", got)
+	}
+	s.SetText("")
+	if got := s.Text(); got != "" {
+		t.Errorf("got text %q, want empty text", got)
+	}
+}
+
 // Verify that an existing selection is dismissed when you press arrow keys.
 func TestSelectableMove(t *testing.T) {
 	r := new(input.Router)
@@ -0,0 +1,258 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package widget
+
+import (
+	"slices"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+
+	"gioui.org/f32"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+)
+
+// SpellChecker checks the spelling of the words of an Editor. Words are
+// delimited by whitespace, like the words MoveWord moves between, and
+// stripped of leading and trailing punctuation.
+type SpellChecker interface {
+	// Check reports whether word is spelled correctly.
+	Check(word string) bool
+	// Suggest returns corrections for a misspelled word, best first.
+	Suggest(word string) []string
+}
+
+// editorSpell is the spell checking state of an Editor.
+type editorSpell struct {
+	checker SpellChecker
+	// revision is the text revision reflected by misspelled and dirty. It
+	// is -1 if the text must be checked in full.
+	revision int
+	// misspelled holds the ranges of the misspelled words, in text order.
+	misspelled []Match
+	// dirty holds the ranges changed since they were last checked.
+	dirty   []Match
+	buf     []byte
+	regions []Region
+}
+
+// SetSpellChecker sets the checker for the spelling of the text, or
+// disables spell checking if c is nil. After a full check, only the words
+// touched by changes are checked again. Call SetSpellChecker again to check
+// the entire text, for example after adding words to a dictionary.
+func (e *Editor) SetSpellChecker(c SpellChecker) {
+	e.spell = editorSpell{checker: c, revision: -1}
+}
+
+// Misspellings returns the ranges of the misspelled words in text order.
+func (e *Editor) Misspellings() []Match {
+	e.initBuffer()
+	return slices.Clone(e.updateSpelling())
+}
+
+// MisspellingAt returns the range of the misspelled word containing or
+// touching runeOffset and the corrections suggested for it, for example to
+// offer them in a context menu. A correction is applied by selecting m and
+// inserting it. MisspellingAt reports false if there is no misspelled word
+// at runeOffset.
+func (e *Editor) MisspellingAt(runeOffset int) (m Match, suggestions []string, ok bool) {
+	e.initBuffer()
+	misspelled := e.updateSpelling()
+	i := sort.Search(len(misspelled), func(i int) bool {
+		return misspelled[i].End >= runeOffset
+	})
+	if i == len(misspelled) || misspelled[i].Start > runeOffset {
+		return Match{}, nil, false
+	}
+	m = misspelled[i]
+	word := e.spellWord(m)
+	return m, e.spell.checker.Suggest(word), true
+}
+
+// spellWord returns the text of the rune range m.
+func (e *Editor) spellWord(m Match) string {
+	start, end := e.text.ByteOffset(m.Start), e.text.ByteOffset(m.End)
+	buf := make([]byte, end-start)
+	n, _ := e.text.ReadAt(buf, start)
+	return string(buf[:n])
+}
+
+// spellReplace updates the misspellings after the runes in [start, end)
+// are replaced by the runes in [start, newEnd).
+func (e *Editor) spellReplace(start, end, newEnd int) {
+	s := &e.spell
+	// Leave the text to be checked in full if it is not in sync.
+	if s.checker == nil || s.revision < 0 || s.revision+1 != e.text.revision {
+		return
+	}
+	s.revision = e.text.revision
+	adjust := func(pos int) int {
+		switch {
+		case newEnd < pos && pos <= end:
+			pos = newEnd
+		case end < pos:
+			pos += newEnd - end
+		}
+		return pos
+	}
+	// Drop the misspellings touching the replaced range; they are checked
+	// again along with the words of the replacement.
+	s.misspelled = slices.DeleteFunc(s.misspelled, func(m Match) bool {
+		return m.End >= start && m.Start <= end
+	})
+	for i := range s.misspelled {
+		m := &s.misspelled[i]
+		m.Start, m.End = adjust(m.Start), adjust(m.End)
+	}
+	for i := range s.dirty {
+		d := &s.dirty[i]
+		d.Start, d.End = adjust(d.Start), adjust(d.End)
+	}
+	s.dirty = append(s.dirty, Match{Start: start, End: newEnd})
+}
+
+// updateSpelling checks the words changed since the last check and returns
+// the misspellings.
+func (e *Editor) updateSpelling() []Match {
+	s := &e.spell
+	if s.checker == nil {
+		return nil
+	}
+	if s.revision != e.text.revision {
+		s.revision = e.text.revision
+		s.misspelled = s.misspelled[:0]
+		s.dirty = append(s.dirty[:0], Match{Start: 0, End: e.text.Len()})
+	}
+	for _, d := range s.dirty {
+		e.checkSpelling(d.Start, d.End)
+	}
+	s.dirty = s.dirty[:0]
+	return s.misspelled
+}
+
+// checkSpelling checks the words touching the rune range [start, end).
+func (e *Editor) checkSpelling(start, end int) {
+	s := &e.spell
+	start, end = e.text.closestToRune(start).runes, e.text.closestToRune(end).runes
+	startOff, endOff := e.text.ByteOffset(start), e.text.ByteOffset(end)
+	// Expand the range to whole words.
+	for startOff > 0 {
+		r, n, _ := e.text.ReadRuneBefore(startOff)
+		if n == 0 || unicode.IsSpace(r) {
+			break
+		}
+		startOff -= int64(n)
+		start--
+	}
+	for {
+		r, n, _ := e.text.ReadRuneAt(endOff)
+		if n == 0 || unicode.IsSpace(r) {
+			break
+		}
+		endOff += int64(n)
+		end++
+	}
+	if n := int(endOff - startOff); cap(s.buf) < n {
+		s.buf = make([]byte, n)
+	}
+	s.buf = s.buf[:endOff-startOff]
+	n, _ := e.text.ReadAt(s.buf, startOff)
+	s.buf = s.buf[:n]
+
+	lo := sort.Search(len(s.misspelled), func(i int) bool {
+		return s.misspelled[i].End > start
+	})
+	hi := sort.Search(len(s.misspelled), func(i int) bool {
+		return s.misspelled[i].Start >= end
+	})
+	var found []Match
+	forEachWord(s.buf, func(word []byte, wstart, wend int) {
+		if !s.checker.Check(string(word)) {
+			found = append(found, Match{Start: start + wstart, End: start + wend})
+		}
+	})
+	s.misspelled = slices.Replace(s.misspelled, lo, max(lo, hi), found...)
+}
+
+// forEachWord calls f for every whitespace delimited word of text, with
+// leading and trailing punctuation removed. The rune range of the word is
+// relative to the start of text.
+func forEachWord(text []byte, f func(word []byte, start, end int)) {
+	var runes int
+	for len(text) > 0 {
+		r, n := utf8.DecodeRune(text)
+		if unicode.IsSpace(r) {
+			text = text[n:]
+			runes++
+			continue
+		}
+		// Find the end of the word and the runes to trim from it.
+		var size, count, first, last, firstOff, lastOff int
+		first = -1
+		for size < len(text) {
+			r, n := utf8.DecodeRune(text[size:])
+			if unicode.IsSpace(r) {
+				break
+			}
+			if isWordRune(r) {
+				if first == -1 {
+					first, firstOff = count, size
+				}
+				last, lastOff = count+1, size+n
+			}
+			size += n
+			count++
+		}
+		if first != -1 {
+			f(text[firstOff:lastOff], runes+first, runes+last)
+		}
+		text = text[size:]
+		runes += count
+	}
+}
+
+// PaintMisspellings paints wavy lines with material under the visible
+// misspelled words. Call it after Layout.
+func (e *Editor) PaintMisspellings(gtx layout.Context, material op.CallOp) {
+	e.initBuffer()
+	misspelled := e.updateSpelling()
+	if len(misspelled) == 0 {
+		return
+	}
+	defer clip.Rect{Max: e.text.viewSize}.Push(gtx.Ops).Pop()
+	start, end := e.text.visibleRunes()
+	i := sort.Search(len(misspelled), func(i int) bool {
+		return misspelled[i].End > start
+	})
+	amp := float32(max(gtx.Dp(1), 1))
+	for _, m := range misspelled[i:] {
+		if m.Start >= end {
+			break
+		}
+		e.spell.regions = e.text.Regions(m.Start, m.End, e.spell.regions)
+		for _, r := range e.spell.regions {
+			// Center the wave in the descent of the line.
+			y := float32(r.Bounds.Max.Y) - float32(r.Baseline)/2
+			paintWave(gtx, material, float32(r.Bounds.Min.X), float32(r.Bounds.Max.X), y, amp)
+		}
+	}
+}
+
+// paintWave paints a zigzag line from x0 to x1 centered at y, with the
+// given amplitude.
+func paintWave(gtx layout.Context, material op.CallOp, x0, x1, y, amp float32) {
+	var p clip.Path
+	p.Begin(gtx.Ops)
+	p.MoveTo(f32.Pt(x0, y+amp))
+	dy := -amp
+	for x := x0; x < x1; dy = -dy {
+		x = min(x+2*amp, x1)
+		p.LineTo(f32.Pt(x, y+dy))
+	}
+	defer clip.Stroke{Path: p.End(), Width: amp}.Op().Push(gtx.Ops).Pop()
+	material.Add(gtx.Ops)
+	paint.PaintOp{}.Add(gtx.Ops)
+}
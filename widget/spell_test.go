@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package widget
+
+import (
+	"image"
+	"slices"
+	"strings"
+	"testing"
+
+	"gioui.org/font"
+	"gioui.org/font/gofont"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/text"
+	"gioui.org/unit"
+)
+
+type countingChecker struct {
+	SpellChecker
+	checked []string
+}
+
+func (c *countingChecker) Check(word string) bool {
+	c.checked = append(c.checked, word)
+	return c.SpellChecker.Check(word)
+}
+
+func TestDictionary(t *testing.T) {
+	d, err := NewDictionary(strings.NewReader("3\nhello/S\nworld\nwild\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range []string{"hello", "Hello", "HELLO", "world", "42"} {
+		if !d.Check(w) {
+			t.Errorf("%q is misspelled", w)
+		}
+	}
+	for _, w := range []string{"hello/S", "wrld", "helo"} {
+		if d.Check(w) {
+			t.Errorf("%q is spelled correctly", w)
+		}
+	}
+	if got, want := d.Suggest("wrld"), []string{"wild", "world"}; !slices.Equal(got, want) {
+		t.Errorf("got suggestions %v, want %v", got, want)
+	}
+	if got, want := d.Suggest("Helol"), []string{"Hello"}; !slices.Equal(got, want) {
+		t.Errorf("got suggestions %v, want %v", got, want)
+	}
+	if got, want := d.Suggest("HELO"), []string{"HELLO"}; !slices.Equal(got, want) {
+		t.Errorf("got suggestions %v, want %v", got, want)
+	}
+	// Transpositions rank before the other edits.
+	d.Add("to", "not", "out")
+	if got, want := d.Suggest("ot"), []string{"to", "not", "out"}; !slices.Equal(got, want) {
+		t.Errorf("got suggestions %v, want %v", got, want)
+	}
+}
+
+func TestEditorSpelling(t *testing.T) {
+	d, _ := NewDictionary(strings.NewReader("hello\nworld\n"))
+	c := &countingChecker{SpellChecker: d}
+	e := new(Editor)
+	e.SetText("hello wrld, (helo)")
+	e.SetSpellChecker(c)
+	if got, want := e.Misspellings(), []Match{{6, 10}, {13, 17}}; !slices.Equal(got, want) {
+		t.Errorf("got misspellings %v, want %v", got, want)
+	}
+	c.checked = nil
+	e.SetCaret(7, 7)
+	e.Insert("o")
+	if got, want := e.Misspellings(), []Match{{14, 18}}; !slices.Equal(got, want) {
+		t.Errorf("got misspellings %v after correction, want %v", got, want)
+	}
+	// Only the changed word is checked again.
+	if want := []string{"world"}; !slices.Equal(c.checked, want) {
+		t.Errorf("checked %v, want %v", c.checked, want)
+	}
+	m, suggestions, ok := e.MisspellingAt(16)
+	if !ok || m != (Match{14, 18}) || !slices.Equal(suggestions, []string{"hello"}) {
+		t.Errorf("got misspelling %v suggestions %v ok %v at 16", m, suggestions, ok)
+	}
+	if _, _, ok := e.MisspellingAt(3); ok {
+		t.Error("got misspelling for correct word")
+	}
+	// Joining words checks the joined word.
+	e.SetCaret(6, 5)
+	e.Delete(1)
+	if got, want := e.Misspellings(), []Match{{0, 10}, {13, 17}}; !slices.Equal(got, want) {
+		t.Errorf("got misspellings %v after joining words, want %v", got, want)
+	}
+
+	gtx := layout.Context{
+		Ops:         new(op.Ops),
+		Constraints: layout.Exact(image.Pt(100, 100)),
+		Locale:      english,
+	}
+	cache := text.NewShaper(text.NoSystemFonts(), text.WithCollection(gofont.Collection()))
+	e.Layout(gtx, cache, font.Font{}, unit.Sp(10), op.CallOp{}, op.CallOp{})
+	e.PaintMisspellings(gtx, op.CallOp{})
+
+	e.SetSpellChecker(nil)
+	if got := e.Misspellings(); len(got) != 0 {
+		t.Errorf("got misspellings %v without a checker", got)
+	}
+}
@@ -0,0 +1,183 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package widget
+
+import (
+	"image"
+	"math"
+
+	"gioui.org/gesture"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/unit"
+)
+
+// Split lays out two widgets side by side, or one above the other,
+// separated by a divider the user can drag to share the space between
+// them. A Split fills the maximum constraints, and gives its widgets exact
+// constraints, so splits can be nested to build layouts with several
+// resizable panes.
+type Split struct {
+	// Axis is the axis along which the widgets are laid out.
+	Axis layout.Axis
+	// Ratio is the position of the divider, from -1 where the first widget
+	// takes no space, to 1 where the second widget takes no space. The zero
+	// Ratio shares the space evenly.
+	Ratio float32
+	// First and Second limit the sizes of the widgets along the axis. The
+	// limits of the first widget take precedence when they can't both be
+	// met.
+	First, Second SplitLimits
+	// Collapsed hides the first widget, or the second widget if
+	// CollapseSecond is set, giving all the space to the other widget
+	// regardless of the limits. Double clicking the divider toggles
+	// Collapsed, and dragging it clears Collapsed.
+	Collapsed      bool
+	CollapseSecond bool
+
+	drag  gesture.Drag
+	click gesture.Click
+	// grab is the distance from the start of the divider to the position
+	// of the drag.
+	grab int
+	// avail is the space shared by the widgets, and first the size of the
+	// first widget, during the last layout.
+	avail, first int
+}
+
+// SplitLimits are the size limits of a widget of a Split.
+type SplitLimits struct {
+	Min unit.Dp
+	// Max is the maximum size. If zero, the size is unlimited.
+	Max unit.Dp
+}
+
+// Update the split by processing events, and report whether Ratio or
+// Collapsed changed.
+func (s *Split) Update(gtx layout.Context) bool {
+	changed := false
+	for {
+		e, ok := s.click.Update(gtx.Source)
+		if !ok {
+			break
+		}
+		if e.Kind == gesture.KindClick && e.NumClicks == 2 {
+			s.Collapsed = !s.Collapsed
+			changed = true
+		}
+	}
+	for {
+		e, ok := s.drag.Update(gtx.Metric, gtx.Source, gesture.Axis(s.Axis))
+		if !ok {
+			break
+		}
+		// The divider handles events in the coordinates of the split.
+		pos := s.Axis.Convert(e.Position.Round()).X
+		switch e.Kind {
+		case pointer.Press:
+			s.grab = pos - s.first
+		case pointer.Drag:
+			if s.avail <= 0 {
+				break
+			}
+			first := s.clamp(gtx, pos-s.grab, s.avail)
+			ratio := 2*float32(first)/float32(s.avail) - 1
+			if ratio != s.Ratio || s.Collapsed {
+				s.Ratio = ratio
+				s.Collapsed = false
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// Dragging reports whether the divider is being dragged.
+func (s *Split) Dragging() bool {
+	return s.drag.Dragging()
+}
+
+// firstSize returns the size of the first widget, given the space avail
+// shared by the widgets.
+func (s *Split) firstSize(gtx layout.Context, avail int) int {
+	if s.Collapsed {
+		if s.CollapseSecond {
+			return avail
+		}
+		return 0
+	}
+	ratio := min(max(s.Ratio, -1), 1)
+	first := int(math.Round(float64((ratio + 1) / 2 * float32(avail))))
+	return s.clamp(gtx, first, avail)
+}
+
+// clamp the size of the first widget to the limits. The limits of the
+// second widget are applied first, so the limits of the first widget win
+// when they conflict.
+func (s *Split) clamp(gtx layout.Context, first, avail int) int {
+	maxSize := func(l SplitLimits) int {
+		if l.Max == 0 {
+			return avail
+		}
+		return gtx.Dp(l.Max)
+	}
+	first = max(min(first, avail-gtx.Dp(s.Second.Min)), avail-maxSize(s.Second))
+	first = max(min(first, maxSize(s.First)), gtx.Dp(s.First.Min))
+	return max(min(first, avail), 0)
+}
+
+// Layout the widgets with first and second, and the divider between them
+// with divider. The divider is given the full cross axis size, and its
+// size along the axis is the width of the area that can be dragged.
+func (s *Split) Layout(gtx layout.Context, first, second, divider layout.Widget) layout.Dimensions {
+	s.Update(gtx)
+	size := gtx.Constraints.Max
+	main, cross := s.Axis.Convert(size).X, s.Axis.Convert(size).Y
+
+	m := op.Record(gtx.Ops)
+	dgtx := gtx
+	dgtx.Constraints = layout.Constraints{
+		Min: s.Axis.Convert(image.Pt(0, cross)),
+		Max: s.Axis.Convert(image.Pt(main, cross)),
+	}
+	thickness := s.Axis.Convert(divider(dgtx).Size).X
+	dividerCall := m.Stop()
+
+	avail := max(main-thickness, 0)
+	s.avail = avail
+	s.first = s.firstSize(gtx, avail)
+	s.layoutSide(gtx, first, 0, s.first, cross)
+	s.layoutSide(gtx, second, s.first+thickness, avail-s.first, cross)
+
+	off := s.Axis.Convert(image.Pt(s.first, 0))
+	trans := op.Offset(off).Push(gtx.Ops)
+	dividerCall.Add(gtx.Ops)
+	trans.Pop()
+	// Handle the divider input without its offset, so that the drag
+	// positions don't move along with the divider.
+	area := clip.Rect{Min: off, Max: off.Add(s.Axis.Convert(image.Pt(thickness, cross)))}.Push(gtx.Ops)
+	if s.Axis == layout.Horizontal {
+		pointer.CursorColResize.Add(gtx.Ops)
+	} else {
+		pointer.CursorRowResize.Add(gtx.Ops)
+	}
+	s.drag.Add(gtx.Ops)
+	s.click.Add(gtx.Ops)
+	area.Pop()
+	return layout.Dimensions{Size: size}
+}
+
+// layoutSide lays out w at pos along the axis, with the exact size length
+// along the axis and cross across it.
+func (s *Split) layoutSide(gtx layout.Context, w layout.Widget, pos, length, cross int) {
+	if length <= 0 {
+		return
+	}
+	sz := s.Axis.Convert(image.Pt(length, cross))
+	defer op.Offset(s.Axis.Convert(image.Pt(pos, 0))).Push(gtx.Ops).Pop()
+	defer clip.Rect{Max: sz}.Push(gtx.Ops).Pop()
+	gtx.Constraints = layout.Exact(sz)
+	w(gtx)
+}
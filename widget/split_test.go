@@ -0,0 +1,118 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package widget
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	"gioui.org/f32"
+	"gioui.org/io/input"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+)
+
+func TestSplit(t *testing.T) {
+	var (
+		s, inner           Split
+		first, second, top image.Point
+	)
+	r := new(input.Router)
+	gtx := layout.Context{
+		Ops:         new(op.Ops),
+		Constraints: layout.Exact(image.Pt(200, 100)),
+		Source:      r.Source(),
+	}
+	size := func(sz *image.Point) layout.Widget {
+		return func(gtx layout.Context) layout.Dimensions {
+			*sz = gtx.Constraints.Max
+			return layout.Dimensions{Size: *sz}
+		}
+	}
+	frame := func() {
+		first, second, top = image.Point{}, image.Point{}, image.Point{}
+		gtx.Ops.Reset()
+		s.Layout(gtx, size(&first), func(gtx layout.Context) layout.Dimensions {
+			return inner.Layout(gtx, size(&top), size(&second), func(gtx layout.Context) layout.Dimensions {
+				return layout.Dimensions{Size: image.Pt(gtx.Constraints.Min.X, 10)}
+			})
+		}, func(gtx layout.Context) layout.Dimensions {
+			return layout.Dimensions{Size: image.Pt(10, gtx.Constraints.Min.Y)}
+		})
+		r.Frame(gtx.Ops)
+	}
+	drag := func(from, to f32.Point) {
+		r.Queue(
+			pointer.Event{Kind: pointer.Press, Buttons: pointer.ButtonPrimary, Position: from},
+			pointer.Event{Kind: pointer.Move, Buttons: pointer.ButtonPrimary, Position: to},
+			pointer.Event{Kind: pointer.Release, Position: to},
+		)
+		frame()
+	}
+	inner.Axis = layout.Vertical
+	frame()
+	// The divider is 10 pixels wide, leaving 190 pixels to share.
+	if first != image.Pt(95, 100) || second.X != 95 {
+		t.Fatalf("got sizes %v and %v, want an even split", first, second)
+	}
+	drag(f32.Pt(100, 50), f32.Pt(140, 50))
+	if first.X != 135 || second.X != 55 {
+		t.Errorf("got sizes %v and %v after drag", first, second)
+	}
+	if s.Ratio <= 0 {
+		t.Errorf("got ratio %v after dragging right", s.Ratio)
+	}
+
+	s.Second.Min = 60
+	s.First.Min = 20
+	drag(f32.Pt(140, 50), f32.Pt(190, 50))
+	if first.X != 130 {
+		t.Errorf("second widget shrank to %d below its minimum", second.X)
+	}
+	drag(f32.Pt(135, 50), f32.Pt(0, 50))
+	if first.X != 20 {
+		t.Errorf("first widget shrank to %d below its minimum", first.X)
+	}
+	// The limits of the first widget take precedence.
+	limits := Split{First: SplitLimits{Max: 50}, Second: SplitLimits{Max: 50}}
+	if got := limits.clamp(gtx, 75, 150); got != 50 {
+		t.Errorf("got first size %d with conflicting maximums, want 50", got)
+	}
+	limits.First, limits.Second = SplitLimits{Min: 100}, SplitLimits{Min: 100}
+	if got := limits.clamp(gtx, 75, 150); got != 100 {
+		t.Errorf("got first size %d with conflicting minimums, want 100", got)
+	}
+
+	// Double clicks toggle the collapse of the first widget.
+	doubleClick := func(pos f32.Point, at time.Duration) {
+		for _, t := range []time.Duration{at, at + 100*time.Millisecond} {
+			r.Queue(
+				pointer.Event{Kind: pointer.Press, Buttons: pointer.ButtonPrimary, Position: pos, Time: t},
+				pointer.Event{Kind: pointer.Release, Position: pos, Time: t},
+			)
+		}
+		frame()
+	}
+	doubleClick(f32.Pt(25, 50), 10*time.Second)
+	if !s.Collapsed || first.X != 0 || second.X != 190 {
+		t.Errorf("got sizes %v and %v after double click", first, second)
+	}
+	doubleClick(f32.Pt(5, 50), 20*time.Second)
+	if s.Collapsed || first.X != 20 {
+		t.Errorf("got first size %v after restoring", first)
+	}
+
+	// The nested split, in the 170x100 second widget.
+	inner.CollapseSecond = true
+	drag(f32.Pt(100, 50), f32.Pt(100, 30))
+	if top != image.Pt(170, 25) || second != image.Pt(170, 65) {
+		t.Errorf("got nested sizes %v and %v after drag", top, second)
+	}
+	inner.Collapsed = true
+	frame()
+	if top.Y != 90 || second != (image.Point{}) {
+		t.Errorf("got nested sizes %v and %v with the second collapsed", top, second)
+	}
+}
@@ -0,0 +1,637 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package widget
+
+import (
+	"image"
+	"slices"
+	"sort"
+
+	"gioui.org/gesture"
+	"gioui.org/io/event"
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/unit"
+)
+
+// Table is the state of a two-dimensional grid of cells, such as a
+// spreadsheet. Only the visible cells are laid out, so tables of many
+// rows and columns remain cheap to display.
+//
+// The leading rows and columns of the table can be fixed, such that they
+// stay in view while the rest of the cells scroll. The first fixed row is
+// the header of the table: clicking its cells sorts the table by the
+// column, and dragging the edges between them resizes the columns.
+type Table struct {
+	// Columns describes the columns of the table.
+	Columns []TableColumn
+	// RowHeight is the height of every row.
+	RowHeight unit.Dp
+	// FixedRows is the number of leading rows that don't scroll
+	// vertically.
+	FixedRows int
+	// FixedColumns is the number of leading columns that don't scroll
+	// horizontally.
+	FixedColumns int
+	// HScrollbar and VScrollbar are the states of the horizontal and
+	// vertical scroll bars, if any.
+	HScrollbar, VScrollbar Scrollbar
+
+	rows           int
+	hscroll        gesture.Scroll
+	vscroll        gesture.Scroll
+	scroll         image.Point
+	anchor, caret  TableCell
+	hasSelection   bool
+	sortColumn     int
+	sortDescending bool
+	sorted         bool
+	focused        bool
+	// reveal is set when the caret must be scrolled into view by
+	// the next layout.
+	reveal bool
+	events []TableEvent
+
+	// press tracks the pointer press in progress.
+	press struct {
+		kind tablePress
+		// column is the column of a header press or resize.
+		column int
+		// start is the x position of a resize, and width the
+		// column width at that position.
+		start, width int
+	}
+
+	// Geometry of the last layout, in pixels.
+	size      image.Point
+	rowHeight int
+	// colX are the offsets of the column edges.
+	colX []int
+}
+
+// TableColumn describes a column of a Table.
+type TableColumn struct {
+	// Width of the column. It is updated when the user resizes the
+	// column.
+	Width unit.Dp
+	// MinWidth is the smallest width the user can resize the column to.
+	MinWidth unit.Dp
+	// Resizable columns can be resized by dragging the trailing edge of
+	// their header.
+	Resizable bool
+	// Sortable columns are sorted by clicking their header.
+	Sortable bool
+}
+
+// TableCell is the position of a cell in a Table.
+type TableCell struct {
+	Row, Column int
+}
+
+// TableElement lays out a cell of a Table. The constraints are set to the
+// size of the cell.
+type TableElement func(gtx layout.Context, cell TableCell) layout.Dimensions
+
+// TableEvent describes a change to a Table made by the user.
+type TableEvent struct {
+	Kind TableEventKind
+	// Column is the column sorted or resized.
+	Column int
+}
+
+// TableEventKind is the kind of a TableEvent.
+type TableEventKind uint8
+
+const (
+	// TableSelect is reported when the selected cells change.
+	TableSelect TableEventKind = iota
+	// TableSort is reported when the user changes the sorting of the
+	// table. The table doesn't sort its rows; the program is expected
+	// to lay them out in the order given by Sort.
+	TableSort
+	// TableResize is reported when the user resizes a column.
+	TableResize
+)
+
+type tablePress uint8
+
+const (
+	tablePressNone tablePress = iota
+	tablePressHeader
+	tablePressResize
+	tablePressCell
+)
+
+const (
+	// tableResizeHandle is the distance from a column edge within which
+	// presses resize the column.
+	tableResizeHandle = 4
+	// tableMinColumnWidth is the smallest width of a column whose
+	// MinWidth is zero.
+	tableMinColumnWidth = 16
+)
+
+// Update the table state by processing events, and return the next
+// change, if any.
+func (t *Table) Update(gtx layout.Context) (TableEvent, bool) {
+	t.update(gtx)
+	if len(t.events) == 0 {
+		return TableEvent{}, false
+	}
+	e := t.events[0]
+	t.events = slices.Delete(t.events, 0, 1)
+	return e, true
+}
+
+func (t *Table) update(gtx layout.Context) {
+	if !gtx.Enabled() {
+		t.focused = false
+	}
+	fixed := t.fixedSize()
+	maxScroll := t.maxScroll()
+	dx := t.hscroll.Update(gtx.Metric, gtx.Source, gtx.Now, gesture.Horizontal,
+		pointer.ScrollRange{Min: -t.scroll.X, Max: maxScroll.X - t.scroll.X},
+		pointer.ScrollRange{},
+	)
+	dy := t.vscroll.Update(gtx.Metric, gtx.Source, gtx.Now, gesture.Vertical,
+		pointer.ScrollRange{},
+		pointer.ScrollRange{Min: -t.scroll.Y, Max: maxScroll.Y - t.scroll.Y},
+	)
+	t.scroll = t.clampScroll(t.scroll.Add(image.Pt(dx, dy)))
+	for {
+		ev, ok := gtx.Event(pointer.Filter{
+			Target: t,
+			Kinds:  pointer.Press | pointer.Drag | pointer.Release | pointer.Cancel,
+		})
+		if !ok {
+			break
+		}
+		e, ok := ev.(pointer.Event)
+		if !ok {
+			continue
+		}
+		pos := e.Position.Round()
+		switch e.Kind {
+		case pointer.Press:
+			if e.Buttons != pointer.ButtonPrimary && e.Source == pointer.Mouse {
+				break
+			}
+			gtx.Execute(key.FocusCmd{Tag: t})
+			t.pressAt(gtx, pos, e.Modifiers, fixed)
+		case pointer.Drag:
+			switch t.press.kind {
+			case tablePressResize:
+				c := t.press.column
+				if c >= len(t.Columns) {
+					break
+				}
+				col := &t.Columns[c]
+				px := max(t.press.width+pos.X-t.press.start, gtx.Dp(col.MinWidth), gtx.Dp(tableMinColumnWidth))
+				if w := gtx.Metric.PxToDp(px); w != col.Width {
+					col.Width = w
+					t.events = append(t.events, TableEvent{Kind: TableResize, Column: c})
+				}
+			case tablePressCell:
+				t.moveCaret(t.clampCell(t.cellAt(pos, fixed)), true)
+			}
+		case pointer.Release:
+			if t.press.kind == tablePressHeader {
+				if c := t.cellAt(pos, fixed); c.Row == 0 && c.Column == t.press.column {
+					t.toggleSort(c.Column)
+				}
+			}
+			t.press.kind = tablePressNone
+		case pointer.Cancel:
+			t.press.kind = tablePressNone
+		}
+	}
+	for {
+		ev, ok := gtx.Event(
+			key.FocusFilter{Target: t},
+			key.Filter{Focus: t, Name: key.NameUpArrow, Optional: key.ModShift | key.ModShortcut},
+			key.Filter{Focus: t, Name: key.NameDownArrow, Optional: key.ModShift | key.ModShortcut},
+			key.Filter{Focus: t, Name: key.NameLeftArrow, Optional: key.ModShift | key.ModShortcut},
+			key.Filter{Focus: t, Name: key.NameRightArrow, Optional: key.ModShift | key.ModShortcut},
+			key.Filter{Focus: t, Name: key.NameHome, Optional: key.ModShift | key.ModShortcut},
+			key.Filter{Focus: t, Name: key.NameEnd, Optional: key.ModShift | key.ModShortcut},
+			key.Filter{Focus: t, Name: key.NamePageUp, Optional: key.ModShift},
+			key.Filter{Focus: t, Name: key.NamePageDown, Optional: key.ModShift},
+			key.Filter{Focus: t, Name: "A", Required: key.ModShortcut},
+		)
+		if !ok {
+			break
+		}
+		switch ev := ev.(type) {
+		case key.FocusEvent:
+			t.focused = ev.Focus
+		case key.Event:
+			if ev.State == key.Press {
+				t.command(ev)
+			}
+		}
+	}
+}
+
+// pressAt handles a press at pos.
+func (t *Table) pressAt(gtx layout.Context, pos image.Point, mods key.Modifiers, fixed image.Point) {
+	if t.FixedRows > 0 && pos.Y >= 0 && pos.Y < t.rowHeight {
+		// Header press.
+		if c, ok := t.resizeColumnAt(gtx, pos, fixed); ok {
+			t.press.kind = tablePressResize
+			t.press.column = c
+			t.press.start = pos.X
+			t.press.width = t.colX[c+1] - t.colX[c]
+			return
+		}
+		if c := t.cellAt(pos, fixed); c.Column >= 0 && c.Column < t.columns() {
+			t.press.kind = tablePressHeader
+			t.press.column = c.Column
+		}
+		return
+	}
+	c := t.cellAt(pos, fixed)
+	if c.Row < t.FixedRows || c.Row >= t.rows || c.Column < t.FixedColumns || c.Column >= len(t.Columns) {
+		return
+	}
+	t.press.kind = tablePressCell
+	t.moveCaret(c, mods.Contain(key.ModShift))
+}
+
+// resizeColumnAt returns the resizable column whose trailing edge is
+// within reach of pos.
+func (t *Table) resizeColumnAt(gtx layout.Context, pos image.Point, fixed image.Point) (int, bool) {
+	handle := gtx.Dp(tableResizeHandle)
+	for c := range min(t.columns(), len(t.Columns)) {
+		if !t.Columns[c].Resizable {
+			continue
+		}
+		x, ok := t.columnEdge(c, fixed)
+		if ok && pos.X >= x-handle && pos.X < x+handle {
+			return c, true
+		}
+	}
+	return 0, false
+}
+
+// columnEdge returns the on-screen position of the trailing edge of
+// column c, and whether it is visible.
+func (t *Table) columnEdge(c int, fixed image.Point) (int, bool) {
+	x := t.colX[c+1]
+	if c < t.FixedColumns {
+		return x, true
+	}
+	x -= t.scroll.X
+	return x, x > fixed.X && x <= t.size.X
+}
+
+func (t *Table) command(e key.Event) {
+	if !t.hasSelection {
+		t.moveCaret(t.clampCell(TableCell{}), false)
+		return
+	}
+	if e.Name == "A" {
+		t.SetSelection(TableCell{Row: t.FixedRows, Column: t.FixedColumns}, TableCell{Row: t.rows - 1, Column: len(t.Columns) - 1})
+		t.events = append(t.events, TableEvent{Kind: TableSelect})
+		return
+	}
+	c := t.caret
+	page := 1
+	if t.rowHeight > 0 {
+		page = max((t.size.Y-t.fixedSize().Y)/t.rowHeight, 1)
+	}
+	jump := e.Modifiers.Contain(key.ModShortcut)
+	switch e.Name {
+	case key.NameUpArrow:
+		if jump {
+			c.Row = 0
+		} else {
+			c.Row--
+		}
+	case key.NameDownArrow:
+		if jump {
+			c.Row = t.rows
+		} else {
+			c.Row++
+		}
+	case key.NameLeftArrow:
+		if jump {
+			c.Column = 0
+		} else {
+			c.Column--
+		}
+	case key.NameRightArrow:
+		if jump {
+			c.Column = len(t.Columns)
+		} else {
+			c.Column++
+		}
+	case key.NameHome:
+		c.Column = 0
+		if jump {
+			c.Row = 0
+		}
+	case key.NameEnd:
+		c.Column = len(t.Columns)
+		if jump {
+			c.Row = t.rows
+		}
+	case key.NamePageUp:
+		c.Row -= page
+	case key.NamePageDown:
+		c.Row += page
+	}
+	t.moveCaret(t.clampCell(c), e.Modifiers.Contain(key.ModShift))
+}
+
+// moveCaret moves the caret to c, and the anchor too unless extend is
+// set.
+func (t *Table) moveCaret(c TableCell, extend bool) {
+	anchor := c
+	if extend && t.hasSelection {
+		anchor = t.anchor
+	}
+	if t.hasSelection && anchor == t.anchor && c == t.caret {
+		return
+	}
+	t.SetSelection(anchor, c)
+	t.reveal = true
+	t.events = append(t.events, TableEvent{Kind: TableSelect})
+}
+
+func (t *Table) toggleSort(c int) {
+	if c >= len(t.Columns) || !t.Columns[c].Sortable {
+		return
+	}
+	if t.sorted && t.sortColumn == c {
+		t.sortDescending = !t.sortDescending
+	} else {
+		t.SetSort(c, false)
+	}
+	t.events = append(t.events, TableEvent{Kind: TableSort, Column: c})
+}
+
+// cellAt returns the cell at the on-screen position pos. The cell may be
+// outside the table.
+func (t *Table) cellAt(pos image.Point, fixed image.Point) TableCell {
+	if pos.X >= fixed.X {
+		pos.X += t.scroll.X
+	}
+	if pos.Y >= fixed.Y {
+		pos.Y += t.scroll.Y
+	}
+	var c TableCell
+	c.Column = sort.Search(t.columns(), func(i int) bool {
+		return t.colX[i+1] > pos.X
+	})
+	if pos.X < 0 {
+		c.Column = -1
+	}
+	if t.rowHeight > 0 {
+		c.Row = pos.Y / t.rowHeight
+	}
+	if pos.Y < 0 {
+		c.Row = -1
+	}
+	return c
+}
+
+// clampCell returns the scrolling cell closest to c.
+func (t *Table) clampCell(c TableCell) TableCell {
+	c.Row = max(min(c.Row, t.rows-1), t.FixedRows)
+	c.Column = max(min(c.Column, len(t.Columns)-1), t.FixedColumns)
+	return c
+}
+
+// columns returns the number of columns in the last layout.
+func (t *Table) columns() int {
+	return max(len(t.colX)-1, 0)
+}
+
+// fixedSize returns the size of the fixed rows and columns in the last
+// layout.
+func (t *Table) fixedSize() image.Point {
+	if len(t.colX) == 0 {
+		return image.Point{}
+	}
+	return image.Pt(t.colX[min(t.FixedColumns, t.columns())], min(t.FixedRows, t.rows)*t.rowHeight)
+}
+
+// contentSize returns the size of all cells in the last layout.
+func (t *Table) contentSize() image.Point {
+	if len(t.colX) == 0 {
+		return image.Point{}
+	}
+	return image.Pt(t.colX[len(t.colX)-1], t.rows*t.rowHeight)
+}
+
+func (t *Table) maxScroll() image.Point {
+	m := t.contentSize().Sub(t.size)
+	return image.Pt(max(m.X, 0), max(m.Y, 0))
+}
+
+func (t *Table) clampScroll(p image.Point) image.Point {
+	m := t.maxScroll()
+	return image.Pt(max(min(p.X, m.X), 0), max(min(p.Y, m.Y), 0))
+}
+
+// revealCaret scrolls the caret cell into view.
+func (t *Table) revealCaret() {
+	fixed := t.fixedSize()
+	c := t.caret
+	if c.Column >= t.FixedColumns && c.Column < t.columns() {
+		left, right := t.colX[c.Column]-fixed.X, t.colX[c.Column+1]-t.size.X
+		t.scroll.X = max(min(t.scroll.X, left), right)
+	}
+	if c.Row >= t.FixedRows && c.Row < t.rows {
+		top, bottom := c.Row*t.rowHeight-fixed.Y, (c.Row+1)*t.rowHeight-t.size.Y
+		t.scroll.Y = max(min(t.scroll.Y, top), bottom)
+	}
+	t.scroll = t.clampScroll(t.scroll)
+}
+
+// SetSelection selects the cells of the rectangle spanned by anchor and
+// caret, where caret is the cell moved by the keyboard.
+func (t *Table) SetSelection(anchor, caret TableCell) {
+	t.anchor, t.caret = anchor, caret
+	t.hasSelection = true
+}
+
+// ClearSelection deselects all cells.
+func (t *Table) ClearSelection() {
+	t.hasSelection = false
+}
+
+// Selection returns the top left and bottom right corners of the selected
+// cells, and whether any cell is selected.
+func (t *Table) Selection() (start, end TableCell, ok bool) {
+	if !t.hasSelection {
+		return TableCell{}, TableCell{}, false
+	}
+	start = TableCell{Row: min(t.anchor.Row, t.caret.Row), Column: min(t.anchor.Column, t.caret.Column)}
+	end = TableCell{Row: max(t.anchor.Row, t.caret.Row), Column: max(t.anchor.Column, t.caret.Column)}
+	return start, end, true
+}
+
+// Caret returns the cell moved by the keyboard, and whether any cell is
+// selected.
+func (t *Table) Caret() (TableCell, bool) {
+	return t.caret, t.hasSelection
+}
+
+// Selected reports whether cell c is selected.
+func (t *Table) Selected(c TableCell) bool {
+	start, end, ok := t.Selection()
+	return ok && c.Row >= start.Row && c.Row <= end.Row && c.Column >= start.Column && c.Column <= end.Column
+}
+
+// Sort returns the column the table is sorted by, and whether it is
+// sorted in descending order. The ok result is false if the table isn't
+// sorted.
+func (t *Table) Sort() (column int, descending, ok bool) {
+	return t.sortColumn, t.sortDescending, t.sorted
+}
+
+// SetSort sorts the table by column, or clears the sorting if column is
+// negative.
+func (t *Table) SetSort(column int, descending bool) {
+	t.sortColumn, t.sortDescending = column, descending
+	t.sorted = column >= 0
+}
+
+// Focused reports whether the table has the keyboard focus.
+func (t *Table) Focused() bool {
+	return t.focused
+}
+
+// Position returns the scroll offset of the scrolling cells.
+func (t *Table) Position() image.Point {
+	return t.scroll
+}
+
+// ScrollTo scrolls the scrolling cells to the offset pos.
+func (t *Table) ScrollTo(pos image.Point) {
+	t.scroll = pos
+}
+
+// Viewport returns the start and end of the visible part of the scrolling
+// cells along axis, as fractions of their total size.
+func (t *Table) Viewport(axis layout.Axis) (start, end float32) {
+	fixed := axis.Convert(t.fixedSize()).X
+	total := float32(axis.Convert(t.contentSize()).X - fixed)
+	if total <= 0 {
+		return 0, 1
+	}
+	pos := float32(axis.Convert(t.scroll).X)
+	visible := float32(axis.Convert(t.size).X - fixed)
+	return pos / total, min((pos+visible)/total, 1)
+}
+
+// ScrollBy scrolls the cells along axis by a fraction of the total size
+// of the scrolling cells.
+func (t *Table) ScrollBy(axis layout.Axis, fraction float32) {
+	fixed := axis.Convert(t.fixedSize()).X
+	total := axis.Convert(t.contentSize()).X - fixed
+	d := axis.Convert(image.Pt(int(fraction*float32(total)), 0))
+	t.scroll = t.clampScroll(t.scroll.Add(d))
+}
+
+// Layout the visible cells of a table of rows rows, including the fixed
+// rows, with w.
+func (t *Table) Layout(gtx layout.Context, rows int, w TableElement) layout.Dimensions {
+	t.update(gtx)
+	t.rows = rows
+	t.size = gtx.Constraints.Max
+	t.rowHeight = gtx.Dp(t.RowHeight)
+	t.colX = append(t.colX[:0], 0)
+	x := 0
+	for _, c := range t.Columns {
+		x += gtx.Dp(c.Width)
+		t.colX = append(t.colX, x)
+	}
+	if t.reveal {
+		t.reveal = false
+		t.revealCaret()
+	}
+	t.scroll = t.clampScroll(t.scroll)
+
+	fixed := t.fixedSize()
+	size := t.size
+	defer clip.Rect{Max: size}.Push(gtx.Ops).Pop()
+	t.hscroll.Add(gtx.Ops)
+	t.vscroll.Add(gtx.Ops)
+	event.Op(gtx.Ops, t)
+	nfixedRows, nfixedCols := min(t.FixedRows, rows), min(t.FixedColumns, len(t.Columns))
+	// Lay out the scrolling cells first, so the fixed cells cover them.
+	t.layoutCells(gtx, w, image.Rectangle{Min: fixed, Max: size}, t.scroll,
+		nfixedRows, rows, nfixedCols, len(t.Columns))
+	t.layoutCells(gtx, w, image.Rectangle{Min: image.Pt(fixed.X, 0), Max: image.Pt(size.X, fixed.Y)}, image.Pt(t.scroll.X, 0),
+		0, nfixedRows, nfixedCols, len(t.Columns))
+	t.layoutCells(gtx, w, image.Rectangle{Min: image.Pt(0, fixed.Y), Max: image.Pt(fixed.X, size.Y)}, image.Pt(0, t.scroll.Y),
+		nfixedRows, rows, 0, nfixedCols)
+	t.layoutCells(gtx, w, image.Rectangle{Max: fixed},
+		image.Point{}, 0, nfixedRows, 0, nfixedCols)
+	// Add the resize handles.
+	if nfixedRows > 0 {
+		handle := gtx.Dp(tableResizeHandle)
+		for c, col := range t.Columns {
+			if !col.Resizable {
+				continue
+			}
+			if x, ok := t.columnEdge(c, fixed); ok {
+				area := clip.Rect{Min: image.Pt(x-handle, 0), Max: image.Pt(x+handle, t.rowHeight)}.Push(gtx.Ops)
+				pointer.CursorColResize.Add(gtx.Ops)
+				event.Op(gtx.Ops, t)
+				area.Pop()
+			}
+		}
+	}
+	return layout.Dimensions{Size: size}
+}
+
+// layoutCells lays out the cells of rows [r0, r1) and columns [c0, c1)
+// that are visible in the region, where the cells are displaced by
+// offset.
+func (t *Table) layoutCells(gtx layout.Context, w TableElement, region image.Rectangle, offset image.Point, r0, r1, c0, c1 int) {
+	if region.Empty() || t.rowHeight <= 0 {
+		return
+	}
+	defer clip.Rect(region).Push(gtx.Ops).Pop()
+	content := region.Add(offset)
+	r0 = max(r0, content.Min.Y/t.rowHeight)
+	r1 = min(r1, (content.Max.Y+t.rowHeight-1)/t.rowHeight)
+	c0 = max(c0, sort.Search(len(t.Columns), func(i int) bool {
+		return t.colX[i+1] > content.Min.X
+	}))
+	c1 = min(c1, sort.Search(len(t.Columns), func(i int) bool {
+		return t.colX[i] >= content.Max.X
+	}))
+	for r := r0; r < r1; r++ {
+		for c := c0; c < c1; c++ {
+			pos := image.Pt(t.colX[c], r*t.rowHeight).Sub(offset)
+			size := image.Pt(t.colX[c+1]-t.colX[c], t.rowHeight)
+			trans := op.Offset(pos).Push(gtx.Ops)
+			cl := clip.Rect{Max: size}.Push(gtx.Ops)
+			gtx := gtx
+			gtx.Constraints = layout.Exact(size)
+			w(gtx, TableCell{Row: r, Column: c})
+			cl.Pop()
+			trans.Pop()
+		}
+	}
+}
+
+func (k TableEventKind) String() string {
+	switch k {
+	case TableSelect:
+		return "TableSelect"
+	case TableSort:
+		return "TableSort"
+	case TableResize:
+		return "TableResize"
+	default:
+		panic("invalid TableEventKind")
+	}
+}
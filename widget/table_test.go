@@ -0,0 +1,142 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package widget
+
+import (
+	"image"
+	"slices"
+	"testing"
+
+	"gioui.org/f32"
+	"gioui.org/io/input"
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/unit"
+)
+
+func TestTable(t *testing.T) {
+	table := &Table{
+		RowHeight:    10,
+		FixedRows:    1,
+		FixedColumns: 1,
+	}
+	for range 100 {
+		table.Columns = append(table.Columns, TableColumn{Width: 20, Resizable: true, Sortable: true})
+	}
+	r := new(input.Router)
+	gtx := layout.Context{
+		Ops:         new(op.Ops),
+		Constraints: layout.Exact(image.Pt(100, 50)),
+		Source:      r.Source(),
+	}
+	var cells []TableCell
+	frame := func() {
+		cells = cells[:0]
+		gtx.Ops.Reset()
+		table.Layout(gtx, 10000, func(gtx layout.Context, cell TableCell) layout.Dimensions {
+			cells = append(cells, cell)
+			return layout.Dimensions{Size: gtx.Constraints.Min}
+		})
+		r.Frame(gtx.Ops)
+	}
+	var events []TableEvent
+	update := func() {
+		events = events[:0]
+		for {
+			e, ok := table.Update(gtx)
+			if !ok {
+				break
+			}
+			events = append(events, e)
+		}
+	}
+	click := func(pos f32.Point, mods key.Modifiers) {
+		r.Queue(
+			pointer.Event{Kind: pointer.Press, Buttons: pointer.ButtonPrimary, Position: pos, Modifiers: mods},
+			pointer.Event{Kind: pointer.Release, Position: pos, Modifiers: mods},
+		)
+		update()
+		frame()
+	}
+	frame()
+	// Only the 5x5 visible cells are laid out.
+	if len(cells) != 25 || slices.Contains(cells, TableCell{Row: 5, Column: 0}) || slices.Contains(cells, TableCell{Row: 0, Column: 5}) {
+		t.Errorf("laid out cells %v", cells)
+	}
+
+	click(f32.Pt(25, 15), 0)
+	if want := []TableEvent{{Kind: TableSelect}}; !slices.Equal(events, want) {
+		t.Errorf("got events %v after click, want %v", events, want)
+	}
+	click(f32.Pt(45, 35), key.ModShift)
+	start, end, ok := table.Selection()
+	if !ok || start != (TableCell{Row: 1, Column: 1}) || end != (TableCell{Row: 3, Column: 2}) {
+		t.Errorf("got selection %v-%v after shift click", start, end)
+	}
+	if !table.Selected(TableCell{Row: 2, Column: 2}) || table.Selected(TableCell{Row: 4, Column: 2}) {
+		t.Error("wrong selected cells")
+	}
+
+	keys := func(mods key.Modifiers, names ...key.Name) {
+		for _, n := range names {
+			r.Queue(key.Event{Name: n, Modifiers: mods, State: key.Press})
+		}
+		update()
+		frame()
+	}
+	keys(0, key.NameDownArrow, key.NameDownArrow, key.NameRightArrow, key.NameRightArrow, key.NameRightArrow)
+	if c, _ := table.Caret(); c != (TableCell{Row: 5, Column: 5}) {
+		t.Errorf("got caret %v after moving", c)
+	}
+	if start, end, _ := table.Selection(); start != end {
+		t.Error("moving the caret didn't collapse the selection")
+	}
+	// The caret cell is scrolled into view, below and right of the
+	// fixed cells.
+	if got, want := table.Position(), image.Pt(20, 10); got != want {
+		t.Errorf("got position %v, want %v", got, want)
+	}
+	if !slices.Contains(cells, TableCell{Row: 5, Column: 5}) || !slices.Contains(cells, TableCell{Row: 0, Column: 0}) {
+		t.Errorf("laid out cells %v", cells)
+	}
+	keys(key.ModShortcut, key.NameDownArrow)
+	if c, _ := table.Caret(); c.Row != 9999 {
+		t.Errorf("got caret %v, want last row", c)
+	}
+	keys(key.ModShortcut, key.NameHome)
+	if c, _ := table.Caret(); c != (TableCell{Row: 1, Column: 1}) {
+		t.Errorf("got caret %v, want first scrolling cell", c)
+	}
+	if got := table.Position(); got != (image.Point{}) {
+		t.Errorf("got position %v after home", got)
+	}
+
+	// Clicking a header cell sorts by its column.
+	click(f32.Pt(30, 5), 0)
+	click(f32.Pt(30, 5), 0)
+	if want := []TableEvent{{Kind: TableSort, Column: 1}}; !slices.Equal(events, want) {
+		t.Errorf("got events %v after header click, want %v", events, want)
+	}
+	if col, desc, ok := table.Sort(); !ok || col != 1 || !desc {
+		t.Errorf("got sort column %d descending %v", col, desc)
+	}
+
+	// Dragging a column edge resizes the column.
+	r.Queue(
+		pointer.Event{Kind: pointer.Press, Buttons: pointer.ButtonPrimary, Position: f32.Pt(40, 5)},
+		pointer.Event{Kind: pointer.Move, Buttons: pointer.ButtonPrimary, Position: f32.Pt(50, 5)},
+		pointer.Event{Kind: pointer.Release, Position: f32.Pt(50, 5)},
+	)
+	update()
+	if got, want := table.Columns[1].Width, unit.Dp(30); got != want {
+		t.Errorf("got column width %v after resize, want %v", got, want)
+	}
+	if want := []TableEvent{{Kind: TableResize, Column: 1}}; !slices.Equal(events, want) {
+		t.Errorf("got events %v after resize, want %v", events, want)
+	}
+	if col, desc, _ := table.Sort(); col != 1 || !desc {
+		t.Error("resizing changed the sorting")
+	}
+}
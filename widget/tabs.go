@@ -0,0 +1,349 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package widget
+
+import (
+	"slices"
+	"time"
+
+	"gioui.org/gesture"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+)
+
+// Tabs is the state of a tab bar, a row of tabs of which one is selected,
+// such as the pages of a tabbed window. Tabs are identified by keys, and
+// the selected tab is tracked by an Enum. The bar scrolls when the tabs
+// don't fit, and the selection indicator animates between tabs when the
+// selection changes.
+type Tabs struct {
+	// Keys are the keys of the tabs in display order.
+	Keys []string
+	// Enum tracks the selected tab. Its Value is the key of the
+	// selected tab.
+	Enum Enum
+	// List is the row of tabs. Its axis is always horizontal.
+	List layout.List
+	// Closable tabs have a close button. Clicking it reports a
+	// TabClose event; the tab is removed by removing its key.
+	Closable bool
+	// Reorderable tabs can be moved by dragging them.
+	Reorderable bool
+
+	tabs   map[string]*tabState
+	events []TabsEvent
+	// viewSize is the width of the bar in the last layout.
+	viewSize int
+	// reveal is set when the selected tab must be scrolled into view
+	// by the next layout.
+	reveal bool
+	// indicator is the span of the selection indicator in the last
+	// layout.
+	indicator    tabSpan
+	hasIndicator bool
+	// anim animates the indicator from the span from to the selected
+	// tab.
+	anim struct {
+		key   string
+		start time.Time
+		from  tabSpan
+	}
+}
+
+// Tab is a tab of a Tabs bar.
+type Tab struct {
+	Key string
+	// Selected reports whether the tab is the selected tab.
+	Selected bool
+	// Hovered reports whether the pointer is over the tab.
+	Hovered bool
+	// Dragging reports whether the tab is held by the pointer for
+	// moving it.
+	Dragging bool
+}
+
+// TabElement lays out a tab of a Tabs bar.
+type TabElement func(gtx layout.Context, tab Tab) layout.Dimensions
+
+// TabsEvent describes a change to a Tabs bar made by the user.
+type TabsEvent struct {
+	Kind TabsEventKind
+	Key  string
+}
+
+// TabsEventKind is the kind of a TabsEvent.
+type TabsEventKind uint8
+
+const (
+	// TabSelect is reported when a tab is selected.
+	TabSelect TabsEventKind = iota
+	// TabClose is reported when the close button of a tab is clicked.
+	TabClose
+	// TabMove is reported when a tab is moved to a new position in
+	// Keys.
+	TabMove
+)
+
+type tabState struct {
+	close Clickable
+	drag  gesture.Drag
+	// width is the width of the tab in the last layout.
+	width int
+	// used tracks whether the tab was laid out in the current
+	// frame.
+	used bool
+}
+
+// tabSpan is the horizontal extent of a tab in the bar.
+type tabSpan struct {
+	Min, Max int
+}
+
+// tabsIndicatorDuration is the duration of the indicator animation.
+const tabsIndicatorDuration = 150 * time.Millisecond
+
+// Update the tabs state by processing events, and return the next
+// change, if any.
+func (t *Tabs) Update(gtx layout.Context) (TabsEvent, bool) {
+	t.update(gtx)
+	if len(t.events) == 0 {
+		return TabsEvent{}, false
+	}
+	e := t.events[0]
+	t.events = slices.Delete(t.events, 0, 1)
+	return e, true
+}
+
+func (t *Tabs) update(gtx layout.Context) {
+	var closed []string
+	for _, k := range t.Keys {
+		if st := t.tabs[k]; st != nil && st.close.Clicked(gtx) {
+			closed = append(closed, k)
+		}
+	}
+	prev := t.Enum.Value
+	if t.Enum.Update(gtx) {
+		if slices.Contains(closed, t.Enum.Value) {
+			// The click was meant for the close button.
+			t.Enum.Value = prev
+		} else {
+			t.reveal = true
+			t.events = append(t.events, TabsEvent{Kind: TabSelect, Key: t.Enum.Value})
+		}
+	}
+	for _, k := range closed {
+		t.events = append(t.events, TabsEvent{Kind: TabClose, Key: k})
+	}
+	for _, k := range t.Keys {
+		st := t.tabs[k]
+		if st == nil {
+			continue
+		}
+		var (
+			pos   float32
+			moved bool
+		)
+		for {
+			ev, ok := st.drag.Update(gtx.Metric, gtx.Source, gesture.Horizontal)
+			if !ok {
+				break
+			}
+			if ev.Kind == pointer.Drag {
+				pos, moved = ev.Position.X, true
+			}
+		}
+		// Move the tab at most once per frame, because the pointer
+		// positions are relative to the tab position of the last
+		// frame.
+		if moved && t.Reorderable {
+			t.dragTo(k, pos)
+		}
+	}
+}
+
+// dragTo moves the tab k past a neighbour if x, relative to the tab, is
+// beyond the middle of the neighbour.
+func (t *Tabs) dragTo(k string, x float32) {
+	i := slices.Index(t.Keys, k)
+	if i == -1 {
+		return
+	}
+	j := i
+	switch {
+	case i+1 < len(t.Keys) && x > float32(t.width(k)+t.width(t.Keys[i+1])/2):
+		j = i + 1
+	case i > 0 && x < -float32(t.width(t.Keys[i-1])/2):
+		j = i - 1
+	}
+	if j == i {
+		return
+	}
+	t.Keys[i], t.Keys[j] = t.Keys[j], t.Keys[i]
+	t.events = append(t.events, TabsEvent{Kind: TabMove, Key: k})
+}
+
+func (t *Tabs) width(k string) int {
+	if st := t.tabs[k]; st != nil {
+		return st.width
+	}
+	return 0
+}
+
+// Select the tab k.
+func (t *Tabs) Select(k string) {
+	if t.Enum.Value != k {
+		t.Enum.Value = k
+		t.reveal = true
+	}
+}
+
+// Selected returns the key of the selected tab.
+func (t *Tabs) Selected() string {
+	return t.Enum.Value
+}
+
+// Indicator returns the horizontal extent of the selection indicator in the
+// last layout, and whether the selected tab is visible. The indicator
+// moves from the previously selected tab to the selected tab when the
+// selection changes.
+func (t *Tabs) Indicator() (x0, x1 int, ok bool) {
+	return t.indicator.Min, t.indicator.Max, t.hasIndicator
+}
+
+func (t *Tabs) state(k string) *tabState {
+	st, ok := t.tabs[k]
+	if !ok {
+		if t.tabs == nil {
+			t.tabs = make(map[string]*tabState)
+		}
+		st = new(tabState)
+		t.tabs[k] = st
+	}
+	return st
+}
+
+// Layout the visible tabs with w.
+func (t *Tabs) Layout(gtx layout.Context, w TabElement) layout.Dimensions {
+	t.update(gtx)
+	t.List.Axis = layout.Horizontal
+	if t.reveal {
+		t.reveal = false
+		if i := slices.Index(t.Keys, t.Enum.Value); i != -1 {
+			scrollIntoView(&t.List, i, t.viewSize)
+		}
+	}
+	for _, st := range t.tabs {
+		st.used = false
+	}
+	m := op.Record(gtx.Ops)
+	dims := t.List.Layout(gtx, len(t.Keys), func(gtx layout.Context, i int) layout.Dimensions {
+		return t.layoutTab(gtx, t.Keys[i], w)
+	})
+	call := m.Stop()
+	for k, st := range t.tabs {
+		if !st.used {
+			delete(t.tabs, k)
+		}
+	}
+	t.viewSize = dims.Size.X
+	t.layoutIndicator(gtx)
+	defer clip.Rect{Max: dims.Size}.Push(gtx.Ops).Pop()
+	call.Add(gtx.Ops)
+	return dims
+}
+
+func (t *Tabs) layoutTab(gtx layout.Context, k string, w TabElement) layout.Dimensions {
+	st := t.state(k)
+	st.used = true
+	hovered, hovering := t.Enum.Hovered()
+	m := op.Record(gtx.Ops)
+	dims := t.Enum.Layout(gtx, k, func(gtx layout.Context) layout.Dimensions {
+		return w(gtx, Tab{
+			Key:      k,
+			Selected: k == t.Enum.Value,
+			Hovered:  hovering && k == hovered,
+			Dragging: t.Reorderable && st.drag.Dragging(),
+		})
+	})
+	c := m.Stop()
+	st.width = dims.Size.X
+	defer clip.Rect{Max: dims.Size}.Push(gtx.Ops).Pop()
+	if t.Reorderable {
+		st.drag.Add(gtx.Ops)
+	}
+	c.Add(gtx.Ops)
+	return dims
+}
+
+// layoutIndicator updates the indicator span, animating it towards the
+// selected tab.
+func (t *Tabs) layoutIndicator(gtx layout.Context) {
+	target, ok := t.span(t.Enum.Value)
+	if !ok {
+		t.hasIndicator = false
+		return
+	}
+	if t.anim.key != t.Enum.Value {
+		// Start a new animation from the current indicator, if any.
+		t.anim.key = t.Enum.Value
+		t.anim.start = time.Time{}
+		if t.hasIndicator {
+			t.anim.start = gtx.Now
+			t.anim.from = t.indicator
+		}
+	}
+	t.indicator, t.hasIndicator = target, true
+	if t.anim.start.IsZero() {
+		return
+	}
+	progress := float32(gtx.Now.Sub(t.anim.start)) / float32(tabsIndicatorDuration)
+	if progress >= 1 {
+		t.anim.start = time.Time{}
+		return
+	}
+	// Ease out.
+	progress = 1 - (1-progress)*(1-progress)
+	lerp := func(a, b int) int {
+		return a + int(float32(b-a)*progress+.5)
+	}
+	t.indicator = tabSpan{
+		Min: lerp(t.anim.from.Min, target.Min),
+		Max: lerp(t.anim.from.Max, target.Max),
+	}
+	gtx.Execute(op.InvalidateCmd{})
+}
+
+// span returns the extent of the visible tab k in the bar.
+func (t *Tabs) span(k string) (tabSpan, bool) {
+	pos := t.List.Position
+	x := -pos.Offset
+	for i := pos.First; i < pos.First+pos.Count && i < len(t.Keys); i++ {
+		w := t.width(t.Keys[i])
+		if t.Keys[i] == k {
+			return tabSpan{Min: x, Max: x + w}, true
+		}
+		x += w
+	}
+	return tabSpan{}, false
+}
+
+// LayoutClose lays out w as the close button of the tab k. It is meant to
+// be called by a TabElement of Closable tabs.
+func (t *Tabs) LayoutClose(gtx layout.Context, k string, w layout.Widget) layout.Dimensions {
+	return t.state(k).close.Layout(gtx, w)
+}
+
+func (k TabsEventKind) String() string {
+	switch k {
+	case TabSelect:
+		return "TabSelect"
+	case TabClose:
+		return "TabClose"
+	case TabMove:
+		return "TabMove"
+	default:
+		panic("invalid TabsEventKind")
+	}
+}
@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package widget
+
+import (
+	"image"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"gioui.org/f32"
+	"gioui.org/io/input"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+)
+
+func TestTabs(t *testing.T) {
+	tabs := new(Tabs)
+	for i := range 10 {
+		tabs.Keys = append(tabs.Keys, strconv.Itoa(i))
+	}
+	r := new(input.Router)
+	gtx := layout.Context{
+		Ops:         new(op.Ops),
+		Constraints: layout.Exact(image.Pt(100, 20)),
+		Source:      r.Source(),
+		Now:         time.Unix(1, 0),
+	}
+	frame := func() {
+		gtx.Ops.Reset()
+		tabs.Layout(gtx, func(gtx layout.Context, tab Tab) layout.Dimensions {
+			if tabs.Closable {
+				trans := op.Offset(image.Pt(20, 0)).Push(gtx.Ops)
+				tabs.LayoutClose(gtx, tab.Key, func(gtx layout.Context) layout.Dimensions {
+					return layout.Dimensions{Size: image.Pt(10, 10)}
+				})
+				trans.Pop()
+			}
+			return layout.Dimensions{Size: image.Pt(30, 20)}
+		})
+		r.Frame(gtx.Ops)
+	}
+	var events []TabsEvent
+	update := func() {
+		events = events[:0]
+		// Focus changes may defer events to the next frame.
+		for range 2 {
+			for {
+				e, ok := tabs.Update(gtx)
+				if !ok {
+					break
+				}
+				events = append(events, e)
+			}
+			frame()
+		}
+	}
+	click := func(pos f32.Point) {
+		r.Queue(
+			pointer.Event{Kind: pointer.Press, Buttons: pointer.ButtonPrimary, Position: pos},
+			pointer.Event{Kind: pointer.Release, Position: pos},
+		)
+		update()
+	}
+	// The tabs process events from the second frame on.
+	frame()
+	frame()
+	click(f32.Pt(45, 5))
+	if want := []TabsEvent{{TabSelect, "1"}}; !slices.Equal(events, want) {
+		t.Errorf("got events %v after click, want %v", events, want)
+	}
+	if x0, x1, ok := tabs.Indicator(); !ok || x0 != 30 || x1 != 60 {
+		t.Errorf("got indicator %d-%d, want 30-60", x0, x1)
+	}
+
+	tabs.Closable = true
+	frame()
+	click(f32.Pt(25, 5))
+	if want := []TabsEvent{{TabClose, "0"}}; !slices.Equal(events, want) {
+		t.Errorf("got events %v after clicking close, want %v", events, want)
+	}
+	if got := tabs.Selected(); got != "1" {
+		t.Errorf("closing tab selected %q", got)
+	}
+
+	// Selecting a partially visible tab scrolls it into view, and the
+	// indicator moves to it.
+	click(f32.Pt(95, 5))
+	if want := []TabsEvent{{TabSelect, "3"}}; !slices.Equal(events, want) {
+		t.Errorf("got events %v after click, want %v", events, want)
+	}
+	x0, x1, _ := tabs.Indicator()
+	if x0 == 70 && x1 == 100 {
+		t.Error("indicator didn't animate")
+	}
+	gtx.Now = gtx.Now.Add(tabsIndicatorDuration)
+	frame()
+	if x0, x1, _ := tabs.Indicator(); x0 != 70 || x1 != 100 {
+		t.Errorf("got indicator %d-%d after animation, want 70-100", x0, x1)
+	}
+
+	tabs.Reorderable = true
+	frame()
+	r.Queue(
+		pointer.Event{Kind: pointer.Press, Buttons: pointer.ButtonPrimary, Position: f32.Pt(15, 5)},
+		pointer.Event{Kind: pointer.Move, Buttons: pointer.ButtonPrimary, Position: f32.Pt(65, 5)},
+		pointer.Event{Kind: pointer.Release, Position: f32.Pt(65, 5)},
+	)
+	update()
+	if want := []TabsEvent{{TabMove, "1"}}; !slices.Equal(events, want) {
+		t.Errorf("got events %v after drag, want %v", events, want)
+	}
+	if got, want := tabs.Keys[:3], []string{"0", "2", "1"}; !slices.Equal(got, want) {
+		t.Errorf("got keys %v after drag, want %v", got, want)
+	}
+	if off := tabs.List.Position.Offset; off != 20 {
+		t.Errorf("dragging a tab scrolled the bar to %d", off)
+	}
+}
@@ -21,11 +21,17 @@ import (
 	"golang.org/x/image/math/fixed"
 )
 
-// textSource provides text data for use in widgets. If the underlying data type
-// can fail due to I/O errors, it is the responsibility of that type to provide
-// its own mechanism to surface and handle those errors. They will not always
-// be returned by widgets using these functions.
-type textSource interface {
+// TextSource provides UTF-8 encoded text data for use in widgets such as
+// [Editor] and [Selectable]. If the underlying data type can fail due to I/O
+// errors, it is the responsibility of that type to provide its own mechanism to
+// surface and handle those errors. They will not always be returned by widgets
+// using these functions.
+//
+// A TextSource may change by means other than ReplaceRunes, for example when it
+// represents a document edited collaboratively. Such changes must be reported
+// by Changed, and widgets re-read the entire text when they notice them.
+// Widgets access a TextSource only from the goroutine that lays them out.
+type TextSource interface {
 	io.ReaderAt
 	// Size returns the total length of the data in bytes.
 	Size() int64
@@ -33,8 +39,9 @@ type textSource interface {
 	// to Changed.
 	Changed() bool
 	// ReplaceRunes replaces runeCount runes starting at byteOffset within the
-	// data with the provided string. Implementations of read-only text sources
-	// are free to make this a no-op.
+	// data with the provided string. byteOffset is always at the start of a
+	// rune. Implementations of read-only text sources are free to make this a
+	// no-op.
 	ReplaceRunes(byteOffset int64, runeCount int64, replacement string)
 }
 
@@ -68,11 +75,22 @@ type textView struct {
 	// Newline characters are not masked. When non-zero, the unmasked contents
 	// are accessed by Len, Text, and SetText.
 	Mask rune
+	// LetterSpacing is extra space added after every glyph cluster.
+	LetterSpacing unit.Sp
+	// WordSpacing is extra space added to every word separator.
+	WordSpacing unit.Sp
+	// Decoration configures lines drawn alongside the text, such as underlines.
+	Decoration text.Decoration
+	// Incremental lays out the text one paragraph at a time, shaping only the
+	// paragraphs that are displayed or navigated through. Paragraphs that are
+	// not shaped are assumed to occupy a single line. Incremental is ignored
+	// for SingleLine, MaxLines and masked text.
+	Incremental bool
 
 	params     text.Parameters
 	shaper     *text.Shaper
 	seekCursor int64
-	rr         textSource
+	rr         TextSource
 	maskReader maskReader
 	// graphemes tracks the indices of grapheme cluster boundaries within rr.
 	graphemes []int
@@ -83,29 +101,51 @@ type textView struct {
 	valid           bool
 	regions         []Region
 	dims            layout.Dimensions
+	// changed tracks whether the text has changed since the last call to
+	// Changed.
+	changed bool
+	// revision is incremented for every change to the text.
+	revision int
+	// external counts the changes to the source made by means other than
+	// Replace.
+	external int
 
 	// offIndex is an index of rune index to byte offsets.
 	offIndex []offEntry
 
 	index glyphIndex
+	// paragraphs indexes the text when it is laid out incrementally.
+	paragraphs paragraphIndex
+	// incrementalLayout tracks whether the current layout is incremental.
+	incrementalLayout bool
 
-	caret struct {
-		// xoff is the offset to the current position when moving between lines.
-		xoff fixed.Int26_6
-		// start is the current caret position in runes, and also the start position of
-		// selected text. end is the end position of selected text. If start
-		// == end, then there's no selection. Note that it's possible (and
-		// common) that the caret (start) is after the end, e.g. after
-		// Shift-DownArrow.
-		start int
-		end   int
-	}
+	// caret is the primary caret. Movement and editing operate on it.
+	caret textCaret
+	// carets holds the secondary carets, if any.
+	carets []textCaret
 
 	scrollOff image.Point
 }
 
+// Changed reports whether the text has changed since the last call to
+// Changed, either through Replace or by changes to the source.
 func (e *textView) Changed() bool {
-	return e.rr.Changed()
+	e.refresh()
+	changed := e.changed
+	e.changed = false
+	return changed
+}
+
+// refresh discards the layout if the source changed by means other than
+// Replace.
+func (e *textView) refresh() {
+	if e.rr.Changed() {
+		e.changed = true
+		e.revision++
+		e.external++
+		e.paragraphs.reset()
+		e.invalidate()
+	}
 }
 
 // Dimensions returns the dimensions of the visible text.
@@ -122,8 +162,12 @@ func (e *textView) FullDimensions() layout.Dimensions {
 
 // SetSource initializes the underlying data source for the Text. This
 // must be done before invoking any other methods on Text.
-func (e *textView) SetSource(source textSource) {
+func (e *textView) SetSource(source TextSource) {
 	e.rr = source
+	// Changes made before the source is used are not reported.
+	e.rr.Changed()
+	e.revision++
+	e.paragraphs.reset()
 	e.invalidate()
 	e.seekCursor = 0
 }
@@ -155,29 +199,57 @@ func (e *textView) ReadRuneBefore(off int64) (rune, int, error) {
 }
 
 func (e *textView) makeValid() {
+	e.refresh()
+	if inc := e.incremental(); inc != e.incrementalLayout {
+		e.incrementalLayout = inc
+		e.invalidate()
+	}
 	if e.valid {
 		return
 	}
-	e.layoutText(e.shaper)
+	if e.incrementalLayout {
+		e.layoutParagraphs()
+	} else {
+		e.layoutText(e.shaper)
+	}
 	e.valid = true
 }
 
 func (e *textView) closestToRune(runeIdx int) combinedPos {
 	e.makeValid()
+	if e.incrementalLayout {
+		return e.paragraphClosestToRune(runeIdx)
+	}
 	pos, _ := e.index.closestToRune(runeIdx)
 	return pos
 }
 
 func (e *textView) closestToLineCol(line, col int) combinedPos {
 	e.makeValid()
+	if e.incrementalLayout {
+		return e.paragraphClosestToLineCol(line, col)
+	}
 	return e.index.closestToLineCol(screenPos{line: line, col: col})
 }
 
 func (e *textView) closestToXY(x fixed.Int26_6, y int) combinedPos {
 	e.makeValid()
+	if e.incrementalLayout {
+		return e.paragraphClosestToXY(x, y)
+	}
 	return e.index.closestToXY(x, y)
 }
 
+// locate returns the regions covering the runes in [start, end) that are
+// visible within viewport.
+func (e *textView) locate(viewport image.Rectangle, start, end int, regions []Region) []Region {
+	e.makeValid()
+	if e.incrementalLayout {
+		return e.paragraphLocate(viewport, start, end, regions)
+	}
+	return e.index.locate(viewport, start, end, regions)
+}
+
 func (e *textView) closestToXYGraphemes(x fixed.Int26_6, y int) combinedPos {
 	// Find the closest existing rune position to the provided coordinates.
 	pos := e.closestToXY(x, y)
@@ -292,12 +364,24 @@ func (e *textView) Layout(gtx layout.Context, lt *text.Shaper, font font.Font, s
 		e.params.DisableSpaceTrim = e.DisableSpaceTrim
 		e.invalidate()
 	}
+	if ls := fixed.I(gtx.Sp(e.LetterSpacing)); ls != e.params.LetterSpacing {
+		e.params.LetterSpacing = ls
+		e.invalidate()
+	}
+	if ws := fixed.I(gtx.Sp(e.WordSpacing)); ws != e.params.WordSpacing {
+		e.params.WordSpacing = ws
+		e.invalidate()
+	}
 
 	e.makeValid()
 
 	if viewSize := e.calculateViewSize(gtx); viewSize != e.viewSize {
 		e.viewSize = viewSize
-		e.invalidate()
+		// Incremental layouts don't depend on the view size, and are
+		// expensive to redo.
+		if !e.incrementalLayout {
+			e.invalidate()
+		}
 	}
 	e.makeValid()
 }
@@ -306,9 +390,21 @@ func (e *textView) Layout(gtx layout.Context, lt *text.Shaper, font font.Font, s
 // the provided material to fill the rectangles.
 func (e *textView) PaintSelection(gtx layout.Context, material op.CallOp) {
 	localViewport := image.Rectangle{Max: e.viewSize}
-	docViewport := image.Rectangle{Max: e.viewSize}.Add(e.scrollOff)
 	defer clip.Rect(localViewport).Push(gtx.Ops).Pop()
-	e.regions = e.index.locate(docViewport, e.caret.start, e.caret.end, e.regions)
+	for i := -1; i < len(e.carets); i++ {
+		c := e.caret
+		if i >= 0 {
+			c = e.carets[i]
+		}
+		e.paintRegions(gtx, material, c.start, c.end)
+	}
+}
+
+// paintRegions paints the visible regions covering the runes in
+// [start, end) using the provided material.
+func (e *textView) paintRegions(gtx layout.Context, material op.CallOp, start, end int) {
+	docViewport := image.Rectangle{Max: e.viewSize}.Add(e.scrollOff)
+	e.regions = e.locate(docViewport, start, end, e.regions)
 	for _, region := range e.regions {
 		area := clip.Rect(region.Bounds).Push(gtx.Ops)
 		material.Add(gtx.Ops)
@@ -317,6 +413,14 @@ func (e *textView) PaintSelection(gtx layout.Context, material op.CallOp) {
 	}
 }
 
+// visibleRunes returns a rune range covering the lines of text that are
+// at least partially visible.
+func (e *textView) visibleRunes() (start, end int) {
+	top := e.closestToXY(0, e.scrollOff.Y).lineCol.line
+	bottom := e.closestToXY(0, e.scrollOff.Y+e.viewSize.Y).lineCol.line
+	return e.closestToLineCol(top, 0).runes, e.closestToLineCol(bottom+1, 0).runes
+}
+
 // PaintText clips and paints the visible text glyph outlines using the provided
 // material to fill the glyphs.
 func (e *textView) PaintText(gtx layout.Context, material op.CallOp) {
@@ -325,32 +429,44 @@ func (e *textView) PaintText(gtx layout.Context, material op.CallOp) {
 		Min: e.scrollOff,
 		Max: e.viewSize.Add(e.scrollOff),
 	}
-	it := textIterator{
-		viewport: viewport,
-		material: material,
+	var padding image.Rectangle
+	if e.incrementalLayout {
+		padding = e.paintParagraphs(gtx, viewport, material)
+	} else {
+		it := textIterator{
+			viewport:   viewport,
+			material:   material,
+			decoration: e.Decoration,
+		}
+		e.paintIndex(gtx, &it, &e.index)
+		padding = it.padding
 	}
 
+	call := m.Stop()
+	viewport.Min = viewport.Min.Add(padding.Min)
+	viewport.Max = viewport.Max.Add(padding.Max)
+	defer clip.Rect(viewport.Sub(e.scrollOff)).Push(gtx.Ops).Pop()
+	call.Add(gtx.Ops)
+}
+
+// paintIndex paints the glyphs of index that are visible within the viewport
+// of it.
+func (e *textView) paintIndex(gtx layout.Context, it *textIterator, index *glyphIndex) {
 	startGlyph := 0
-	for _, line := range e.index.lines {
-		if line.descent.Ceil()+line.yOff >= viewport.Min.Y {
+	for _, line := range index.lines {
+		if line.descent.Ceil()+line.yOff >= it.viewport.Min.Y {
 			break
 		}
 		startGlyph += line.glyphs
 	}
 	var glyphs [32]text.Glyph
 	line := glyphs[:0]
-	for _, g := range e.index.glyphs[startGlyph:] {
+	for _, g := range index.glyphs[startGlyph:] {
 		var ok bool
 		if line, ok = it.paintGlyph(gtx, e.shaper, g, line); !ok {
 			break
 		}
 	}
-
-	call := m.Stop()
-	viewport.Min = viewport.Min.Add(it.padding.Min)
-	viewport.Max = viewport.Max.Add(it.padding.Max)
-	defer clip.Rect(viewport.Sub(e.scrollOff)).Push(gtx.Ops).Pop()
-	call.Add(gtx.Ops)
 }
 
 // caretWidth returns the width occupied by the caret for the current
@@ -366,8 +482,16 @@ func (e *textView) caretWidth(gtx layout.Context) int {
 // PaintCaret clips and paints the caret rectangle, adding material immediately
 // before painting to set the appropriate paint material.
 func (e *textView) PaintCaret(gtx layout.Context, material op.CallOp) {
+	for i := range e.carets {
+		e.paintCaret(gtx, material, e.carets[i].start)
+	}
+	e.paintCaret(gtx, material, e.caret.start)
+}
+
+// paintCaret paints a caret at the rune offset pos.
+func (e *textView) paintCaret(gtx layout.Context, material op.CallOp, pos int) {
 	carWidth2 := e.caretWidth(gtx)
-	caretPos, carAsc, carDesc := e.CaretInfo()
+	caretPos, carAsc, carDesc := e.caretInfo(pos)
 
 	carRect := image.Rectangle{
 		Min: caretPos.Sub(image.Pt(carWidth2, carAsc)),
@@ -383,7 +507,12 @@ func (e *textView) PaintCaret(gtx layout.Context, material op.CallOp) {
 }
 
 func (e *textView) CaretInfo() (pos image.Point, ascent, descent int) {
-	caretStart := e.closestToRune(e.caret.start)
+	return e.caretInfo(e.caret.start)
+}
+
+// caretInfo is like CaretInfo for a caret at the rune offset runes.
+func (e *textView) caretInfo(runes int) (pos image.Point, ascent, descent int) {
+	caretStart := e.closestToRune(runes)
 
 	ascent = caretStart.ascent.Ceil()
 	descent = caretStart.descent.Ceil()
@@ -405,6 +534,9 @@ func (e *textView) ByteOffset(runeOffset int) int64 {
 // Len is the length of the editor contents, in runes.
 func (e *textView) Len() int {
 	e.makeValid()
+	if e.incrementalLayout {
+		return e.paragraphs.total().runes
+	}
 	return e.closestToRune(math.MaxInt).runes
 }
 
@@ -470,16 +602,22 @@ func (e *textView) scrollAbs(x, y int) {
 // MoveCoord moves the caret to the position closest to the provided
 // point that is aligned to a grapheme cluster boundary.
 func (e *textView) MoveCoord(pos image.Point) {
+	e.caret.start = e.coordRune(pos)
+	e.caret.xoff = 0
+}
+
+// coordRune returns the rune offset of the position closest to the
+// provided point that is aligned to a grapheme cluster boundary.
+func (e *textView) coordRune(pos image.Point) int {
 	x := fixed.I(pos.X + e.scrollOff.X)
 	y := pos.Y + e.scrollOff.Y
-	e.caret.start = e.closestToXYGraphemes(x, y).runes
-	e.caret.xoff = 0
+	return e.closestToXYGraphemes(x, y).runes
 }
 
 // Truncated returns whether the text in the textView is currently
 // truncated due to a restriction on the number of lines.
 func (e *textView) Truncated() bool {
-	return e.index.truncated
+	return !e.incrementalLayout && e.index.truncated
 }
 
 func (e *textView) layoutText(lt *text.Shaper) {
@@ -555,6 +693,9 @@ func (e *textView) indexRune(r int) offEntry {
 // runeOffset returns the byte offset into e.rr of the r'th rune.
 // r must be a valid rune index, usually returned by closestPosition.
 func (e *textView) runeOffset(r int) int {
+	if e.incrementalLayout {
+		return e.paragraphRuneOffset(r)
+	}
 	const runesPerIndexEntry = 50
 	entry := e.indexRune(r)
 	lastEntry := e.offIndex[len(e.offIndex)-1].runes
@@ -571,6 +712,7 @@ func (e *textView) runeOffset(r int) int {
 
 func (e *textView) invalidate() {
 	e.offIndex = e.offIndex[:0]
+	e.paragraphs.unshape()
 	e.valid = false
 }
 
@@ -587,7 +729,12 @@ func (e *textView) Replace(start, end int, s string) int {
 	sc := utf8.RuneCountInString(s)
 	newEnd := startPos.runes + sc
 
+	size := e.rr.Size()
 	e.rr.ReplaceRunes(int64(startOff), int64(replaceSize), s)
+	if e.rr.Changed() {
+		e.changed = true
+		e.revision++
+	}
 	adjust := func(pos int) int {
 		switch {
 		case newEnd < pos && pos <= endPos.runes:
@@ -600,7 +747,17 @@ func (e *textView) Replace(start, end int, s string) int {
 	}
 	e.caret.start = adjust(e.caret.start)
 	e.caret.end = adjust(e.caret.end)
-	e.invalidate()
+	for i := range e.carets {
+		c := &e.carets[i]
+		c.start = adjust(c.start)
+		c.end = adjust(c.end)
+	}
+	if e.incrementalLayout {
+		e.replaceParagraphs(startPos.runes, endPos.runes, e.rr.Size()-size)
+	} else {
+		e.paragraphs.reset()
+		e.invalidate()
+	}
 	return sc
 }
 
@@ -619,6 +776,10 @@ func (e *textView) MovePages(pages int, selAct selectionAction) {
 // moveByGraphemes returns the rune index resulting from moving the
 // specified number of grapheme clusters from startRuneidx.
 func (e *textView) moveByGraphemes(startRuneidx, graphemes int) int {
+	e.makeValid()
+	if e.incrementalLayout {
+		return e.paragraphMoveByGraphemes(startRuneidx, graphemes)
+	}
 	if len(e.graphemes) == 0 {
 		return startRuneidx
 	}
@@ -783,8 +944,13 @@ func (e *textView) SetCaret(start, end int) {
 // Callers can guarantee that the buf is large enough by providing a buffer
 // with capacity e.SelectionLen()*utf8.UTFMax.
 func (e *textView) SelectedText(buf []byte) []byte {
-	startOff := e.runeOffset(e.caret.start)
-	endOff := e.runeOffset(e.caret.end)
+	return e.selectedText(e.caret, buf)
+}
+
+// selectedText is like SelectedText for the selection of c.
+func (e *textView) selectedText(c textCaret, buf []byte) []byte {
+	startOff := e.runeOffset(c.start)
+	endOff := e.runeOffset(c.end)
 	start := min(startOff, endOff)
 	end := max(startOff, endOff)
 	if cap(buf) < end-start {
@@ -793,7 +959,7 @@ func (e *textView) SelectedText(buf []byte) []byte {
 	buf = buf[:end-start]
 	n, _ := e.rr.ReadAt(buf, int64(start))
 	// There is no way to reasonably handle a read error here. We rely upon
-	// implementations of textSource to provide other ways to signal errors
+	// implementations of TextSource to provide other ways to signal errors
 	// if the user cares about that, and here we use whatever data we were
 	// able to read.
 	return buf[:n]
@@ -848,5 +1014,5 @@ func (e *textView) Regions(start, end int, regions []Region) []Region {
 		Min: e.scrollOff,
 		Max: e.viewSize.Add(e.scrollOff),
 	}
-	return e.index.locate(viewport, start, end, regions)
+	return e.locate(viewport, start, end, regions)
 }
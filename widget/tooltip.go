@@ -0,0 +1,180 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package widget
+
+import (
+	"image"
+	"time"
+
+	"gioui.org/f32"
+	"gioui.org/io/event"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+)
+
+// Tooltip displays a tip about a widget in a popover layer of an Overlay,
+// after the pointer has hovered over the widget for a while, or after a
+// long press on touch screens. The tip is displayed below the widget, or
+// above it if there is no room below, and inside the overlay.
+type Tooltip struct {
+	// HoverDelay is the time the pointer must hover over the widget
+	// before the tip is displayed. If zero, the delay is 500ms.
+	HoverDelay time.Duration
+	// LongPressDelay is the duration of a touch press that displays the
+	// tip. If zero, the delay is 500ms.
+	LongPressDelay time.Duration
+
+	layer   Layer
+	visible bool
+	// hovering tracks whether a mouse pointer is over the widget since
+	// hoverStart.
+	hovering   bool
+	hoverStart time.Time
+	// pressing tracks whether a touch press on the widget is in progress
+	// since pressStart.
+	pressing   bool
+	pressStart time.Time
+	// suppressed is set when a mouse press hides the tip until the
+	// pointer leaves the widget.
+	suppressed bool
+	// hideAt is the time the tip of a long press is hidden.
+	hideAt time.Time
+	// origin is the position of the widget relative to the overlay,
+	// derived from the position at of the pointer over the overlay.
+	origin image.Point
+	at     f32.Point
+}
+
+const (
+	defaultTooltipDelay = 500 * time.Millisecond
+	// tooltipLongPressDuration is how long the tip of a long press
+	// stays after the release.
+	tooltipLongPressDuration = 1500 * time.Millisecond
+)
+
+func (t *Tooltip) update(gtx layout.Context, o *Overlay) {
+	if !gtx.Enabled() {
+		t.hovering, t.pressing = false, false
+		t.hideAt = time.Time{}
+	}
+	for {
+		ev, ok := gtx.Event(pointer.Filter{
+			Target: t,
+			Kinds:  pointer.Enter | pointer.Leave | pointer.Move | pointer.Drag | pointer.Press | pointer.Release | pointer.Cancel,
+		})
+		if !ok {
+			break
+		}
+		e, ok := ev.(pointer.Event)
+		if !ok {
+			continue
+		}
+		switch e.Kind {
+		case pointer.Enter, pointer.Move, pointer.Drag, pointer.Press:
+			// The overlay tracks the same pointer in its own
+			// coordinates.
+			t.at = o.pointer
+			t.origin = o.pointer.Sub(e.Position).Round()
+		}
+		switch e.Kind {
+		case pointer.Enter, pointer.Move:
+			if e.Source == pointer.Mouse && !t.hovering {
+				t.hovering = true
+				t.hoverStart = gtx.Now
+			}
+		case pointer.Press:
+			if e.Source == pointer.Touch {
+				t.pressing = true
+				t.pressStart = gtx.Now
+				t.hideAt = time.Time{}
+			} else {
+				t.suppressed = true
+			}
+		case pointer.Release:
+			if t.pressing && t.longPressed(gtx.Now) {
+				t.hideAt = gtx.Now.Add(tooltipLongPressDuration)
+			}
+			t.pressing = false
+		case pointer.Leave, pointer.Cancel:
+			t.hovering, t.pressing, t.suppressed = false, false, false
+		}
+	}
+	var wakeup time.Time
+	visible := false
+	switch {
+	case t.pressing:
+		at := t.pressStart.Add(tooltipDelay(t.LongPressDelay))
+		visible = !gtx.Now.Before(at)
+		if !visible {
+			wakeup = at
+		}
+	case gtx.Now.Before(t.hideAt):
+		visible = true
+		wakeup = t.hideAt
+	case t.hovering && !t.suppressed:
+		at := t.hoverStart.Add(tooltipDelay(t.HoverDelay))
+		visible = !gtx.Now.Before(at)
+		if !visible {
+			wakeup = at
+		}
+	}
+	if !wakeup.IsZero() {
+		gtx.Execute(op.InvalidateCmd{At: wakeup})
+	}
+	// The origin is stale if the overlay has seen the pointer move since
+	// it was derived, such as when the overlay is updated after the
+	// tooltip. Hide the tip until the next event confirms the origin.
+	visible = visible && o.pointer == t.at
+	t.visible = visible
+	switch active := o.Active(&t.layer); {
+	case visible && !active:
+		t.layer.Kind = PopoverLayer
+		t.layer.Persistent = true
+		o.Push(&t.layer)
+	case !visible && active:
+		o.Close(&t.layer)
+	}
+}
+
+func (t *Tooltip) longPressed(now time.Time) bool {
+	return !now.Before(t.pressStart.Add(tooltipDelay(t.LongPressDelay)))
+}
+
+// tooltipDelay returns d, or the default delay if d is zero.
+func tooltipDelay(d time.Duration) time.Duration {
+	if d == 0 {
+		return defaultTooltipDelay
+	}
+	return d
+}
+
+// Visible reports whether the tip is displayed.
+func (t *Tooltip) Visible() bool {
+	return t.visible
+}
+
+// Layout w, and display the tip laid out by tip in a layer of o while the
+// tooltip is visible. Descriptions for screen readers, such as the text of
+// the tip, are meant to be added by w.
+//
+// The tip is placed by comparing the pointer positions seen by w and by
+// o, so Layout must be called while o lays out its content, after
+// [Overlay.Layout] has processed the pointer events of the frame. The tip
+// is hidden while its position is unconfirmed, that is when o has seen
+// the pointer move without a matching event for w. Because positions are
+// known from pointer events only, a widget moving under a still pointer
+// keeps its tip in place until the pointer moves.
+func (t *Tooltip) Layout(gtx layout.Context, o *Overlay, w, tip layout.Widget) layout.Dimensions {
+	t.layer.Widget = tip
+	t.update(gtx, o)
+	m := op.Record(gtx.Ops)
+	dims := w(gtx)
+	call := m.Stop()
+	defer clip.Rect{Max: dims.Size}.Push(gtx.Ops).Pop()
+	event.Op(gtx.Ops, t)
+	call.Add(gtx.Ops)
+	t.layer.Anchor = image.Rectangle{Min: t.origin, Max: t.origin.Add(dims.Size)}
+	return dims
+}
@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package widget
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	"gioui.org/f32"
+	"gioui.org/io/input"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+)
+
+func TestTooltip(t *testing.T) {
+	var (
+		o  Overlay
+		tt Tooltip
+	)
+	r := new(input.Router)
+	gtx := layout.Context{
+		Ops:         new(op.Ops),
+		Constraints: layout.Exact(image.Pt(200, 200)),
+		Source:      r.Source(),
+		Now:         time.Unix(1, 0),
+	}
+	box := func(size image.Point) layout.Widget {
+		return func(gtx layout.Context) layout.Dimensions {
+			return layout.Dimensions{Size: size}
+		}
+	}
+	frame := func() {
+		gtx.Ops.Reset()
+		o.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			// The target is near the bottom of the overlay.
+			defer op.Offset(image.Pt(50, 170)).Push(gtx.Ops).Pop()
+			return tt.Layout(gtx, &o, box(image.Pt(40, 20)), box(image.Pt(60, 30)))
+		}, nil)
+		r.Frame(gtx.Ops)
+	}
+	wait := func(d time.Duration) {
+		gtx.Now = gtx.Now.Add(d)
+		frame()
+	}
+	frame()
+	frame()
+	r.Queue(pointer.Event{Kind: pointer.Move, Source: pointer.Mouse, Position: f32.Pt(60, 175)})
+	frame()
+	if tt.Visible() {
+		t.Fatal("tooltip displayed before the hover delay")
+	}
+	wait(500 * time.Millisecond)
+	if !tt.Visible() || !o.Active(&tt.layer) {
+		t.Fatal("tooltip not displayed after the hover delay")
+	}
+	if got, want := tt.layer.Anchor, image.Rect(50, 170, 90, 190); got != want {
+		t.Errorf("tooltip anchor is %v, want %v", got, want)
+	}
+	// A pointer move seen by the overlay but not by the tooltip leaves
+	// the anchor unconfirmed.
+	o.pointer = f32.Pt(70, 175)
+	frame()
+	if tt.Visible() {
+		t.Error("tooltip displayed with a stale anchor")
+	}
+	r.Queue(pointer.Event{Kind: pointer.Move, Source: pointer.Mouse, Position: f32.Pt(70, 175)})
+	frame()
+	if !tt.Visible() || tt.layer.Anchor != image.Rect(50, 170, 90, 190) {
+		t.Errorf("tooltip not displayed at %v after confirming its anchor", tt.layer.Anchor)
+	}
+	// A press hides the tip until the pointer leaves the widget.
+	r.Queue(pointer.Event{Kind: pointer.Press, Source: pointer.Mouse, Buttons: pointer.ButtonPrimary, Position: f32.Pt(60, 175)})
+	frame()
+	wait(time.Second)
+	if tt.Visible() || o.Active(&tt.layer) {
+		t.Error("tooltip displayed after a press")
+	}
+	r.Queue(
+		pointer.Event{Kind: pointer.Release, Source: pointer.Mouse, Position: f32.Pt(60, 175)},
+		pointer.Event{Kind: pointer.Move, Source: pointer.Mouse, Position: f32.Pt(5, 5)},
+		pointer.Event{Kind: pointer.Move, Source: pointer.Mouse, Position: f32.Pt(60, 175)},
+	)
+	frame()
+	wait(500 * time.Millisecond)
+	if !tt.Visible() {
+		t.Error("tooltip not displayed after hovering again")
+	}
+	r.Queue(pointer.Event{Kind: pointer.Move, Source: pointer.Mouse, Position: f32.Pt(5, 5)})
+	frame()
+	if tt.Visible() {
+		t.Fatal("tooltip displayed after the pointer left")
+	}
+
+	// Long press on touch screens.
+	r.Queue(pointer.Event{Kind: pointer.Press, Source: pointer.Touch, Position: f32.Pt(60, 175)})
+	frame()
+	wait(500 * time.Millisecond)
+	if !tt.Visible() {
+		t.Fatal("tooltip not displayed after a long press")
+	}
+	r.Queue(pointer.Event{Kind: pointer.Release, Source: pointer.Touch, Position: f32.Pt(60, 175)})
+	frame()
+	if !tt.Visible() {
+		t.Error("tooltip hidden right after the long press")
+	}
+	wait(tooltipLongPressDuration)
+	if tt.Visible() {
+		t.Error("tooltip displayed after the long press duration")
+	}
+}
+
+func TestTooltipPlacement(t *testing.T) {
+	overlay := image.Pt(200, 200)
+	size := image.Pt(60, 30)
+	tests := []struct {
+		anchor image.Rectangle
+		want   image.Point
+	}{
+		// Below the anchor.
+		{image.Rect(50, 20, 90, 40), image.Pt(40, 40)},
+		// Above the anchor, for lack of room below.
+		{image.Rect(50, 170, 90, 190), image.Pt(40, 140)},
+		// Inside the overlay.
+		{image.Rect(180, 20, 200, 40), image.Pt(140, 40)},
+		{image.Rect(0, 20, 10, 40), image.Pt(0, 40)},
+	}
+	for _, test := range tests {
+		l := &Layer{Anchor: test.anchor}
+		if got := l.place(overlay, size); got != test.want {
+			t.Errorf("tip of anchor %v placed at %v, want %v", test.anchor, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,458 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package widget
+
+import (
+	"slices"
+
+	"gioui.org/gesture"
+	"gioui.org/io/event"
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/io/semantic"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+)
+
+// Tree is the state of a hierarchical list of nodes, such as the files
+// of a file browser. The children of a node are loaded when the node is
+// first expanded, and only the rows of visible nodes are laid out, so
+// trees of many nodes remain cheap to display.
+//
+// Nodes are identified by comparable values, such as paths or pointers.
+// The nil node denotes the invisible root of the tree.
+type Tree struct {
+	// Children returns the children of node, or the top level nodes
+	// if node is nil. It is called when a node is first expanded, and
+	// its result is kept until Reload.
+	Children func(node any) []any
+	// HasChildren reports whether node can be expanded. If HasChildren
+	// is nil, nodes are expandable until expanding them finds no
+	// children.
+	HasChildren func(node any) bool
+	// List is the list of visible rows. Its axis is always vertical.
+	List List
+
+	children map[any][]any
+	expanded map[any]bool
+	rows     []TreeRow
+	// rowsValid tracks whether rows matches the expanded nodes.
+	rowsValid bool
+	selected  any
+	focused   bool
+	items     map[any]*treeItem
+	events    []TreeEvent
+	// viewSize is the height of the tree in the last layout.
+	viewSize int
+	// reveal is set when the selection must be scrolled into
+	// view by the next layout.
+	reveal bool
+}
+
+// TreeRow is a visible node of a Tree.
+type TreeRow struct {
+	Node any
+	// Depth is the nesting level of Node, starting at 0 for the
+	// top level nodes.
+	Depth int
+	// Expanded reports whether the children of Node are displayed.
+	Expanded bool
+	// Expandable reports whether Node may have children.
+	Expandable bool
+}
+
+// TreeElement lays out the row of a Tree node.
+type TreeElement func(gtx layout.Context, row TreeRow) layout.Dimensions
+
+// TreeEvent describes a change to a Tree made by the user.
+type TreeEvent struct {
+	Kind TreeEventKind
+	Node any
+}
+
+// TreeEventKind is the kind of a TreeEvent.
+type TreeEventKind uint8
+
+const (
+	// TreeSelect is reported when a node is selected.
+	TreeSelect TreeEventKind = iota
+	// TreeExpand is reported when a node is expanded.
+	TreeExpand
+	// TreeCollapse is reported when a node is collapsed.
+	TreeCollapse
+	// TreeActivate is reported when a node is double clicked, or
+	// the return key is pressed while the node is selected.
+	TreeActivate
+)
+
+type treeItem struct {
+	click    gesture.Click
+	expander Clickable
+	// used tracks whether the item was laid out in the
+	// current frame.
+	used bool
+}
+
+// Update the tree state by processing events, and return the next
+// change, if any.
+func (t *Tree) Update(gtx layout.Context) (TreeEvent, bool) {
+	t.update(gtx)
+	if len(t.events) == 0 {
+		return TreeEvent{}, false
+	}
+	e := t.events[0]
+	t.events = slices.Delete(t.events, 0, 1)
+	return e, true
+}
+
+func (t *Tree) update(gtx layout.Context) {
+	if !gtx.Enabled() {
+		t.focused = false
+	}
+	for n, it := range t.items {
+		if it.expander.Clicked(gtx) {
+			gtx.Execute(key.FocusCmd{Tag: t})
+			t.toggle(n)
+		}
+		for {
+			ev, ok := it.click.Update(gtx.Source)
+			if !ok {
+				break
+			}
+			switch ev.Kind {
+			case gesture.KindPress:
+				if ev.Source == pointer.Mouse {
+					gtx.Execute(key.FocusCmd{Tag: t})
+				}
+			case gesture.KindClick:
+				t.selectNode(n)
+				if ev.NumClicks == 2 && !it.expander.Hovered() {
+					t.toggle(n)
+					t.events = append(t.events, TreeEvent{Kind: TreeActivate, Node: n})
+				}
+			}
+		}
+	}
+	for {
+		ev, ok := gtx.Event(
+			key.FocusFilter{Target: t},
+			key.Filter{Focus: t, Name: key.NameUpArrow},
+			key.Filter{Focus: t, Name: key.NameDownArrow},
+			key.Filter{Focus: t, Name: key.NameLeftArrow},
+			key.Filter{Focus: t, Name: key.NameRightArrow},
+			key.Filter{Focus: t, Name: key.NameHome},
+			key.Filter{Focus: t, Name: key.NameEnd},
+			key.Filter{Focus: t, Name: key.NameReturn},
+		)
+		if !ok {
+			break
+		}
+		switch ev := ev.(type) {
+		case key.FocusEvent:
+			t.focused = ev.Focus
+		case key.Event:
+			if ev.State == key.Press {
+				t.command(ev.Name)
+			}
+		}
+	}
+}
+
+// command handles the navigation key name.
+func (t *Tree) command(name key.Name) {
+	rows := t.Rows()
+	if len(rows) == 0 {
+		return
+	}
+	i := t.index(t.selected)
+	if i == -1 {
+		// Any key selects the first row.
+		t.selectRow(0)
+		return
+	}
+	row := rows[i]
+	switch name {
+	case key.NameUpArrow:
+		t.selectRow(max(i-1, 0))
+	case key.NameDownArrow:
+		t.selectRow(min(i+1, len(rows)-1))
+	case key.NameHome:
+		t.selectRow(0)
+	case key.NameEnd:
+		t.selectRow(len(rows) - 1)
+	case key.NameRightArrow:
+		switch {
+		case !row.Expanded && row.Expandable:
+			t.toggle(row.Node)
+		case row.Expanded && i+1 < len(rows):
+			t.selectRow(i + 1)
+		}
+	case key.NameLeftArrow:
+		if row.Expanded {
+			t.toggle(row.Node)
+			break
+		}
+		for j := i - 1; j >= 0; j-- {
+			if rows[j].Depth < row.Depth {
+				t.selectRow(j)
+				break
+			}
+		}
+	case key.NameReturn:
+		t.events = append(t.events, TreeEvent{Kind: TreeActivate, Node: row.Node})
+	}
+}
+
+// toggle expands or collapses node on behalf of the user.
+func (t *Tree) toggle(node any) {
+	if t.expanded[node] {
+		// Move the selection out of the collapsed nodes.
+		if i, j := t.index(node), t.index(t.selected); i != -1 && j > i && j < t.subtreeEnd(i) {
+			t.selectNode(node)
+		}
+		t.Collapse(node)
+		t.events = append(t.events, TreeEvent{Kind: TreeCollapse, Node: node})
+		return
+	}
+	if t.Expand(node) {
+		t.events = append(t.events, TreeEvent{Kind: TreeExpand, Node: node})
+	}
+}
+
+func (t *Tree) selectRow(i int) {
+	t.selectNode(t.rows[i].Node)
+	t.reveal = true
+}
+
+func (t *Tree) selectNode(node any) {
+	if t.selected == node {
+		return
+	}
+	t.selected = node
+	t.events = append(t.events, TreeEvent{Kind: TreeSelect, Node: node})
+}
+
+// subtreeEnd returns the index of the first row after the descendants
+// of row i.
+func (t *Tree) subtreeEnd(i int) int {
+	depth := t.rows[i].Depth
+	for i++; i < len(t.rows); i++ {
+		if t.rows[i].Depth <= depth {
+			break
+		}
+	}
+	return i
+}
+
+// index returns the row index of node, or -1 if node is not visible.
+func (t *Tree) index(node any) int {
+	if node == nil {
+		return -1
+	}
+	for i, r := range t.Rows() {
+		if r.Node == node {
+			return i
+		}
+	}
+	return -1
+}
+
+// Expand the node, loading its children if necessary. Expand reports
+// whether node has children.
+func (t *Tree) Expand(node any) bool {
+	if len(t.load(node)) == 0 {
+		return false
+	}
+	if t.expanded == nil {
+		t.expanded = make(map[any]bool)
+	}
+	if !t.expanded[node] {
+		t.expanded[node] = true
+		t.rowsValid = false
+	}
+	return true
+}
+
+// Collapse the node, hiding its descendants.
+func (t *Tree) Collapse(node any) {
+	if t.expanded[node] {
+		delete(t.expanded, node)
+		t.rowsValid = false
+	}
+}
+
+// Expanded reports whether node is expanded.
+func (t *Tree) Expanded(node any) bool {
+	return t.expanded[node]
+}
+
+// Reload discards the children loaded for node and its descendants, such
+// that they're loaded again when displayed. A nil node reloads the
+// whole tree. Expanded nodes stay expanded if they're still present.
+func (t *Tree) Reload(node any) {
+	if node == nil {
+		clear(t.children)
+	} else {
+		t.unload(node)
+	}
+	t.rowsValid = false
+}
+
+func (t *Tree) unload(node any) {
+	for _, c := range t.children[node] {
+		t.unload(c)
+	}
+	delete(t.children, node)
+}
+
+// Select the node, or clear the selection if node is nil.
+func (t *Tree) Select(node any) {
+	t.selected = node
+}
+
+// Selected returns the selected node, or nil if no node is selected.
+func (t *Tree) Selected() any {
+	return t.selected
+}
+
+// Focused reports whether the tree has the keyboard focus.
+func (t *Tree) Focused() bool {
+	return t.focused
+}
+
+// Rows returns the visible rows of the tree. The slice is valid until the
+// tree changes.
+func (t *Tree) Rows() []TreeRow {
+	if !t.rowsValid {
+		t.rowsValid = true
+		t.rows = t.rows[:0]
+		t.appendRows(nil, 0)
+	}
+	return t.rows
+}
+
+func (t *Tree) appendRows(parent any, depth int) {
+	for _, n := range t.load(parent) {
+		expanded := t.expanded[n]
+		t.rows = append(t.rows, TreeRow{
+			Node:       n,
+			Depth:      depth,
+			Expanded:   expanded,
+			Expandable: expanded || t.expandable(n),
+		})
+		if expanded {
+			t.appendRows(n, depth+1)
+		}
+	}
+}
+
+func (t *Tree) expandable(node any) bool {
+	if c, ok := t.children[node]; ok {
+		return len(c) > 0
+	}
+	if t.HasChildren != nil {
+		return t.HasChildren(node)
+	}
+	return true
+}
+
+// load returns the children of node, calling Children if they're not
+// loaded.
+func (t *Tree) load(node any) []any {
+	if c, ok := t.children[node]; ok {
+		return c
+	}
+	if t.Children == nil {
+		return nil
+	}
+	c := t.Children(node)
+	if t.children == nil {
+		t.children = make(map[any][]any)
+	}
+	t.children[node] = c
+	return c
+}
+
+func (t *Tree) item(node any) *treeItem {
+	it, ok := t.items[node]
+	if !ok {
+		if t.items == nil {
+			t.items = make(map[any]*treeItem)
+		}
+		it = new(treeItem)
+		t.items[node] = it
+	}
+	return it
+}
+
+// Layout the visible rows of the tree with w.
+func (t *Tree) Layout(gtx layout.Context, w TreeElement) layout.Dimensions {
+	return t.LayoutList(gtx, t.List.List.Layout, w)
+}
+
+// LayoutList is like Layout, but lays out the rows with list, such as the
+// Layout method of a list style that draws scroll bars.
+func (t *Tree) LayoutList(gtx layout.Context, list func(gtx layout.Context, n int, w layout.ListElement) layout.Dimensions, w TreeElement) layout.Dimensions {
+	t.update(gtx)
+	t.List.Axis = layout.Vertical
+	if t.reveal {
+		t.reveal = false
+		if i := t.index(t.selected); i != -1 {
+			scrollIntoView(&t.List.List, i, t.viewSize)
+		}
+	}
+	for _, it := range t.items {
+		it.used = false
+	}
+	rows := t.Rows()
+	m := op.Record(gtx.Ops)
+	dims := list(gtx, len(rows), func(gtx layout.Context, i int) layout.Dimensions {
+		return t.layoutRow(gtx, rows[i], w)
+	})
+	call := m.Stop()
+	for n, it := range t.items {
+		if !it.used {
+			delete(t.items, n)
+		}
+	}
+	t.viewSize = dims.Size.Y
+	defer clip.Rect{Max: dims.Size}.Push(gtx.Ops).Pop()
+	event.Op(gtx.Ops, t)
+	call.Add(gtx.Ops)
+	return dims
+}
+
+func (t *Tree) layoutRow(gtx layout.Context, row TreeRow, w TreeElement) layout.Dimensions {
+	it := t.item(row.Node)
+	it.used = true
+	m := op.Record(gtx.Ops)
+	dims := w(gtx, row)
+	c := m.Stop()
+	defer clip.Rect{Max: dims.Size}.Push(gtx.Ops).Pop()
+	it.click.Add(gtx.Ops)
+	semantic.SelectedOp(row.Node == t.selected).Add(gtx.Ops)
+	c.Add(gtx.Ops)
+	return dims
+}
+
+// LayoutExpander lays out w as the button that expands and collapses the
+// node of row. It is meant to be called by a TreeElement.
+func (t *Tree) LayoutExpander(gtx layout.Context, row TreeRow, w layout.Widget) layout.Dimensions {
+	return t.item(row.Node).expander.Layout(gtx, w)
+}
+
+func (k TreeEventKind) String() string {
+	switch k {
+	case TreeSelect:
+		return "TreeSelect"
+	case TreeExpand:
+		return "TreeExpand"
+	case TreeCollapse:
+		return "TreeCollapse"
+	case TreeActivate:
+		return "TreeActivate"
+	default:
+		panic("invalid TreeEventKind")
+	}
+}
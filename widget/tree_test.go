@@ -0,0 +1,140 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package widget
+
+import (
+	"fmt"
+	"image"
+	"slices"
+	"strings"
+	"testing"
+
+	"gioui.org/f32"
+	"gioui.org/io/input"
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+)
+
+func TestTree(t *testing.T) {
+	var loaded []any
+	tree := &Tree{
+		// Every node above depth 3 has 3 children.
+		Children: func(node any) []any {
+			loaded = append(loaded, node)
+			parent, _ := node.(string)
+			if strings.Count(parent, "/") == 3 {
+				return nil
+			}
+			var children []any
+			for i := range 3 {
+				children = append(children, fmt.Sprintf("%s/%d", parent, i))
+			}
+			return children
+		},
+	}
+	nodes := func() []string {
+		var nodes []string
+		for _, r := range tree.Rows() {
+			nodes = append(nodes, r.Node.(string))
+		}
+		return nodes
+	}
+	if got, want := nodes(), []string{"/0", "/1", "/2"}; !slices.Equal(got, want) {
+		t.Fatalf("got rows %v, want %v", got, want)
+	}
+	if !slices.Equal(loaded, []any{nil}) {
+		t.Errorf("loaded %v before expanding", loaded)
+	}
+	if !tree.Expand("/1") {
+		t.Fatal("failed to expand node with children")
+	}
+	if got, want := nodes(), []string{"/0", "/1", "/1/0", "/1/1", "/1/2", "/2"}; !slices.Equal(got, want) {
+		t.Errorf("got rows %v, want %v", got, want)
+	}
+	if r := tree.Rows()[2]; r.Depth != 1 || r.Expanded || !r.Expandable {
+		t.Errorf("got row %+v", r)
+	}
+	if tree.Expand("/1/0/0") {
+		t.Error("expanded a leaf")
+	}
+
+	r := new(input.Router)
+	gtx := layout.Context{
+		Ops:         new(op.Ops),
+		Constraints: layout.Exact(image.Pt(100, 30)),
+		Source:      r.Source(),
+	}
+	frame := func() {
+		gtx.Ops.Reset()
+		tree.Layout(gtx, func(gtx layout.Context, row TreeRow) layout.Dimensions {
+			return layout.Dimensions{Size: image.Pt(gtx.Constraints.Max.X, 10)}
+		})
+		r.Frame(gtx.Ops)
+	}
+	var events []TreeEvent
+	update := func() {
+		events = events[:0]
+		for {
+			e, ok := tree.Update(gtx)
+			if !ok {
+				break
+			}
+			events = append(events, e)
+		}
+	}
+	// The rows process events from the second frame on.
+	frame()
+	frame()
+	r.Queue(
+		pointer.Event{Kind: pointer.Press, Buttons: pointer.ButtonPrimary, Position: f32.Pt(5, 15)},
+		pointer.Event{Kind: pointer.Release, Position: f32.Pt(5, 15)},
+	)
+	update()
+	if want := []TreeEvent{{TreeSelect, "/1"}}; !slices.Equal(events, want) {
+		t.Errorf("got events %v after click, want %v", events, want)
+	}
+	frame()
+	if !tree.Focused() {
+		t.Error("click didn't focus the tree")
+	}
+	keys := func(names ...key.Name) {
+		for _, n := range names {
+			r.Queue(key.Event{Name: n, State: key.Press})
+		}
+		update()
+		frame()
+	}
+	// Move to the last child of /1 and expand it, scrolling it into view.
+	keys(key.NameDownArrow, key.NameDownArrow, key.NameDownArrow, key.NameRightArrow)
+	want := []TreeEvent{{TreeSelect, "/1/0"}, {TreeSelect, "/1/1"}, {TreeSelect, "/1/2"}, {TreeExpand, "/1/2"}}
+	if !slices.Equal(events, want) {
+		t.Errorf("got events %v, want %v", events, want)
+	}
+	if first := tree.List.Position.First; first == 0 {
+		t.Error("selection didn't scroll into view")
+	}
+	keys(key.NameRightArrow, key.NameLeftArrow, key.NameLeftArrow)
+	want = []TreeEvent{{TreeSelect, "/1/2/0"}, {TreeSelect, "/1/2"}, {TreeCollapse, "/1/2"}}
+	if !slices.Equal(events, want) {
+		t.Errorf("got events %v, want %v", events, want)
+	}
+	keys(key.NameLeftArrow, key.NameLeftArrow, key.NameReturn)
+	want = []TreeEvent{{TreeSelect, "/1"}, {TreeCollapse, "/1"}, {TreeActivate, "/1"}}
+	if !slices.Equal(events, want) {
+		t.Errorf("got events %v, want %v", events, want)
+	}
+	if got, want := nodes(), []string{"/0", "/1", "/2"}; !slices.Equal(got, want) {
+		t.Errorf("got rows %v after collapse, want %v", got, want)
+	}
+	// Children are loaded once.
+	if got, want := loaded, []any{nil, "/1", "/1/0/0", "/1/2"}; !slices.Equal(got, want) {
+		t.Errorf("loaded children of %v, want %v", got, want)
+	}
+	tree.Reload(nil)
+	tree.Rows()
+	if n := len(loaded); n != 5 {
+		t.Errorf("reload didn't load the top level nodes")
+	}
+}